@@ -0,0 +1,174 @@
+package access
+
+import (
+	"fmt"
+
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/theme"
+)
+
+// Option is an accessibility setting the player can change
+type Option int
+
+const (
+	// Outlines draws outlines around the snake and berry so they can be told apart without colour.
+	Outlines Option = iota
+	// TextSize makes the game text larger.
+	TextSize
+	// SpeedRamp sets how much faster the snake gets each time it eats.
+	SpeedRamp
+	// Assist highlights the squares the snake can safely move into next.
+	Assist
+	// Effects turns the particle, pop-up and trail effects on or off, or leaves them on without the flash on death.
+	Effects
+	// NumOptions is the number of options, it can be used to loop over all options.
+	NumOptions
+)
+
+// optionNames are the names used for each option in the settings file and on screen
+var optionNames = map[Option]string{
+	Outlines:  "Outlines",
+	TextSize:  "Text Size",
+	SpeedRamp: "Speed Ramp",
+	Assist:    "Assist",
+	Effects:   "Effects",
+}
+
+// textScales are the text sizes which can be chosen, larger sizes don't fit the controls panel
+var textScales = []float64{1, 1.25}
+
+// speedRamps are the speed increases which can be chosen, the first is the original game's ramp
+var speedRamps = []float64{1, 0.5, 0.25}
+
+// effectLevels are the effect settings which can be chosen, in order
+var effectLevels = []string{"On", "No Flash", "Off"}
+
+// String returns the display name of the option
+func (o Option) String() string {
+	return optionNames[o]
+}
+
+// Type holds the accessibility options
+type Type struct {
+	outlines  bool
+	textScale int
+	speedRamp int
+	assist    bool
+	effects   int
+	settings  *settings.Type
+}
+
+// NewAccess creates a new accessibility options struct, loading any saved options from the settings provided
+func NewAccess(set *settings.Type) Type {
+	t := new(Type)
+	t.settings = set
+	t.outlines = set.GetBool("access.Outlines", false)
+	t.textScale = indexOf(textScales, set.GetFloat("access.TextSize", textScales[0]))
+	t.speedRamp = indexOf(speedRamps, set.GetFloat("access.SpeedRamp", speedRamps[0]))
+	t.assist = set.GetBool("access.Assist", false)
+	t.effects = set.GetInt("access.Effects", 0)
+	if t.effects < 0 || t.effects >= len(effectLevels) {
+		t.effects = 0
+	}
+	return *t
+}
+
+// GetOutlines returns true if outlines should be drawn around the snake and berry
+func (t *Type) GetOutlines() bool {
+	return t.outlines
+}
+
+// GetTextScale returns the multiplier for the size of the game text
+func (t *Type) GetTextScale() float64 {
+	return textScales[t.textScale]
+}
+
+// GetSpeedRamp returns how much the snake speed increases each time it eats
+func (t *Type) GetSpeedRamp() float64 {
+	return speedRamps[t.speedRamp]
+}
+
+// GetAssist returns true if the safe squares next to the snake's head should be highlighted
+func (t *Type) GetAssist() bool {
+	return t.assist
+}
+
+// GetEffects returns true if the effects should be played
+func (t *Type) GetEffects() bool {
+	return effectLevels[t.effects] != "Off"
+}
+
+// GetFlash returns true if the game area should flash when the snake dies
+func (t *Type) GetFlash() bool {
+	return effectLevels[t.effects] == "On"
+}
+
+// Next changes the option to its next value and saves the options
+func (t *Type) Next(o Option) {
+	switch o {
+	case Outlines:
+		t.outlines = !t.outlines
+	case TextSize:
+		t.textScale = (t.textScale + 1) % len(textScales)
+	case SpeedRamp:
+		t.speedRamp = (t.speedRamp + 1) % len(speedRamps)
+	case Assist:
+		t.assist = !t.assist
+	case Effects:
+		t.effects = (t.effects + 1) % len(effectLevels)
+	}
+	t.settings.SetBool("access.Outlines", t.outlines)
+	t.settings.SetFloat("access.TextSize", t.GetTextScale())
+	t.settings.SetFloat("access.SpeedRamp", t.GetSpeedRamp())
+	t.settings.SetBool("access.Assist", t.assist)
+	t.settings.SetInt("access.Effects", t.effects)
+	t.settings.SaveSettings()
+}
+
+// GetValueText returns the current value of the option for display
+func (t *Type) GetValueText(o Option) string {
+	switch o {
+	case Outlines:
+		return onOff(t.outlines)
+	case TextSize:
+		if t.textScale == 0 {
+			return "Normal"
+		}
+		return "Large"
+	case SpeedRamp:
+		if t.speedRamp == 0 {
+			return "Normal"
+		}
+		return fmt.Sprintf("x%g", t.GetSpeedRamp())
+	case Assist:
+		return onOff(t.assist)
+	case Effects:
+		return effectLevels[t.effects]
+	}
+	return ""
+}
+
+// Apply returns a copy of the theme drawn with the accessibility options
+func (t *Type) Apply(th theme.Type) theme.Type {
+	th.Outlines = t.outlines
+	th.TextScale = t.GetTextScale()
+	return th
+}
+
+// indexOf returns the index of value in values, or 0 if it isn't there
+func indexOf(values []float64, value float64) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return 0
+}
+
+// onOff returns the display text for a setting which can be turned on or off
+func onOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
@@ -0,0 +1,341 @@
+package arenas
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/benjmarshall/gopixelsnake/levels"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// Style is a way of laying out the walls of a generated arena
+type Style int
+
+const (
+	// Maze is a grid of rooms joined by gaps in the walls between them, every room can be reached and there are a
+	// few loops so the snake isn't always heading into a dead end.
+	Maze Style = iota
+	// Rooms is solid rock with rooms carved out of it, joined by corridors three squares wide.
+	Rooms
+	// Pillars is an open arena with small blocks scattered across it, never close enough together to trap the snake.
+	Pillars
+	// NumStyles is the number of styles, it can be used to loop over all styles.
+	NumStyles
+)
+
+// styleNames are the names used for each style in level names and on the command line
+var styleNames = map[Style]string{
+	Maze:    "Maze",
+	Rooms:   "Rooms",
+	Pillars: "Pillars",
+}
+
+// mazeCell is how many grid squares apart the walls of a maze are
+const mazeCell = 7
+
+// spawnRunway is how many grid squares at each end of the snake are kept clear of walls when it starts
+const spawnRunway = 6
+
+// String returns the name of the style
+func (s Style) String() string {
+	return styleNames[s]
+}
+
+// StyleFromName returns the style with the given name, ignoring case, and false if there isn't one
+func StyleFromName(name string) (Style, bool) {
+	for s, n := range styleNames {
+		if strings.EqualFold(n, name) {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// Random returns an arena for a cols by rows grid in a style picked by the seed, see Generate
+func Random(cols int, rows int, seed int64) levels.Level {
+	return Generate(Style(uint64(seed)%uint64(NumStyles)), cols, rows, seed)
+}
+
+// Generate returns an arena in the style for a cols by rows grid, the same seed always gives the same arena. Every
+// square which isn't a wall can be reached from every other, and the snake starts with its body and the squares at
+// both ends of it clear of walls, as it can start going either way. The level is named after the style and the
+// seed so it can be made again.
+func Generate(style Style, cols int, rows int, seed int64) levels.Level {
+	r := rand.New(rand.NewSource(seed))
+	a := newArena(cols, rows)
+	switch style {
+	case Maze:
+		a.maze(r)
+	case Rooms:
+		a.rooms(r)
+	case Pillars:
+		a.pillars(r)
+	}
+	l := levels.Open(fmt.Sprintf("%s-%d", strings.ToLower(style.String()), seed), cols, rows)
+	if style == Pillars {
+		l.Speed = 3
+	}
+	l.Spawn, l.Heading = a.spawn(r)
+	a.fillUnreachable(l.Spawn)
+	l.Walls = a.getWalls()
+	return l
+}
+
+// arena is the grid of squares an arena is built on, true squares are walls
+type arena struct {
+	cols  int
+	rows  int
+	walls [][]bool
+}
+
+// newArena returns a cols by rows arena without any walls
+func newArena(cols int, rows int) *arena {
+	a := &arena{cols: cols, rows: rows, walls: make([][]bool, cols)}
+	for x := range a.walls {
+		a.walls[x] = make([]bool, rows)
+	}
+	return a
+}
+
+// inside returns true if the square is on the grid
+func (a *arena) inside(x int, y int) bool {
+	return x >= 0 && y >= 0 && x < a.cols && y < a.rows
+}
+
+// fill sets the squares in the w by h block with its bottom left corner at x,y to wall, or clears them, leaving
+// any part of the block off the grid
+func (a *arena) fill(x int, y int, w int, h int, wall bool) {
+	for i := x; i < x+w; i++ {
+		for j := y; j < y+h; j++ {
+			if a.inside(i, j) {
+				a.walls[i][j] = wall
+			}
+		}
+	}
+}
+
+// clear returns true if the w by h block with its bottom left corner at x,y is on the grid and has no walls in it
+func (a *arena) clear(x int, y int, w int, h int) bool {
+	for i := x; i < x+w; i++ {
+		for j := y; j < y+h; j++ {
+			if !a.inside(i, j) || a.walls[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// empty returns true if there are no walls in the part of the w by h block with its bottom left corner at x,y
+// which is on the grid
+func (a *arena) empty(x int, y int, w int, h int) bool {
+	for i := x; i < x+w; i++ {
+		for j := y; j < y+h; j++ {
+			if a.inside(i, j) && a.walls[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// maze builds walls along the lines between maze cells and knocks gaps in them with a random depth first walk,
+// so every cell can be reached, then knocks out a few more so the maze has loops. The squares where the lines
+// cross are always walls.
+func (a *arena) maze(r *rand.Rand) {
+	nx, ny := a.cols/mazeCell, a.rows/mazeCell
+	if nx < 1 || ny < 1 {
+		return
+	}
+	lineX := func(i int) int { return i * a.cols / nx }
+	lineY := func(j int) int { return j * a.rows / ny }
+	for i := 1; i < nx; i++ {
+		a.fill(lineX(i), 0, 1, a.rows, true)
+	}
+	for j := 1; j < ny; j++ {
+		a.fill(0, lineY(j), a.cols, 1, true)
+	}
+	// open knocks a gap in the wall between cell i,j and the cell after it across or up, leaving the corners
+	open := func(i int, j int, across bool) {
+		if across {
+			a.fill(lineX(i+1), lineY(j)+1, 1, lineY(j+1)-lineY(j)-1, false)
+		} else {
+			a.fill(lineX(i)+1, lineY(j+1), lineX(i+1)-lineX(i)-1, 1, false)
+		}
+	}
+	visited := make([][]bool, nx)
+	for i := range visited {
+		visited[i] = make([]bool, ny)
+	}
+	stack := [][2]int{{r.Intn(nx), r.Intn(ny)}}
+	visited[stack[0][0]][stack[0][1]] = true
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		next := [][2]int{}
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			i, j := cell[0]+d[0], cell[1]+d[1]
+			if i >= 0 && j >= 0 && i < nx && j < ny && !visited[i][j] {
+				next = append(next, [2]int{i, j})
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[r.Intn(len(next))]
+		visited[n[0]][n[1]] = true
+		open(minInt(cell[0], n[0]), minInt(cell[1], n[1]), n[0] != cell[0])
+		stack = append(stack, n)
+	}
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			if i+1 < nx && r.Intn(6) == 0 {
+				open(i, j, true)
+			}
+			if j+1 < ny && r.Intn(6) == 0 {
+				open(i, j, false)
+			}
+		}
+	}
+}
+
+// rooms fills the arena with rock and carves out rooms with at least two squares of rock between them, joining
+// each one to the one carved before it with a corridor and adding a few extra corridors between random rooms so
+// there are loops
+func (a *arena) rooms(r *rand.Rand) {
+	a.fill(0, 0, a.cols, a.rows, true)
+	carved := newArena(a.cols, a.rows)
+	centres := [][2]int{}
+	for try := 0; try < 200 && len(centres) < 8; try++ {
+		w, h := 9+r.Intn(10), 9+r.Intn(10)
+		if w+2 > a.cols || h+2 > a.rows {
+			continue
+		}
+		x, y := 1+r.Intn(a.cols-w-1), 1+r.Intn(a.rows-h-1)
+		if !carved.empty(x-2, y-2, w+4, h+4) {
+			continue
+		}
+		carved.fill(x, y, w, h, true)
+		a.fill(x, y, w, h, false)
+		centres = append(centres, [2]int{x + w/2, y + h/2})
+	}
+	if len(centres) == 0 {
+		// The grid is too small for any rooms so the whole arena is one
+		a.fill(0, 0, a.cols, a.rows, false)
+		return
+	}
+	for i := 1; i < len(centres); i++ {
+		a.corridor(centres[i-1], centres[i])
+	}
+	for i := 0; i < len(centres)/3; i++ {
+		a.corridor(centres[r.Intn(len(centres))], centres[r.Intn(len(centres))])
+	}
+}
+
+// corridor carves a corridor three squares wide from one grid square to another, across and then up or down
+func (a *arena) corridor(from [2]int, to [2]int) {
+	a.fill(minInt(from[0], to[0])-1, from[1]-1, abs(to[0]-from[0])+3, 3, false)
+	a.fill(to[0]-1, minInt(from[1], to[1])-1, 3, abs(to[1]-from[1])+3, false)
+}
+
+// pillars scatters blocks of one to three squares across the arena, with at least three clear squares between
+// them and the edges so the snake can always get round them
+func (a *arena) pillars(r *rand.Rand) {
+	want := a.cols * a.rows / 150
+	for try, placed := 0, 0; try < want*20 && placed < want; try++ {
+		w, h := 1+r.Intn(3), 1+r.Intn(3)
+		x, y := r.Intn(a.cols), r.Intn(a.rows)
+		if !a.clear(x-3, y-3, w+6, h+6) {
+			continue
+		}
+		a.fill(x, y, w, h, true)
+		placed++
+	}
+}
+
+// spawn returns a random grid square and heading for the snake where its body, the runways at both ends of it and
+// the squares either side of them are clear of walls. If there isn't anywhere like that a space is cleared for it
+// in the middle of the arena.
+func (a *arena) spawn(r *rand.Rand) (pixel.Vec, snake.Direction) {
+	headings := []snake.Direction{snake.UP, snake.DOWN, snake.LEFT, snake.RIGHT}
+	for _, i := range r.Perm(a.cols * a.rows * len(headings)) {
+		x, y, d := i%a.cols, i/a.cols%a.rows, headings[i/(a.cols*a.rows)]
+		if sx, sy, w, h := spawnArea(x, y, d); a.clear(sx, sy, w, h) {
+			return pixel.V(float64(x), float64(y)), d
+		}
+	}
+	x, y := a.cols/2, a.rows/2
+	sx, sy, w, h := spawnArea(x, y, snake.UP)
+	a.fill(sx, sy, w, h, false)
+	return pixel.V(float64(x), float64(y)), snake.UP
+}
+
+// spawnArea returns the block, bottom left corner, width and height, which needs to be clear for a snake starting
+// with its head at x,y heading in the direction d, or going the other way
+func spawnArea(x int, y int, d snake.Direction) (int, int, int, int) {
+	long := snake.StartLength + 2*spawnRunway
+	switch d {
+	case snake.DOWN:
+		return x - 1, y - spawnRunway, 3, long
+	case snake.LEFT:
+		return x - spawnRunway, y - 1, long, 3
+	case snake.RIGHT:
+		return x - snake.StartLength - spawnRunway + 1, y - 1, long, 3
+	}
+	return x - 1, y - snake.StartLength - spawnRunway + 1, 3, long
+}
+
+// fillUnreachable turns every square which can't be reached from the grid square start into a wall, so berries
+// are never put somewhere the snake can't get to
+func (a *arena) fillUnreachable(start pixel.Vec) {
+	reached := newArena(a.cols, a.rows)
+	queue := [][2]int{{int(start.X), int(start.Y)}}
+	reached.walls[queue[0][0]][queue[0][1]] = true
+	for len(queue) > 0 {
+		square := queue[0]
+		queue = queue[1:]
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			x, y := square[0]+d[0], square[1]+d[1]
+			if a.inside(x, y) && !a.walls[x][y] && !reached.walls[x][y] {
+				reached.walls[x][y] = true
+				queue = append(queue, [2]int{x, y})
+			}
+		}
+	}
+	for x := range a.walls {
+		for y := range a.walls[x] {
+			a.walls[x][y] = a.walls[x][y] || !reached.walls[x][y]
+		}
+	}
+}
+
+// getWalls returns the grid squares which are walls, column by column
+func (a *arena) getWalls() []pixel.Vec {
+	walls := []pixel.Vec{}
+	for x := range a.walls {
+		for y := range a.walls[x] {
+			if a.walls[x][y] {
+				walls = append(walls, pixel.V(float64(x), float64(y)))
+			}
+		}
+	}
+	return walls
+}
+
+// minInt returns the smaller of two ints
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// abs returns the absolute value of an int
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
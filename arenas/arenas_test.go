@@ -0,0 +1,52 @@
+package arenas
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestGenerate(t *testing.T) {
+	// The grids of the window and the terminal
+	for _, size := range [][2]int{{70, 70}, {40, 40}} {
+		cols, rows := size[0], size[1]
+		for style := Style(0); style < NumStyles; style++ {
+			for seed := int64(0); seed < 50; seed++ {
+				l := Generate(style, cols, rows, seed)
+				if err := l.Check(cols, rows); err != nil {
+					t.Errorf("%s on a %dx%d grid: %v", l.Name, cols, rows, err)
+					continue
+				}
+				// The snake can start either way, so the runway has to be clear in front of its head and behind
+				// its tail
+				heading := l.Heading.GetVec()
+				squares := l.StartSquares()
+				tail := squares[len(squares)-1]
+				for i := 1; i <= spawnRunway; i++ {
+					for _, square := range []pixel.Vec{l.Spawn.Add(heading.Scaled(float64(i))), tail.Sub(heading.Scaled(float64(i)))} {
+						if square.X < 0 || square.Y < 0 || square.X >= float64(cols) || square.Y >= float64(rows) || l.IsWall(square) {
+							t.Errorf("%s on a %dx%d grid: the runway isn't clear at %v", l.Name, cols, rows, square)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateSameSeed(t *testing.T) {
+	for style := Style(0); style < NumStyles; style++ {
+		a := Generate(style, 70, 70, 12345)
+		b := Generate(style, 70, 70, 12345)
+		if a.Spawn != b.Spawn || a.Heading != b.Heading || len(a.Walls) != len(b.Walls) {
+			t.Errorf("%s: the same seed gave different arenas", style)
+			continue
+		}
+		for i := range a.Walls {
+			if a.Walls[i] != b.Walls[i] {
+				t.Errorf("%s: the same seed gave different walls", style)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/benjmarshall/gopixelsnake/settings"
+)
+
+// Sound is a sound effect the game can play
+type Sound int
+
+const (
+	// Eat is played when the snake eats a berry.
+	Eat Sound = iota
+	// Shrink is played when the snake eats a poison berry.
+	Shrink
+	// Turn is played when the snake changes direction.
+	Turn
+	// SpeedUp is played when the snake gets faster.
+	SpeedUp
+	// Death is played when the snake dies.
+	Death
+	// HighScore is played when a game ends with a new high score.
+	HighScore
+	// Menu is played when the player moves around the menus.
+	Menu
+	// PowerUp is played when the snake collects a power-up.
+	PowerUp
+	// PowerDown is played when a power-up wears off or the shield is used up.
+	PowerDown
+	// Teleport is played when the snake goes through a portal.
+	Teleport
+	// NumSounds is the number of sounds, it can be used to loop over all sounds.
+	NumSounds
+)
+
+// soundNames are the names used for each sound
+var soundNames = map[Sound]string{
+	Eat:       "Eat",
+	Shrink:    "Shrink",
+	Turn:      "Turn",
+	SpeedUp:   "Speed Up",
+	Death:     "Death",
+	HighScore: "High Score",
+	Menu:      "Menu",
+	PowerUp:   "Power Up",
+	PowerDown: "Power Down",
+	Teleport:  "Teleport",
+}
+
+// String returns the name of the sound
+func (s Sound) String() string {
+	return soundNames[s]
+}
+
+// Channel is one of the volumes the player can set
+type Channel int
+
+const (
+	// Master is the volume of everything.
+	Master Channel = iota
+	// Effects is the volume of the sound effects.
+	Effects
+	// Music is the volume of the background music.
+	Music
+	// NumChannels is the number of channels, it can be used to loop over all channels.
+	NumChannels
+)
+
+// channelNames are the names used for each channel in the settings file and on screen
+var channelNames = map[Channel]string{
+	Master:  "Volume",
+	Effects: "Sound",
+	Music:   "Music",
+}
+
+// defaultVolumes are the volumes used for each channel until the player changes them
+var defaultVolumes = map[Channel]float64{
+	Master:  1,
+	Effects: 0.75,
+	Music:   0.5,
+}
+
+// volumeSteps are the volumes which can be chosen for each channel
+var volumeSteps = []float64{0, 0.25, 0.5, 0.75, 1}
+
+// String returns the display name of the channel
+func (c Channel) String() string {
+	return channelNames[c]
+}
+
+// Backend is implemented by each of the ways the game's audio can be played
+type Backend interface {
+	// PlaySound starts a sound effect at the volume given, from 0 to 1, mixed over anything already playing
+	PlaySound(s Sound, volume float64)
+	// SetMusic sets the volume of the looping background music, 0 stops it, and its tempo, 1 is the normal tempo
+	SetMusic(volume float64, tempo float64)
+}
+
+// Type mixes the sound effects and music at the volumes chosen by the player, and plays them with a backend
+type Type struct {
+	backend  Backend
+	volumes  [NumChannels]int
+	playing  bool
+	tempo    float64
+	settings *settings.Type
+}
+
+// NewAudio creates a new audio struct which plays through the backend, loading any saved volumes from the settings provided
+func NewAudio(backend Backend, set *settings.Type) Type {
+	t := new(Type)
+	t.backend = backend
+	t.settings = set
+	t.tempo = 1
+	for c := Channel(0); c < NumChannels; c++ {
+		t.volumes[c] = nearest(set.GetFloat("audio."+c.String(), defaultVolumes[c]))
+	}
+	return *t
+}
+
+// SetBackend changes the backend the audio is played with, the music carries on with the new backend
+func (t *Type) SetBackend(backend Backend) {
+	t.backend.SetMusic(0, t.tempo)
+	t.backend = backend
+	t.updateMusic()
+}
+
+// Play plays a sound effect
+func (t *Type) Play(s Sound) {
+	volume := t.GetVolume(Master) * t.GetVolume(Effects)
+	if volume > 0 {
+		t.backend.PlaySound(s, volume)
+	}
+}
+
+// SetMusic starts or stops the background music, with its tempo following the speed of the snake. The backend is
+// only told when something changes.
+func (t *Type) SetMusic(playing bool, speed float64) {
+	tempo := TempoForSpeed(speed)
+	if playing == t.playing && tempo == t.tempo {
+		return
+	}
+	t.playing = playing
+	t.tempo = tempo
+	t.updateMusic()
+}
+
+// TempoForSpeed returns the music tempo for a snake speed, it speeds up with the snake but more gently so it stays
+// musical, starting at the normal tempo for a new snake
+func TempoForSpeed(speed float64) float64 {
+	return math.Sqrt(math.Max(speed, 1) / 2)
+}
+
+// GetVolume returns the volume of the channel, from 0 to 1
+func (t *Type) GetVolume(c Channel) float64 {
+	return volumeSteps[t.volumes[c]]
+}
+
+// Next changes the channel to its next volume, going back to silent after the loudest, and saves the volumes
+func (t *Type) Next(c Channel) {
+	t.volumes[c] = (t.volumes[c] + 1) % len(volumeSteps)
+	for c := Channel(0); c < NumChannels; c++ {
+		t.settings.SetFloat("audio."+c.String(), t.GetVolume(c))
+	}
+	t.settings.SaveSettings()
+	t.updateMusic()
+}
+
+// GetValueText returns the volume of the channel for display
+func (t *Type) GetValueText(c Channel) string {
+	if t.volumes[c] == 0 {
+		return "Off"
+	}
+	return fmt.Sprintf("%d%%", int(t.GetVolume(c)*100))
+}
+
+// updateMusic tells the backend the music volume and tempo
+func (t *Type) updateMusic() {
+	volume := 0.0
+	if t.playing {
+		volume = t.GetVolume(Master) * t.GetVolume(Music)
+	}
+	t.backend.SetMusic(volume, t.tempo)
+}
+
+// nearest returns the index of the volume step closest to volume
+func nearest(volume float64) int {
+	best := 0
+	for i, v := range volumeSteps {
+		if math.Abs(v-volume) < math.Abs(volumeSteps[best]-volume) {
+			best = i
+		}
+	}
+	return best
+}
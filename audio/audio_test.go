@@ -0,0 +1,137 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+	"github.com/shibukawa/configdir"
+)
+
+const testSettingsFile = "audio_test_settings.csv"
+
+// newTestAudio returns audio playing on a recorder with the channel volumes provided, the settings file is removed
+// when the test finishes as changing the volumes saves it
+func newTestAudio(t *testing.T, master, effects, music float64) (Type, *Recorder) {
+	t.Cleanup(func() {
+		folder := configdir.New("benjmarshall", "gopixelsnake").QueryFolderContainsFile(testSettingsFile)
+		if folder != nil {
+			os.Remove(filepath.Join(folder.Path, testSettingsFile))
+		}
+	})
+	set := settings.NewSettings(testSettingsFile)
+	set.SetFloat("audio."+Master.String(), master)
+	set.SetFloat("audio."+Effects.String(), effects)
+	set.SetFloat("audio."+Music.String(), music)
+	rec := NewRecorder()
+	return NewAudio(rec, &set), rec
+}
+
+func TestPlayVolume(t *testing.T) {
+	tests := []struct {
+		name    string
+		master  float64
+		effects float64
+		want    []Played
+	}{
+		{"full volume", 1, 1, []Played{{Eat, 1}}},
+		{"channels multiply", 0.5, 0.5, []Played{{Eat, 0.25}}},
+		{"master only", 0.75, 1, []Played{{Eat, 0.75}}},
+		{"silent effects", 1, 0, []Played{}},
+		{"silent master", 0, 1, []Played{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, rec := newTestAudio(t, tt.master, tt.effects, 0.5)
+			a.Play(Eat)
+			got := rec.GetPlayed()
+			if len(got) != len(tt.want) {
+				t.Fatalf("played %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("played %v, want %v", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNextWraps(t *testing.T) {
+	a, _ := newTestAudio(t, 1, 0.5, 0.5)
+	want := []string{"75%", "100%", "Off", "25%", "50%"}
+	for _, text := range want {
+		a.Next(Effects)
+		if got := a.GetValueText(Effects); got != text {
+			t.Errorf("GetValueText(Effects) = %q, want %q", got, text)
+		}
+	}
+	if got := a.GetVolume(Master); got != 1 {
+		t.Errorf("changing the effects volume changed the master volume to %v", got)
+	}
+}
+
+func TestMusic(t *testing.T) {
+	a, rec := newTestAudio(t, 1, 1, 0.5)
+	a.SetMusic(true, 2)
+	if volume, _ := rec.GetMusic(); volume != 0.5 {
+		t.Errorf("music volume = %v, want 0.5", volume)
+	}
+	// Turning the music down to nothing stops it even though the game is still running
+	a.Next(Music)
+	a.Next(Music)
+	if volume, _ := rec.GetMusic(); volume != 1 {
+		t.Errorf("music volume = %v, want 1", volume)
+	}
+	a.Next(Music)
+	if volume, _ := rec.GetMusic(); volume != 0 {
+		t.Errorf("music volume = %v after it was turned off, want 0", volume)
+	}
+	a.Next(Music)
+	if volume, _ := rec.GetMusic(); volume != 0.25 {
+		t.Errorf("music volume = %v after it was turned back on, want 0.25", volume)
+	}
+	a.SetMusic(false, 2)
+	if volume, _ := rec.GetMusic(); volume != 0 {
+		t.Errorf("music volume = %v when stopped, want 0", volume)
+	}
+}
+
+func TestMusicSilentMaster(t *testing.T) {
+	a, rec := newTestAudio(t, 0, 1, 1)
+	a.SetMusic(true, 2)
+	if volume, _ := rec.GetMusic(); volume != 0 {
+		t.Errorf("music volume = %v with the master volume off, want 0", volume)
+	}
+}
+
+func TestTempoFollowsSpeed(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	s := snake.NewSnake(gameCFG)
+	a, rec := newTestAudio(t, 1, 1, 1)
+	a.SetMusic(true, s.GetSpeed())
+	if _, tempo := rec.GetMusic(); tempo != 1 {
+		t.Errorf("tempo = %v for a new snake, want 1", tempo)
+	}
+	last := 1.0
+	for i := 0; i < 4; i++ {
+		s.IncreaseSpeed()
+		a.SetMusic(true, s.GetSpeed())
+		_, tempo := rec.GetMusic()
+		if tempo != TempoForSpeed(s.GetSpeed()) {
+			t.Errorf("tempo = %v at speed %v, want %v", tempo, s.GetSpeed(), TempoForSpeed(s.GetSpeed()))
+		}
+		if tempo <= last {
+			t.Errorf("tempo = %v at speed %v, want it faster than %v", tempo, s.GetSpeed(), last)
+		}
+		last = tempo
+	}
+	// The tempo never drops below normal, even for a speed slower than a new snake
+	if tempo := TempoForSpeed(0); tempo != TempoForSpeed(1) {
+		t.Errorf("TempoForSpeed(0) = %v, want %v", tempo, TempoForSpeed(1))
+	}
+}
@@ -0,0 +1,58 @@
+package audio
+
+// Null is a Backend which plays nothing, it is used when there is no sound device
+type Null struct{}
+
+// PlaySound does nothing
+func (Null) PlaySound(s Sound, volume float64) {}
+
+// SetMusic does nothing
+func (Null) SetMusic(volume float64, tempo float64) {}
+
+// Played is a sound effect played on a Recorder and the volume it was played at
+type Played struct {
+	Sound  Sound
+	Volume float64
+}
+
+// Recorder is a Backend which plays nothing but keeps a list of what it was asked to play, so the audio can be
+// checked on machines without sound hardware
+type Recorder struct {
+	played      []Played
+	musicVolume float64
+	tempo       float64
+}
+
+// NewRecorder returns a recorder which hasn't been asked to play anything yet
+func NewRecorder() *Recorder {
+	r := new(Recorder)
+	r.played = []Played{}
+	r.tempo = 1
+	return r
+}
+
+// PlaySound records the sound effect
+func (r *Recorder) PlaySound(s Sound, volume float64) {
+	r.played = append(r.played, Played{s, volume})
+}
+
+// SetMusic records the music volume and tempo
+func (r *Recorder) SetMusic(volume float64, tempo float64) {
+	r.musicVolume = volume
+	r.tempo = tempo
+}
+
+// GetPlayed returns the sound effects played so far, oldest first
+func (r *Recorder) GetPlayed() []Played {
+	return r.played
+}
+
+// GetMusic returns the music volume, which is 0 if the music is stopped, and tempo last set
+func (r *Recorder) GetMusic() (volume float64, tempo float64) {
+	return r.musicVolume, r.tempo
+}
+
+// Clear forgets the sound effects played so far
+func (r *Recorder) Clear() {
+	r.played = []Played{}
+}
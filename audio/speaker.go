@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+)
+
+// sampleRate is the rate the audio is generated and played at
+const sampleRate = beep.SampleRate(44100)
+
+// loudness scales every voice down so several can play at once before the mix has to be clipped
+const loudness = 0.25
+
+// note is a tone of freq Hz lasting for length seconds at the normal tempo, a frequency of 0 is a rest
+type note struct {
+	freq   float64
+	length float64
+}
+
+// sounds are the notes played for each sound effect
+var sounds = map[Sound][]note{
+	Eat:       {{660, 0.05}, {880, 0.08}},
+	Shrink:    {{220, 0.06}, {165, 0.1}},
+	Turn:      {{330, 0.03}},
+	SpeedUp:   {{0, 0.1}, {523, 0.04}, {659, 0.04}, {784, 0.06}},
+	Death:     {{440, 0.12}, {330, 0.12}, {220, 0.12}, {110, 0.3}},
+	HighScore: {{523, 0.1}, {659, 0.1}, {784, 0.1}, {1047, 0.3}},
+	Menu:      {{880, 0.02}},
+	PowerUp:   {{392, 0.04}, {523, 0.04}, {784, 0.04}, {1047, 0.08}},
+	PowerDown: {{784, 0.05}, {523, 0.05}, {392, 0.08}},
+	Teleport:  {{1047, 0.03}, {523, 0.03}, {1047, 0.03}, {523, 0.05}},
+}
+
+// melody is the background music, which is played on a loop
+var melody = []note{
+	{110, 0.25}, {0, 0.25}, {165, 0.25}, {110, 0.25},
+	{131, 0.25}, {0, 0.25}, {147, 0.25}, {165, 0.25},
+	{110, 0.25}, {0, 0.25}, {165, 0.25}, {110, 0.25},
+	{98, 0.25}, {0, 0.25}, {123, 0.25}, {147, 0.25},
+}
+
+// voice plays a list of notes as a square wave, once or on a loop
+type voice struct {
+	notes  []note
+	volume float64
+	loop   bool
+	index  int
+	pos    float64
+	phase  float64
+}
+
+// next returns the voice's next sample, moving through the notes tempo times faster than normal
+func (v *voice) next(tempo float64) float64 {
+	if v.done() {
+		return 0
+	}
+	n := v.notes[v.index]
+	sample := 0.0
+	if n.freq > 0 {
+		// Fade each note out so they don't click when they stop
+		sample = v.volume * loudness * (1 - v.pos/n.length)
+		if v.phase >= 0.5 {
+			sample = -sample
+		}
+		v.phase = math.Mod(v.phase+n.freq/float64(sampleRate), 1)
+	}
+	v.pos += tempo / float64(sampleRate)
+	if v.pos >= n.length {
+		v.pos = 0
+		v.index++
+		if v.loop {
+			v.index %= len(v.notes)
+		}
+	}
+	return sample
+}
+
+// done returns true once the voice has played all its notes
+func (v *voice) done() bool {
+	return v.index >= len(v.notes)
+}
+
+// Speaker is a Backend which plays through the computer's sound device. It mixes the sound effects and music
+// together itself and streams the mix to the speaker.
+type Speaker struct {
+	voices []*voice
+	music  voice
+	tempo  float64
+}
+
+// NewSpeaker opens the sound device and starts streaming to it
+func NewSpeaker() (*Speaker, error) {
+	if err := speaker.Init(sampleRate, sampleRate.N(time.Second/20)); err != nil {
+		return nil, err
+	}
+	sp := new(Speaker)
+	sp.music = voice{notes: melody, loop: true}
+	sp.tempo = 1
+	speaker.Play(sp)
+	return sp, nil
+}
+
+// PlaySound starts a sound effect
+func (sp *Speaker) PlaySound(s Sound, volume float64) {
+	speaker.Lock()
+	sp.voices = append(sp.voices, &voice{notes: sounds[s], volume: volume})
+	speaker.Unlock()
+}
+
+// SetMusic sets the music volume and tempo, the music starts again from the beginning after it is stopped
+func (sp *Speaker) SetMusic(volume float64, tempo float64) {
+	speaker.Lock()
+	if volume == 0 {
+		sp.music.index, sp.music.pos = 0, 0
+	}
+	sp.music.volume = volume
+	sp.tempo = tempo
+	speaker.Unlock()
+}
+
+// Stream fills samples with the mix of everything playing, it is called by the speaker
+func (sp *Speaker) Stream(samples [][2]float64) (n int, ok bool) {
+	for i := range samples {
+		mix := 0.0
+		playing := sp.voices[:0]
+		for _, v := range sp.voices {
+			mix += v.next(1)
+			if !v.done() {
+				playing = append(playing, v)
+			}
+		}
+		sp.voices = playing
+		if sp.music.volume > 0 {
+			mix += sp.music.next(sp.tempo)
+		}
+		mix = math.Max(-1, math.Min(mix, 1))
+		samples[i] = [2]float64{mix, mix}
+	}
+	return len(samples), true
+}
+
+// Err returns nil, the mix never fails
+func (sp *Speaker) Err() error {
+	return nil
+}
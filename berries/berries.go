@@ -0,0 +1,305 @@
+package berries
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/random"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/faiface/pixel"
+)
+
+// Kind is a type of berry, each does something different when it is eaten
+type Kind int
+
+const (
+	// Normal berries make the snake grow and speed up.
+	Normal Kind = iota
+	// Golden berries score five times the points of a normal berry, but disappear if they aren't eaten in time.
+	Golden
+	// Poison berries shrink the snake and score nothing.
+	Poison
+	// SlowDown berries undo one speed increase and score half the points of a normal berry.
+	SlowDown
+	// NumKinds is the number of kinds of berry, it can be used to loop over all kinds.
+	NumKinds
+)
+
+// kindNames are the names used for each kind in the settings file and on screen
+var kindNames = map[Kind]string{
+	Normal:   "Normal",
+	Golden:   "Golden",
+	Poison:   "Poison",
+	SlowDown: "SlowDown",
+}
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Points returns the score for eating a berry of this kind when the snake is moving at speed
+func (k Kind) Points(speed float64) int {
+	switch k {
+	case Normal:
+		return int(1000 * speed)
+	case Golden:
+		return int(5000 * speed)
+	case SlowDown:
+		return int(500 * speed)
+	}
+	return 0
+}
+
+// Berry is a berry on the board, Pos is in the game area coordinate plane. Berries which disappear have a
+// Lifetime and the TimeLeft before they go, berries which stay until they are eaten have a Lifetime of 0.
+type Berry struct {
+	Pos      pixel.Vec
+	Kind     Kind
+	Lifetime time.Duration
+	TimeLeft time.Duration
+}
+
+// Life returns the fraction of the berry's lifetime it has left, from 1 when it appears to 0 when it disappears
+func (b Berry) Life() float64 {
+	if b.Lifetime == 0 {
+		return 1
+	}
+	return float64(b.TimeLeft) / float64(b.Lifetime)
+}
+
+// Config sets how many berries are on the board, how likely each kind is to appear and how they behave
+type Config struct {
+	Count        int
+	Weights      [NumKinds]float64
+	GoldenLife   time.Duration
+	PoisonShrink int
+}
+
+// DefaultConfig is the config used for any values which aren't in the settings file
+var DefaultConfig = Config{
+	Count:        3,
+	Weights:      [NumKinds]float64{8, 1, 1, 1},
+	GoldenLife:   5 * time.Second,
+	PoisonShrink: 2,
+}
+
+// LoadConfig returns the berry config from the settings. The keys are "berries.Count", "berries.GoldenLife" in
+// seconds, "berries.PoisonShrink" in grid squares and the weight for each kind, e.g. "berries.GoldenWeight".
+func LoadConfig(set *settings.Type) Config {
+	d := DefaultConfig
+	c := Config{
+		Count:        set.GetInt("berries.Count", d.Count),
+		GoldenLife:   time.Duration(set.GetFloat("berries.GoldenLife", d.GoldenLife.Seconds()) * float64(time.Second)),
+		PoisonShrink: set.GetInt("berries.PoisonShrink", d.PoisonShrink),
+	}
+	for k := Kind(0); k < NumKinds; k++ {
+		c.Weights[k] = set.GetFloat("berries."+k.String()+"Weight", d.Weights[k])
+	}
+	if c.Count < 1 {
+		c.Count = 1
+	}
+	return c
+}
+
+// Type holds the berries on the board
+type Type struct {
+	config  Config
+	berries []Berry
+	src     *random.Source
+	r       *rand.Rand
+}
+
+// State is a snapshot of the berries on the board and how far through their random sequence they have got, which
+// can be saved and used to restore them later
+type State struct {
+	Berries []Berry
+	Rand    random.State
+}
+
+// NewBerries returns an empty board which is filled with berries as described by the config
+func NewBerries(config Config) Type {
+	t := new(Type)
+	t.config = config
+	t.berries = []Berry{}
+	t.SetSeed(time.Now().UnixNano())
+	return *t
+}
+
+// SetSeed restarts the sequence of random berries, so the same seed always gives the same berries in the same places
+// as long as the squares are free
+func (t *Type) SetSeed(seed int64) {
+	t.src = random.NewSource(seed)
+	t.r = rand.New(t.src)
+}
+
+// GetState returns a snapshot of the berries
+func (t *Type) GetState() State {
+	return State{Berries: append([]Berry{}, t.berries...), Rand: t.src.GetState()}
+}
+
+// SetState puts the berries back the way they were in the snapshot, carrying on the random sequence where it left off
+func (t *Type) SetState(state State) {
+	t.berries = append([]Berry{}, state.Berries...)
+	t.src.SetState(state.Rand)
+}
+
+// GetBerries returns the berries on the board
+func (t *Type) GetBerries() []Berry {
+	return t.berries
+}
+
+// GetConfig returns the config the berries were created with
+func (t *Type) GetConfig() Config {
+	return t.config
+}
+
+// Fill adds berries until the board has as many as the config asks for. Each berry is put in a random empty grid
+// square, occupied returns true for squares, in the game area coordinate plane, which already have something in
+// them. There is always at least one normal berry so the snake can keep growing.
+func (t *Type) Fill(gameCFG *game.Config, occupied func(pixel.Vec) bool) {
+	for len(t.berries) < t.config.Count {
+		pos, ok := t.emptySquare(gameCFG, occupied)
+		if !ok {
+			return
+		}
+		kind := t.randomKind()
+		if !t.has(Normal) {
+			kind = Normal
+		}
+		b := Berry{Pos: pos, Kind: kind}
+		if kind == Golden {
+			b.Lifetime = t.config.GoldenLife
+			b.TimeLeft = t.config.GoldenLife
+		}
+		t.berries = append(t.berries, b)
+	}
+}
+
+// Update counts down the time left for berries which disappear, removing them when they run out. It returns
+// true if any berries were removed, so the board needs filling again.
+func (t *Type) Update(dt time.Duration) bool {
+	left := t.berries[:0]
+	for _, b := range t.berries {
+		if b.Lifetime > 0 {
+			b.TimeLeft -= dt
+			if b.TimeLeft <= 0 {
+				continue
+			}
+		}
+		left = append(left, b)
+	}
+	removed := len(left) < len(t.berries)
+	t.berries = left
+	return removed
+}
+
+// Eat removes and returns the berry at pos, which is in the game area coordinate plane, if there is one
+func (t *Type) Eat(pos pixel.Vec) (Berry, bool) {
+	i, ok := t.find(pos)
+	if !ok {
+		return Berry{}, false
+	}
+	b := t.berries[i]
+	t.berries = append(t.berries[:i], t.berries[i+1:]...)
+	return b, true
+}
+
+// Occupies returns true if there is a berry in the grid square at pos, which is in the game area coordinate plane
+func (t *Type) Occupies(pos pixel.Vec) bool {
+	_, ok := t.find(pos)
+	return ok
+}
+
+// Attract moves each berry within reach grid squares of target one square towards it, as long as the square it
+// would move into is empty. Positions are in the game area coordinate plane.
+func (t *Type) Attract(gameCFG *game.Config, target pixel.Vec, reach int, occupied func(pixel.Vec) bool) {
+	grid := gameCFG.GetGridMatrix()
+	to := roundVec(grid.Unproject(target))
+	for i, b := range t.berries {
+		from := roundVec(grid.Unproject(b.Pos))
+		d := from.To(to)
+		if math.Abs(d.X)+math.Abs(d.Y) > float64(reach) || d == pixel.ZV {
+			continue
+		}
+		// Move along whichever axis the berry is furthest from the target on
+		step := pixel.V(math.Copysign(1, d.X), 0)
+		if math.Abs(d.Y) > math.Abs(d.X) {
+			step = pixel.V(0, math.Copysign(1, d.Y))
+		}
+		pos := grid.Project(from.Add(step))
+		if occupied(pos) || t.Occupies(pos) {
+			continue
+		}
+		t.berries[i].Pos = pos
+	}
+}
+
+// Clear removes all the berries from the board
+func (t *Type) Clear() {
+	t.berries = []Berry{}
+}
+
+// has returns true if there is a berry of the kind on the board
+func (t *Type) has(kind Kind) bool {
+	for _, b := range t.berries {
+		if b.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// randomKind picks a kind of berry at random using the weights in the config
+func (t *Type) randomKind() Kind {
+	total := 0.0
+	for _, w := range t.config.Weights {
+		total += w
+	}
+	if total <= 0 {
+		return Normal
+	}
+	pick := t.r.Float64() * total
+	for k, w := range t.config.Weights {
+		if pick < w {
+			return Kind(k)
+		}
+		pick -= w
+	}
+	return Normal
+}
+
+// emptySquare returns a random grid square, in the game area coordinate plane, which isn't occupied and doesn't
+// have a berry in it. It gives up and returns false if it can't find one.
+func (t *Type) emptySquare(gameCFG *game.Config, occupied func(pixel.Vec) bool) (pixel.Vec, bool) {
+	x, y := gameCFG.GetGameAreaDims()
+	for try := 0; try < 1000; try++ {
+		square := pixel.V(float64(t.r.Intn(int(x/gameCFG.GetGridSize()))), float64(t.r.Intn(int(y/gameCFG.GetGridSize()))))
+		pos := gameCFG.GetGridMatrix().Project(square)
+		if occupied(pos) {
+			continue
+		}
+		if _, taken := t.find(pos); taken {
+			continue
+		}
+		return pos, true
+	}
+	return pixel.ZV, false
+}
+
+// roundVec rounds a vector to the nearest grid square, so positions projected back to the game area match exactly
+func roundVec(v pixel.Vec) pixel.Vec {
+	return pixel.V(math.Round(v.X), math.Round(v.Y))
+}
+
+// find returns the index of the berry at pos, if there is one
+func (t *Type) find(pos pixel.Vec) (int, bool) {
+	for i, b := range t.berries {
+		if b.Pos == pos {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,22 @@
+package berries
+
+import (
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/faiface/pixel"
+)
+
+func TestFillReachesEverySquare(t *testing.T) {
+	// A 2x2 grid with only the top right square free, which is in the last row and column
+	gameCFG := game.NewGameConfig(20, 20, 2, 10, pixel.R(0, 0, 60, 40))
+	free := gameCFG.GetGridMatrix().Project(pixel.V(1, 1))
+	config := DefaultConfig
+	config.Count = 1
+	b := NewBerries(config)
+	b.SetSeed(1)
+	b.Fill(&gameCFG, func(pos pixel.Vec) bool { return pos != free })
+	if got := b.GetBerries(); len(got) != 1 || got[0].Pos != free {
+		t.Errorf("Fill() put the berries at %v, want one at %v", got, free)
+	}
+}
@@ -0,0 +1,283 @@
+package controls
+
+import (
+	"strings"
+
+	"github.com/benjmarshall/gopixelsnake/settings"
+)
+
+// Action is something the player can ask the game to do
+type Action int
+
+// Key is a keyboard key, named the same way as pixelgl.Button.String() names it so the bindings can be saved and
+// shown without a window
+type Key string
+
+const (
+	// MoveUp turns the snake towards the top of the game area.
+	MoveUp Action = iota
+	// MoveDown turns the snake towards the bottom of the game area.
+	MoveDown
+	// MoveLeft turns the snake towards the left of the game area.
+	MoveLeft
+	// MoveRight turns the snake towards the right of the game area.
+	MoveRight
+	// Pause pauses and resumes a running game.
+	Pause
+	// ShowScores toggles the high scores table.
+	ShowScores
+	// Rebind toggles the controls rebinding screen.
+	Rebind
+	// Confirm accepts the current choice, e.g. submitting a high score name.
+	Confirm
+	// Delete removes the last typed character.
+	Delete
+	// Quit closes the game.
+	Quit
+	// Fullscreen switches between windowed and fullscreen.
+	Fullscreen
+	// Screenshot saves the current frame as a PNG image.
+	Screenshot
+	// NextTheme switches to the next theme.
+	NextTheme
+	// Options toggles the accessibility options screen.
+	Options
+	// Levels toggles the level select screen.
+	Levels
+	// Editor toggles the level editor.
+	Editor
+	// Undo undoes the last change in the level editor.
+	Undo
+	// Redo redoes the last change undone in the level editor.
+	Redo
+	// SaveLevel saves the level being edited to a level file.
+	SaveLevel
+	// SaveGame saves the game being played so it can be resumed later.
+	SaveGame
+	// NumActions is the number of actions, it can be used to loop over all actions.
+	NumActions
+)
+
+// actionNames are the names used for each action in the settings file and on screen
+var actionNames = map[Action]string{
+	MoveUp:     "Move Up",
+	MoveDown:   "Move Down",
+	MoveLeft:   "Move Left",
+	MoveRight:  "Move Right",
+	Pause:      "Pause",
+	ShowScores: "High Scores",
+	Rebind:     "Controls",
+	Confirm:    "Confirm",
+	Delete:     "Delete",
+	Quit:       "Exit",
+	Fullscreen: "Fullscreen",
+	Screenshot: "Screenshot",
+	NextTheme:  "Theme",
+	Options:    "Options",
+	Levels:     "Levels",
+	Editor:     "Editor",
+	Undo:       "Undo",
+	Redo:       "Redo",
+	SaveLevel:  "Save Level",
+	SaveGame:   "Save Game",
+}
+
+// defaultBindings are the keys used for each action when nothing has been saved
+var defaultBindings = map[Action]Key{
+	MoveUp:     "Up",
+	MoveDown:   "Down",
+	MoveLeft:   "Left",
+	MoveRight:  "Right",
+	Pause:      "P",
+	ShowScores: "S",
+	Rebind:     "K",
+	Confirm:    "Enter",
+	Delete:     "Backspace",
+	Quit:       "X",
+	Fullscreen: "F11",
+	Screenshot: "F12",
+	NextTheme:  "T",
+	Options:    "O",
+	Levels:     "L",
+	Editor:     "E",
+	Undo:       "Z",
+	Redo:       "Y",
+	SaveLevel:  "F2",
+	SaveGame:   "F5",
+}
+
+// String returns the display name of the action
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// String returns the name of the key
+func (k Key) String() string {
+	return string(k)
+}
+
+// Type holds the mapping from actions to keyboard keys
+type Type struct {
+	bindings map[Action]Key
+	settings *settings.Type
+}
+
+// NewControls creates a new controls struct, loading any saved key bindings from the settings provided. Actions
+// without a saved binding, e.g. ones added since the bindings were saved, use their default key unless a saved
+// binding has taken it, then they are given a default key nobody is using. If saved bindings share a key they
+// can't be trusted and the defaults are used instead.
+func NewControls(set *settings.Type) Type {
+	t := new(Type)
+	t.settings = set
+	t.bindings = map[Action]Key{}
+	saved := map[Action]bool{}
+	for a := Action(0); a < NumActions; a++ {
+		t.bindings[a] = defaultBindings[a]
+		if name := set.GetString(settingsKey(a), ""); name != "" {
+			t.bindings[a] = Key(name)
+			saved[a] = true
+		}
+	}
+	t.resolveClashes(saved)
+	return *t
+}
+
+// resolveClashes makes sure no two actions share a key after the saved bindings have been loaded, see NewControls
+func (t *Type) resolveClashes(saved map[Action]bool) {
+	owners := map[Key]bool{}
+	for a := Action(0); a < NumActions; a++ {
+		if !saved[a] {
+			continue
+		}
+		if owners[t.bindings[a]] {
+			for b := Action(0); b < NumActions; b++ {
+				t.bindings[b] = defaultBindings[b]
+			}
+			return
+		}
+		owners[t.bindings[a]] = true
+	}
+	for a := Action(0); a < NumActions; a++ {
+		if !saved[a] && t.clashes(a) {
+			t.bindings[a] = t.spareKey(a, saved)
+		}
+	}
+}
+
+// clashes returns true if another action has the same key as the action
+func (t *Type) clashes(a Action) bool {
+	for other, k := range t.bindings {
+		if other != a && k == t.bindings[a] {
+			return true
+		}
+	}
+	return false
+}
+
+// spareKey returns a default key which no action other than a is using, preferring the defaults of actions which
+// have been saved with a different key. There is always one as each action has its own default key.
+func (t *Type) spareKey(a Action, saved map[Action]bool) Key {
+	used := map[Key]bool{}
+	for other, k := range t.bindings {
+		if other != a {
+			used[k] = true
+		}
+	}
+	for _, fromSaved := range []bool{true, false} {
+		for b := Action(0); b < NumActions; b++ {
+			if saved[b] == fromSaved && !used[defaultBindings[b]] {
+				return defaultBindings[b]
+			}
+		}
+	}
+	return defaultBindings[a]
+}
+
+// GetBinding returns the key currently bound to the action
+func (t *Type) GetBinding(a Action) Key {
+	return t.bindings[a]
+}
+
+// SetBinding binds a key to an action and saves it. If the key was already in use
+// the other action is given this action's old key so no two actions share a key.
+func (t *Type) SetBinding(a Action, key Key) {
+	for other, k := range t.bindings {
+		if other != a && k == key {
+			t.bindings[other] = t.bindings[a]
+			t.settings.SetString(settingsKey(other), t.bindings[other].String())
+		}
+	}
+	t.bindings[a] = key
+	t.settings.SetString(settingsKey(a), key.String())
+	t.settings.SaveSettings()
+}
+
+// ResetBindings restores the default key for every action and saves them
+func (t *Type) ResetBindings() {
+	for a := Action(0); a < NumActions; a++ {
+		t.bindings[a] = defaultBindings[a]
+		t.settings.SetString(settingsKey(a), t.bindings[a].String())
+	}
+	t.settings.SaveSettings()
+}
+
+// GetControlsText returns the lines of the controls panel for the current bindings
+func (t *Type) GetControlsText() []string {
+	move := []string{}
+	arrows := true
+	for a := MoveUp; a <= MoveRight; a++ {
+		move = append(move, t.bindings[a].String())
+		if t.bindings[a] != defaultBindings[a] {
+			arrows = false
+		}
+	}
+	moveText := strings.Join(move, " ")
+	if arrows {
+		moveText = "Arrow Keys"
+	}
+	return []string{
+		"Control Snake",
+		moveText + "\n",
+		Pause.String(),
+		t.bindings[Pause].String() + "\n",
+		ShowScores.String(),
+		t.bindings[ShowScores].String() + "\n",
+		Rebind.String(),
+		t.bindings[Rebind].String() + "\n",
+		Options.String(),
+		t.bindings[Options].String() + "\n",
+		Levels.String(),
+		t.bindings[Levels].String() + "\n",
+		Editor.String(),
+		t.bindings[Editor].String() + "\n",
+		SaveGame.String(),
+		t.bindings[SaveGame].String() + "\n",
+		Quit.String(),
+		t.bindings[Quit].String(),
+	}
+}
+
+// GetEditorText returns the lines explaining how to use the level editor for the current bindings
+func (t *Type) GetEditorText() []string {
+	return []string{
+		"Paint  Left Click",
+		"Erase  Right Click",
+		"Tool  " + t.bindings[MoveLeft].String() + " " + t.bindings[MoveRight].String(),
+		"Load  " + t.bindings[MoveUp].String() + " " + t.bindings[MoveDown].String(),
+		Undo.String() + "  " + t.bindings[Undo].String(),
+		Redo.String() + "  " + t.bindings[Redo].String(),
+		"Save  " + t.bindings[SaveLevel].String(),
+		"Test  " + t.bindings[Confirm].String(),
+		"Back  " + t.bindings[Editor].String(),
+	}
+}
+
+// SettingsName returns the name used for an action in the settings file
+func SettingsName(a Action) string {
+	return strings.Replace(a.String(), " ", "", -1)
+}
+
+// settingsKey returns the key used to store an action's binding in the settings
+func settingsKey(a Action) string {
+	return "key." + SettingsName(a)
+}
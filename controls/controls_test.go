@@ -0,0 +1,95 @@
+package controls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/shibukawa/configdir"
+)
+
+// testSettingsFile is the settings file the tests use, so they don't change the player's settings
+const testSettingsFile = "controls_test_settings.csv"
+
+// removeTestSettings deletes the test settings file, if a test has saved it
+func removeTestSettings() {
+	folder := configdir.New("benjmarshall", "gopixelsnake").QueryFolderContainsFile(testSettingsFile)
+	if folder != nil {
+		os.Remove(filepath.Join(folder.Path, testSettingsFile))
+	}
+}
+
+func TestNewControls(t *testing.T) {
+	// Only the bindings set by each case are loaded
+	removeTestSettings()
+	tests := []struct {
+		name  string
+		saved map[Action]Key
+		want  map[Action]Key
+	}{
+		{
+			name:  "nothing saved",
+			saved: map[Action]Key{},
+			want:  map[Action]Key{Pause: "P", Editor: "E"},
+		},
+		{
+			name:  "saved keys which don't clash",
+			saved: map[Action]Key{Pause: "Space", Quit: "Escape"},
+			want:  map[Action]Key{Pause: "Space", Quit: "Escape", Editor: "E"},
+		},
+		{
+			// Each action added later is given the next default key freed by the saved bindings, in order
+			name:  "saved key which is the default of an action added later",
+			saved: map[Action]Key{Pause: "E"},
+			want:  map[Action]Key{Pause: "E", Editor: "P"},
+		},
+		{
+			name: "saved keys which are the defaults of several actions added later",
+			saved: map[Action]Key{
+				Pause: "E", ShowScores: "L", Rebind: "O", Quit: "T",
+			},
+			want: map[Action]Key{
+				Pause: "E", ShowScores: "L", Rebind: "O", Quit: "T", NextTheme: "P", Options: "S", Levels: "K", Editor: "X",
+			},
+		},
+		{
+			name:  "saved keys which clash with each other",
+			saved: map[Action]Key{Pause: "Space", Quit: "Space"},
+			want:  map[Action]Key{Pause: "P", Quit: "X"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := settings.NewSettings(testSettingsFile)
+			for a, k := range tt.saved {
+				set.SetString(settingsKey(a), k.String())
+			}
+			c := NewControls(&set)
+			for a, k := range tt.want {
+				if got := c.GetBinding(a); got != k {
+					t.Errorf("%s is bound to %s, want %s", a, got, k)
+				}
+			}
+			owners := map[Key]Action{}
+			for a := Action(0); a < NumActions; a++ {
+				k := c.GetBinding(a)
+				if other, ok := owners[k]; ok {
+					t.Errorf("%s and %s are both bound to %s", other, a, k)
+				}
+				owners[k] = a
+			}
+		})
+	}
+}
+
+func TestSetBindingSwaps(t *testing.T) {
+	removeTestSettings()
+	t.Cleanup(removeTestSettings)
+	set := settings.NewSettings(testSettingsFile)
+	c := NewControls(&set)
+	c.SetBinding(Pause, "E")
+	if c.GetBinding(Pause) != "E" || c.GetBinding(Editor) != "P" {
+		t.Errorf("Pause is %s and Editor is %s, want E and P", c.GetBinding(Pause), c.GetBinding(Editor))
+	}
+}
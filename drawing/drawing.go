@@ -1,53 +1,246 @@
 package drawing
 
 import (
-	"github.com/benjmarshall/gopixelsnake/game"
+	"image/color"
+	"math"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/powerups"
 	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/theme"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/imdraw"
-	"github.com/faiface/pixel/pixelgl"
-	"golang.org/x/image/colornames"
 )
 
-// DrawGameBackground draws the game area border
-func DrawGameBackground(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config) {
-	imd.Clear()
-	imd.Color = colornames.White
-	min, max := gameCFG.GetGameAreaAsVecs()
-	min = gameCFG.GetWindowMatrix().Project(min)
-	max = gameCFG.GetWindowMatrix().Project(max)
-	vec := pixel.V(gameCFG.GetBorderWeight()/2, gameCFG.GetBorderWeight()/2)
-	min = min.Sub(vec)
-	max = max.Add(vec)
-	imd.Push(min, max)
-	imd.Rectangle(gameCFG.GetBorderWeight())
-	imd.Draw(win)
-}
-
-// DrawSnakeRect draws the snake shape using rectangles
-func DrawSnakeRect(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, s *snake.Type) {
-	imd.Clear()
-	imd.Color = colornames.Purple
-	positions := []pixel.Vec{s.GetHeadPos()}
-	positions = append(positions, s.GetPositionPoints()...)
-	positions = append(positions, s.GetTailPos())
-	for _, pos := range positions {
-		m := gameCFG.GetWindowMatrix()
-		vec := pixel.V(gameCFG.GetGridSize()/2, gameCFG.GetGridSize()/2)
-		min := m.Project(pos).Sub(vec)
-		max := m.Project(pos).Add(vec)
-		imd.Push(min, max)
-	}
-	imd.Rectangle(0)
-	imd.Draw(win)
-}
-
-// DrawBerry draws the berry shape
-func DrawBerry(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, berry pixel.Vec) {
-	berry = gameCFG.GetWindowMatrix().Project(berry)
-	imd.Clear()
-	imd.Color = colornames.Orangered
-	imd.Push(berry)
-	imd.Circle(gameCFG.GetGridSize()/2, 0)
-	imd.Draw(win)
+// OutlineWeight is the width, in game area pixels, of the outlines drawn when a theme has outlines turned on
+const OutlineWeight = 2
+
+// Part is a filled shape, centred on Pos, which makes up part of a piece of the snake
+type Part struct {
+	Shape theme.Shape
+	Pos   pixel.Vec
+	Size  pixel.Vec
+}
+
+// RivalTheme returns a copy of the theme with the snake in the rival colour, and no sprites, for drawing rivals
+func RivalTheme(th *theme.Type) theme.Type {
+	rt := *th
+	rt.SnakeHead = th.Rival
+	rt.SnakeBody = th.Rival
+	rt.SnakeTail = th.Rival
+	rt.Sprites = ""
+	return rt
+}
+
+// SegmentParts returns the parts a piece of the snake, in the square centred on pos, is drawn with. Each piece has a
+// shape in the middle of its square joined to the edges it shares with the pieces either side. The middle shape is
+// the theme's head or tail shape, a circle for corners so they are rounded, or a square for straight pieces. The
+// parts are inset by w, except where they join their neighbours. A sliding piece's shape is moved by the slide,
+// scaled by scale, and its joins are stretched or shortened so they still reach the edges of its square.
+func SegmentParts(seg snake.Segment, pos pixel.Vec, size float64, scale float64, w float64, th *theme.Type) []Part {
+	inner := size - 2*w
+	shape := theme.Square
+	joins := []snake.Direction{seg.Front, seg.Back}
+	switch seg.Piece {
+	case snake.Head:
+		shape = th.HeadShape
+		joins = joins[1:]
+	case snake.Tail:
+		shape = th.TailShape
+		joins = joins[:1]
+	case snake.Corner:
+		shape = theme.Round
+	}
+	slide := seg.Slide.Scaled(scale)
+	centre := pos.Add(slide)
+	parts := []Part{{shape, centre, pixel.V(inner, inner)}}
+	for _, dir := range joins {
+		d := dir.GetVec()
+		from := centre
+		length := size/2 - slide.Dot(d)
+		if slide != pixel.ZV && slide.Dot(d) == 0 {
+			// The piece is sliding in from the side, e.g. the tail just after it has gone round a corner,
+			// so bridge it back to the middle of its square and join from there
+			parts = append(parts, Part{theme.Square, pos, pixel.V(inner, inner)}, bar(pos, centre, inner))
+			from = pos
+			length = size / 2
+		}
+		if length <= 0 {
+			continue
+		}
+		parts = append(parts, bar(from, from.Add(d.Scaled(length)), inner))
+	}
+	return parts
+}
+
+// bar returns a square part running from a to b, which must be in line horizontally or vertically, with the given width
+func bar(a pixel.Vec, b pixel.Vec, width float64) Part {
+	size := pixel.V(math.Abs(b.X-a.X), width)
+	if a.X == b.X {
+		size = pixel.V(width, math.Abs(b.Y-a.Y))
+	}
+	return Part{theme.Square, pixel.Lerp(a, b, 0.5), size}
+}
+
+// EyeParts returns the parts for the eyes on the head of the snake, which is in the square centred on pos
+func EyeParts(head snake.Segment, pos pixel.Vec, size float64, scale float64) []Part {
+	front := head.Front.GetVec()
+	side := pixel.V(-front.Y, front.X)
+	eye := pixel.V(size/5, size/5)
+	centre := pos.Add(head.Slide.Scaled(scale)).Add(front.Scaled(size / 8))
+	return []Part{
+		{theme.Round, centre.Add(side.Scaled(size / 5)), eye},
+		{theme.Round, centre.Sub(side.Scaled(size / 5)), eye},
+	}
+}
+
+// SegmentColour returns the colour a piece of the snake is drawn in
+func SegmentColour(seg snake.Segment, th *theme.Type) color.RGBA {
+	switch seg.Piece {
+	case snake.Head:
+		return th.SnakeHead
+	case snake.Tail:
+		return th.SnakeTail
+	}
+	return th.SnakeBody
+}
+
+// BerrySize returns the size a berry in a grid square of the given size is drawn at, berries shrink as they
+// run out of time so the player can see they are about to disappear
+func BerrySize(b berries.Berry, size float64) float64 {
+	return size * (0.5 + 0.5*b.Life())
+}
+
+// BerryColour returns the colour a kind of berry is drawn in
+func BerryColour(kind berries.Kind, th *theme.Type) color.RGBA {
+	switch kind {
+	case berries.Golden:
+		return th.GoldenBerry
+	case berries.Poison:
+		return th.PoisonBerry
+	case berries.SlowDown:
+		return th.SlowBerry
+	}
+	return th.Berry
+}
+
+// BerryMarks returns the parts of the mark drawn on a berry of the given size centred on pos. Golden berries have
+// a dot, poison berries a minus sign and slow down berries a pause sign, normal berries have no mark.
+func BerryMarks(kind berries.Kind, pos pixel.Vec, size float64) []Part {
+	w := size / 6
+	switch kind {
+	case berries.Golden:
+		return []Part{{theme.Round, pos, pixel.V(2*w, 2*w)}}
+	case berries.Poison:
+		return []Part{{theme.Square, pos, pixel.V(size/2, w)}}
+	case berries.SlowDown:
+		return []Part{
+			{theme.Square, pos.Sub(pixel.V(w, 0)), pixel.V(w, size/2)},
+			{theme.Square, pos.Add(pixel.V(w, 0)), pixel.V(w, size/2)},
+		}
+	}
+	return nil
+}
+
+// PowerUpSize returns the size a power-up in a grid square of the given size is drawn at, power-ups shrink as they
+// run out of time like berries do
+func PowerUpSize(p powerups.PowerUp, size float64) float64 {
+	return size * (0.5 + 0.5*p.Life())
+}
+
+// PowerUpMarks returns the parts of the mark drawn on a power-up of the given size centred on pos. Ghosts have a
+// hole, slow motion an equals sign, magnets a diamond, shields a plus sign and multipliers two dots.
+func PowerUpMarks(effect snake.Effect, pos pixel.Vec, size float64) []Part {
+	w := size / 6
+	switch effect {
+	case snake.Ghost:
+		return []Part{{theme.Round, pos, pixel.V(size/2, size/2)}}
+	case snake.SlowMotion:
+		return []Part{
+			{theme.Square, pos.Sub(pixel.V(0, w)), pixel.V(size/2, w)},
+			{theme.Square, pos.Add(pixel.V(0, w)), pixel.V(size/2, w)},
+		}
+	case snake.Magnet:
+		return []Part{{theme.Diamond, pos, pixel.V(size/2, size/2)}}
+	case snake.Shield:
+		return []Part{
+			{theme.Square, pos, pixel.V(size/2, w)},
+			{theme.Square, pos, pixel.V(w, size/2)},
+		}
+	case snake.Multiplier:
+		return []Part{
+			{theme.Round, pos.Sub(pixel.V(w, 0)), pixel.V(1.5*w, 1.5*w)},
+			{theme.Round, pos.Add(pixel.V(w, 0)), pixel.V(1.5*w, 1.5*w)},
+		}
+	}
+	return nil
+}
+
+// WallParts returns the parts covering the area outside the arena, there are none if the arena is the whole area
+func WallParts(area pixel.Rect, arena pixel.Rect) []Part {
+	if arena == area {
+		return nil
+	}
+	strip := func(min pixel.Vec, max pixel.Vec) Part {
+		return Part{theme.Square, pixel.Lerp(min, max, 0.5), max.Sub(min)}
+	}
+	return []Part{
+		strip(area.Min, pixel.V(area.Max.X, arena.Min.Y)),
+		strip(pixel.V(area.Min.X, arena.Max.Y), area.Max),
+		strip(pixel.V(area.Min.X, arena.Min.Y), pixel.V(arena.Min.X, arena.Max.Y)),
+		strip(pixel.V(arena.Max.X, arena.Min.Y), pixel.V(area.Max.X, arena.Max.Y)),
+	}
+}
+
+// PortalParts returns the two circles a portal in a grid square of the given size centred on pos is drawn with,
+// the ring in the portal colour and the hole in its middle in the background colour
+func PortalParts(pos pixel.Vec, size float64) []Part {
+	return []Part{
+		{theme.Round, pos, pixel.V(size, size)},
+		{theme.Round, pos, pixel.V(size/2, size/2)},
+	}
+}
+
+// HazardShape returns the shape a hazard of the given kind is drawn with, patrols are square, balls round and
+// hunters diamonds
+func HazardShape(kind hazards.Kind) theme.Shape {
+	switch kind {
+	case hazards.Ball:
+		return theme.Round
+	case hazards.Hunter:
+		return theme.Diamond
+	}
+	return theme.Square
+}
+
+// HazardMarks returns the parts of the mark drawn on a hazard of the given size centred on pos. Patrols have a
+// stripe across them, balls a hole and hunters two eyes.
+func HazardMarks(kind hazards.Kind, pos pixel.Vec, size float64) []Part {
+	w := size / 6
+	switch kind {
+	case hazards.Patrol:
+		return []Part{{theme.Square, pos, pixel.V(size*2/3, w)}}
+	case hazards.Ball:
+		return []Part{{theme.Round, pos, pixel.V(w*2, w*2)}}
+	case hazards.Hunter:
+		return []Part{
+			{theme.Round, pos.Add(pixel.V(-w, w/2)), pixel.V(w, w)},
+			{theme.Round, pos.Add(pixel.V(w, w/2)), pixel.V(w, w)},
+		}
+	}
+	return []Part{}
+}
+
+// ZonePart returns the dot a berry zone in a grid square of the given size centred on pos is drawn with
+func ZonePart(pos pixel.Vec, size float64) Part {
+	return Part{theme.Round, pos, pixel.V(size/3, size/3)}
+}
+
+// SquareParts returns a part filling each of the grid squares, which are given by their centres
+func SquareParts(squares []pixel.Vec, size float64) []Part {
+	parts := []Part{}
+	for _, square := range squares {
+		parts = append(parts, Part{theme.Square, square, pixel.V(size, size)})
+	}
+	return parts
 }
@@ -0,0 +1,285 @@
+package drawing
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+)
+
+// EffectStyle sets how the effects look. Sizes and speeds are in game area pixels and times are in seconds.
+type EffectStyle struct {
+	BurstCount   int
+	BurstSpeed   float64
+	BurstLife    float64
+	ParticleSize float64
+	MaxParticles int
+	PopUpLife    float64
+	PopUpRise    float64
+	FlashLife    float64
+	FlashAlpha   float64
+	TrailLife    float64
+	TrailAlpha   float64
+}
+
+// DefaultEffectStyle is the style used for any values which aren't in the settings file
+var DefaultEffectStyle = EffectStyle{
+	BurstCount:   16,
+	BurstSpeed:   120,
+	BurstLife:    0.6,
+	ParticleSize: 4,
+	MaxParticles: 256,
+	PopUpLife:    0.8,
+	PopUpRise:    40,
+	FlashLife:    0.3,
+	FlashAlpha:   0.5,
+	TrailLife:    0.4,
+	TrailAlpha:   0.4,
+}
+
+// LoadEffectStyle returns the effect style from the settings, each value is stored with the key "effects." followed
+// by its name, e.g. "effects.BurstCount". Values which can't be used are replaced by the default: counts, sizes and
+// speeds can't be negative, lifetimes have to be more than 0 and alphas have to be from 0 to 1.
+func LoadEffectStyle(set *settings.Type) EffectStyle {
+	d := DefaultEffectStyle
+	return EffectStyle{
+		BurstCount:   loadCount(set, "effects.BurstCount", d.BurstCount),
+		BurstSpeed:   loadAmount(set, "effects.BurstSpeed", d.BurstSpeed),
+		BurstLife:    loadLife(set, "effects.BurstLife", d.BurstLife),
+		ParticleSize: loadAmount(set, "effects.ParticleSize", d.ParticleSize),
+		MaxParticles: loadCount(set, "effects.MaxParticles", d.MaxParticles),
+		PopUpLife:    loadLife(set, "effects.PopUpLife", d.PopUpLife),
+		PopUpRise:    loadAmount(set, "effects.PopUpRise", d.PopUpRise),
+		FlashLife:    loadLife(set, "effects.FlashLife", d.FlashLife),
+		FlashAlpha:   loadAlpha(set, "effects.FlashAlpha", d.FlashAlpha),
+		TrailLife:    loadLife(set, "effects.TrailLife", d.TrailLife),
+		TrailAlpha:   loadAlpha(set, "effects.TrailAlpha", d.TrailAlpha),
+	}
+}
+
+// loadCount returns the count stored for key, or def if it isn't set or is negative
+func loadCount(set *settings.Type, key string, def int) int {
+	if v := set.GetInt(key, def); v >= 0 {
+		return v
+	}
+	return def
+}
+
+// loadAmount returns the size or speed stored for key, or def if it isn't set, is negative or isn't a finite number
+func loadAmount(set *settings.Type, key string, def float64) float64 {
+	if v := set.GetFloat(key, def); v >= 0 && !math.IsInf(v, 0) {
+		return v
+	}
+	return def
+}
+
+// loadLife returns the lifetime stored for key, or def if it isn't set or isn't a finite number more than 0
+func loadLife(set *settings.Type, key string, def float64) float64 {
+	if v := set.GetFloat(key, def); v > 0 && !math.IsInf(v, 0) {
+		return v
+	}
+	return def
+}
+
+// loadAlpha returns the alpha stored for key, or def if it isn't set or isn't from 0 to 1
+func loadAlpha(set *settings.Type, key string, def float64) float64 {
+	if v := set.GetFloat(key, def); v >= 0 && v <= 1 {
+		return v
+	}
+	return def
+}
+
+// particle is a square, or a line of text, which moves and fades away over its life
+type particle struct {
+	text   string
+	pos    pixel.Vec
+	vel    pixel.Vec
+	size   float64
+	alpha  float64
+	age    float64
+	life   float64
+	colour color.RGBA
+}
+
+// EffectPart is a part of an effect with the colour it is drawn in, faded by its age
+type EffectPart struct {
+	Part
+	Colour color.RGBA
+}
+
+// PopUp is a line of text which rises from where something happened in the game area and fades away
+type PopUp struct {
+	Pos    pixel.Vec
+	Text   string
+	Colour color.RGBA
+}
+
+// Effects holds the particle bursts, score pop-ups, flashes and trail currently playing. They are started by the
+// game as things happen, moved on by Update each frame and drawn over the game area with positions in the game
+// area coordinate plane.
+type Effects struct {
+	style       EffectStyle
+	enabled     bool
+	flashing    bool
+	particles   []particle
+	trail       []particle
+	popUps      []particle
+	flashAge    float64
+	flashColour color.RGBA
+	r           *rand.Rand
+}
+
+// NewEffects creates a new effects struct with the style provided, the effects start turned on
+func NewEffects(style EffectStyle) Effects {
+	fx := new(Effects)
+	fx.style = style
+	fx.enabled = true
+	fx.flashing = true
+	fx.flashAge = style.FlashLife
+	fx.r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	return *fx
+}
+
+// SetEnabled turns the effects on or off, and separately turns off the flash, which some players may find
+// uncomfortable. Any effects playing are stopped when they are turned off.
+func (fx *Effects) SetEnabled(enabled bool, flashing bool) {
+	fx.enabled = enabled
+	fx.flashing = enabled && flashing
+	if !fx.enabled {
+		fx.particles = nil
+		fx.trail = nil
+		fx.popUps = nil
+	}
+	if !fx.flashing {
+		fx.flashAge = fx.style.FlashLife
+	}
+}
+
+// BerryEaten bursts the berry into particles of its colour, c, and pops up the points scored for it, if any
+func (fx *Effects) BerryEaten(pos pixel.Vec, points int, c color.RGBA, th *theme.Type) {
+	if !fx.enabled {
+		return
+	}
+	fx.burst(pos, c)
+	if points <= 0 {
+		return
+	}
+	fx.popUps = append(fx.popUps, particle{text: "+" + strconv.Itoa(points), pos: pos, vel: pixel.V(0, fx.style.PopUpRise), alpha: 1, life: fx.style.PopUpLife, colour: th.Text})
+}
+
+// SnakeDied flashes the game area and bursts the snake's head into particles
+func (fx *Effects) SnakeDied(pos pixel.Vec, th *theme.Type) {
+	if !fx.enabled {
+		return
+	}
+	fx.burst(pos, th.SnakeHead)
+	if fx.flashing {
+		fx.flashAge = 0
+		fx.flashColour = th.Text
+	}
+}
+
+// SnakeMoved leaves a fading mark in the grid square, given in the game area coordinate plane, the tail moved out of
+func (fx *Effects) SnakeMoved(gameCFG *game.Config, tail pixel.Vec, th *theme.Type) {
+	if !fx.enabled || fx.style.TrailLife <= 0 {
+		return
+	}
+	fx.trail = append(fx.trail, particle{pos: tail, size: gameCFG.GetGridSize(), alpha: fx.style.TrailAlpha, life: fx.style.TrailLife, colour: th.SnakeTail})
+}
+
+// Update moves the effects on by the time since the last frame, removing any which have finished
+func (fx *Effects) Update(dt time.Duration) {
+	secs := dt.Seconds()
+	fx.flashAge = math.Min(fx.flashAge+secs, fx.style.FlashLife)
+	fx.particles = age(fx.particles, secs)
+	fx.trail = age(fx.trail, secs)
+	fx.popUps = age(fx.popUps, secs)
+}
+
+// Parts returns the parts the trail, particles and flash are drawn with, in the game area coordinate plane
+func (fx *Effects) Parts(gameCFG *game.Config) []EffectPart {
+	parts := []EffectPart{}
+	for _, p := range fx.trail {
+		parts = append(parts, p.part(false))
+	}
+	for _, p := range fx.particles {
+		parts = append(parts, p.part(true))
+	}
+	if fx.flashAge < fx.style.FlashLife {
+		area := gameCFG.GetGameAreaAsRec()
+		alpha := fx.style.FlashAlpha * (1 - fx.flashAge/fx.style.FlashLife)
+		parts = append(parts, EffectPart{Part{theme.Square, area.Center(), area.Size()}, Fade(fx.flashColour, alpha)})
+	}
+	return parts
+}
+
+// GetPopUps returns the score pop-ups currently showing
+func (fx *Effects) GetPopUps() []PopUp {
+	popUps := []PopUp{}
+	for _, p := range fx.popUps {
+		popUps = append(popUps, PopUp{p.pos, p.text, Fade(p.colour, p.alpha*p.fraction())})
+	}
+	return popUps
+}
+
+// burst adds a ring of particles flying out from pos in random directions, dropping the oldest particles if
+// there are too many
+func (fx *Effects) burst(pos pixel.Vec, c color.RGBA) {
+	for i := 0; i < fx.style.BurstCount; i++ {
+		angle := fx.r.Float64() * 2 * math.Pi
+		speed := fx.style.BurstSpeed * (0.3 + 0.7*fx.r.Float64())
+		fx.particles = append(fx.particles, particle{
+			pos:    pos,
+			vel:    pixel.Unit(angle).Scaled(speed),
+			size:   fx.style.ParticleSize,
+			alpha:  1,
+			life:   fx.style.BurstLife * (0.5 + 0.5*fx.r.Float64()),
+			colour: c,
+		})
+	}
+	if extra := len(fx.particles) - fx.style.MaxParticles; extra > 0 {
+		fx.particles = fx.particles[extra:]
+	}
+}
+
+// age moves each particle on by secs, slowing it down, and returns those which are still alive
+func age(particles []particle, secs float64) []particle {
+	alive := particles[:0]
+	for _, p := range particles {
+		p.age += secs
+		if p.age >= p.life {
+			continue
+		}
+		p.pos = p.pos.Add(p.vel.Scaled(secs))
+		p.vel = p.vel.Scaled(math.Max(0, 1-2*secs))
+		alive = append(alive, p)
+	}
+	return alive
+}
+
+// fraction returns how much of the particle's life is left, from 1 when it starts to 0 when it finishes
+func (p particle) fraction() float64 {
+	return 1 - p.age/p.life
+}
+
+// part returns the part the particle is drawn with, faded by its age and shrunk too if shrink is true
+func (p particle) part(shrink bool) EffectPart {
+	size := p.size
+	if shrink {
+		size *= p.fraction()
+	}
+	return EffectPart{Part{theme.Square, p.pos, pixel.V(size, size)}, Fade(p.colour, p.alpha*p.fraction())}
+}
+
+// Fade returns the colour made partly transparent, alpha is from 0 for invisible to 1 for unchanged
+func Fade(c color.RGBA, alpha float64) color.RGBA {
+	alpha = math.Max(0, math.Min(alpha, 1))
+	// Colours are stored with their alpha already multiplied in
+	return color.RGBA{uint8(float64(c.R) * alpha), uint8(float64(c.G) * alpha), uint8(float64(c.B) * alpha), uint8(float64(c.A) * alpha)}
+}
@@ -0,0 +1,67 @@
+package drawing
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+)
+
+func TestLoadEffectStyle(t *testing.T) {
+	d := DefaultEffectStyle
+	tests := []struct {
+		key   string
+		value string
+		got   func(s EffectStyle) float64
+		want  float64
+	}{
+		{"effects.BurstCount", "8", func(s EffectStyle) float64 { return float64(s.BurstCount) }, 8},
+		{"effects.BurstCount", "0", func(s EffectStyle) float64 { return float64(s.BurstCount) }, 0},
+		{"effects.BurstCount", "-1", func(s EffectStyle) float64 { return float64(s.BurstCount) }, float64(d.BurstCount)},
+		{"effects.MaxParticles", "-5", func(s EffectStyle) float64 { return float64(s.MaxParticles) }, float64(d.MaxParticles)},
+		{"effects.MaxParticles", "0", func(s EffectStyle) float64 { return float64(s.MaxParticles) }, 0},
+		{"effects.BurstSpeed", "-10", func(s EffectStyle) float64 { return s.BurstSpeed }, d.BurstSpeed},
+		{"effects.BurstSpeed", "+Inf", func(s EffectStyle) float64 { return s.BurstSpeed }, d.BurstSpeed},
+		{"effects.ParticleSize", "NaN", func(s EffectStyle) float64 { return s.ParticleSize }, d.ParticleSize},
+		{"effects.PopUpRise", "0", func(s EffectStyle) float64 { return s.PopUpRise }, 0},
+		{"effects.BurstLife", "0", func(s EffectStyle) float64 { return s.BurstLife }, d.BurstLife},
+		{"effects.BurstLife", "1.5", func(s EffectStyle) float64 { return s.BurstLife }, 1.5},
+		{"effects.PopUpLife", "-1", func(s EffectStyle) float64 { return s.PopUpLife }, d.PopUpLife},
+		{"effects.FlashLife", "NaN", func(s EffectStyle) float64 { return s.FlashLife }, d.FlashLife},
+		{"effects.TrailLife", "0", func(s EffectStyle) float64 { return s.TrailLife }, d.TrailLife},
+		{"effects.FlashAlpha", "1.5", func(s EffectStyle) float64 { return s.FlashAlpha }, d.FlashAlpha},
+		{"effects.TrailAlpha", "0", func(s EffectStyle) float64 { return s.TrailAlpha }, 0},
+		{"effects.TrailAlpha", "-0.5", func(s EffectStyle) float64 { return s.TrailAlpha }, d.TrailAlpha},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			// The settings file doesn't exist, so only the value set here is used
+			set := settings.NewSettings("drawing_test_settings.csv")
+			set.SetString(tt.key, tt.value)
+			if got := tt.got(LoadEffectStyle(&set)); got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBurstLimit(t *testing.T) {
+	for _, limit := range []string{"-5", "0", "3"} {
+		t.Run(limit, func(t *testing.T) {
+			set := settings.NewSettings("drawing_test_settings.csv")
+			set.SetString("effects.MaxParticles", limit)
+			style := LoadEffectStyle(&set)
+			fx := NewEffects(style)
+			for i := 0; i < 4; i++ {
+				fx.BerryEaten(pixel.V(50, 50), 10, color.RGBA{255, 0, 0, 255}, &theme.Classic)
+			}
+			fx.Update(10 * time.Millisecond)
+			if len(fx.particles) > style.MaxParticles {
+				t.Errorf("%d particles are playing, want at most %d", len(fx.particles), style.MaxParticles)
+			}
+		})
+	}
+}
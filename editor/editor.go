@@ -0,0 +1,298 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/levels"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// Tool is what the mouse paints onto the level being edited
+type Tool int
+
+const (
+	// Wall paints walls.
+	Wall Tool = iota
+	// Portal places portals, the first click places one end and the second its partner.
+	Portal
+	// Spawn moves the snake's starting point, clicking on it again turns the way it heads.
+	Spawn
+	// Zone paints the berry zones.
+	Zone
+	// NumTools is the number of tools, it can be used to loop over all tools.
+	NumTools
+)
+
+// toolNames are the names shown for each tool
+var toolNames = map[Tool]string{
+	Wall:   "Wall",
+	Portal: "Portal",
+	Spawn:  "Spawn",
+	Zone:   "Berry Zone",
+}
+
+// String returns the name of the tool
+func (t Tool) String() string {
+	return toolNames[t]
+}
+
+// Type holds a level being edited, the tool being used and the changes which can be undone and redone. Each
+// stroke of the mouse, from pressing a button to letting it go, is one change.
+type Type struct {
+	level      levels.Level
+	tool       Tool
+	pending    pixel.Vec
+	hasPending bool
+	undo       []levels.Level
+	redo       []levels.Level
+	stroke     bool
+	changed    bool
+}
+
+// NewEditor returns an editor for a new open level with the given name on a cols by rows grid
+func NewEditor(name string, cols int, rows int) Type {
+	t := new(Type)
+	t.level = levels.Open(name, cols, rows)
+	t.undo = []levels.Level{}
+	t.redo = []levels.Level{}
+	return *t
+}
+
+// GetLevel returns a copy of the level being edited
+func (t *Type) GetLevel() levels.Level {
+	return t.level.Copy()
+}
+
+// Load replaces the level being edited with a copy of l, which can be undone
+func (t *Type) Load(l levels.Level) {
+	t.save()
+	t.level = l.Copy()
+	t.hasPending = false
+}
+
+// GetTool returns the tool being used
+func (t *Type) GetTool() Tool {
+	return t.tool
+}
+
+// NextTool moves steps tools along the list of tools, back for a negative number of steps
+func (t *Type) NextTool(steps int) {
+	t.tool = Tool((int(t.tool) + steps + int(NumTools)) % int(NumTools))
+	t.hasPending = false
+}
+
+// GetPendingPortal returns the grid square of a portal which is waiting for its partner to be placed, and false
+// if there isn't one
+func (t *Type) GetPendingPortal() (pixel.Vec, bool) {
+	return t.pending, t.hasPending
+}
+
+// Pointer updates the editor with where the mouse is, pos is in the game area coordinate plane, and whether the
+// paint and erase buttons are held down. Holding paint uses the tool on each square the mouse moves over, and
+// holding erase clears them. It returns true if the level changed.
+func (t *Type) Pointer(gameCFG *game.Config, pos pixel.Vec, paint bool, erase bool) bool {
+	if !paint && !erase {
+		t.endStroke()
+		return false
+	}
+	x, y := gameCFG.GetGameAreaDims()
+	if pos.X < 0 || pos.Y < 0 || pos.X >= x || pos.Y >= y {
+		return false
+	}
+	square := pixel.V(math.Floor(pos.X/gameCFG.GetGridSize()), math.Floor(pos.Y/gameCFG.GetGridSize()))
+	first := !t.stroke
+	if first {
+		t.save()
+		t.stroke = true
+		t.changed = false
+	}
+	changed := false
+	if erase {
+		changed = t.erase(square)
+	} else if first || t.tool == Wall || t.tool == Zone {
+		// Portals and the spawn point are placed with a click, walls and zones can be painted by dragging
+		changed = t.paint(square)
+	}
+	t.changed = t.changed || changed
+	return changed
+}
+
+// Undo undoes the last change, returning false if there is nothing to undo
+func (t *Type) Undo() bool {
+	if len(t.undo) == 0 || t.stroke {
+		return false
+	}
+	t.redo = append(t.redo, t.level)
+	t.level = t.undo[len(t.undo)-1]
+	t.undo = t.undo[:len(t.undo)-1]
+	t.hasPending = false
+	return true
+}
+
+// Redo redoes the last change which was undone, returning false if there is nothing to redo
+func (t *Type) Redo() bool {
+	if len(t.redo) == 0 || t.stroke {
+		return false
+	}
+	t.undo = append(t.undo, t.level)
+	t.level = t.redo[len(t.redo)-1]
+	t.redo = t.redo[:len(t.redo)-1]
+	t.hasPending = false
+	return true
+}
+
+// save keeps a copy of the level so the change about to be made can be undone, anything undone can no longer
+// be redone
+func (t *Type) save() {
+	t.undo = append(t.undo, t.level.Copy())
+	t.redo = []levels.Level{}
+}
+
+// endStroke finishes the stroke of the mouse, forgetting it if it didn't change anything
+func (t *Type) endStroke() {
+	if t.stroke && !t.changed {
+		t.undo = t.undo[:len(t.undo)-1]
+	}
+	t.stroke = false
+}
+
+// paint uses the tool on the grid square, returning true if the level changed. Nothing can be put on the spawn
+// point, portals or hazards, walls and berry zones replace each other, and the spawn point and portals can only go
+// in squares without a wall.
+func (t *Type) paint(square pixel.Vec) bool {
+	l := &t.level
+	if t.tool == Spawn && square == l.Spawn {
+		l.Heading = turnClockwise(l.Heading)
+		return true
+	}
+	if square == l.Spawn || t.hasPortal(square) || t.hasHazard(square) {
+		if t.tool == Portal && t.hasPending && t.pending == square {
+			// Clicking on a portal waiting for its partner takes it away again
+			t.hasPending = false
+		}
+		return false
+	}
+	if (t.tool == Spawn || t.tool == Portal) && l.IsWall(square) {
+		return false
+	}
+	switch t.tool {
+	case Wall:
+		if l.IsWall(square) {
+			return false
+		}
+		l.BerryZones = remove(l.BerryZones, square)
+		l.Walls = append(l.Walls, square)
+	case Zone:
+		if contains(l.BerryZones, square) {
+			return false
+		}
+		l.Walls = remove(l.Walls, square)
+		l.BerryZones = append(l.BerryZones, square)
+	case Spawn:
+		l.Spawn = square
+	case Portal:
+		if !t.hasPending {
+			// The first end doesn't change the level until its partner is placed
+			t.pending = square
+			t.hasPending = true
+			return false
+		}
+		l.Portals = append(l.Portals, [2]pixel.Vec{t.pending, square})
+		t.hasPending = false
+	}
+	return true
+}
+
+// erase clears the wall, berry zone, portal pair or hazard in the grid square, returning true if the level changed
+func (t *Type) erase(square pixel.Vec) bool {
+	l := &t.level
+	before := len(l.Walls) + len(l.BerryZones) + len(l.Portals) + len(l.Hazards)
+	l.Walls = remove(l.Walls, square)
+	l.BerryZones = remove(l.BerryZones, square)
+	portals := l.Portals[:0]
+	for _, pair := range l.Portals {
+		if pair[0] != square && pair[1] != square {
+			portals = append(portals, pair)
+		}
+	}
+	l.Portals = portals
+	hs := l.Hazards[:0]
+	for _, h := range l.Hazards {
+		if h.Pos != square {
+			hs = append(hs, h)
+		}
+	}
+	l.Hazards = hs
+	if t.hasPending && t.pending == square {
+		t.hasPending = false
+	}
+	return len(l.Walls)+len(l.BerryZones)+len(l.Portals)+len(l.Hazards) < before
+}
+
+// hasPortal returns true if there is a portal in the grid square, including one waiting for its partner
+func (t *Type) hasPortal(square pixel.Vec) bool {
+	_, ok := t.level.GetPortalMap()[square]
+	return ok || (t.hasPending && t.pending == square)
+}
+
+// hasHazard returns true if a hazard starts in the grid square
+func (t *Type) hasHazard(square pixel.Vec) bool {
+	for _, h := range t.level.Hazards {
+		if h.Pos == square {
+			return true
+		}
+	}
+	return false
+}
+
+// turnClockwise returns the direction a quarter turn clockwise from d
+func turnClockwise(d snake.Direction) snake.Direction {
+	switch d {
+	case snake.UP:
+		return snake.RIGHT
+	case snake.RIGHT:
+		return snake.DOWN
+	case snake.DOWN:
+		return snake.LEFT
+	}
+	return snake.UP
+}
+
+// contains returns true if the square is in the list
+func contains(squares []pixel.Vec, square pixel.Vec) bool {
+	for _, s := range squares {
+		if s == square {
+			return true
+		}
+	}
+	return false
+}
+
+// remove returns the list without the square
+func remove(squares []pixel.Vec, square pixel.Vec) []pixel.Vec {
+	left := []pixel.Vec{}
+	for _, s := range squares {
+		if s != square {
+			left = append(left, s)
+		}
+	}
+	return left
+}
+
+// PanelLines returns the lines of the level editor's panel: the tools with the one at index selected marked, how
+// to use the editor and the status line
+func PanelLines(ctrl *controls.Type, tools []string, selected int, status string) []string {
+	lines := []string{"Level Editor", ""}
+	for i, tool := range tools {
+		if i == selected {
+			tool = "> " + tool + " <"
+		}
+		lines = append(lines, tool)
+	}
+	lines = append(lines, "")
+	lines = append(lines, ctrl.GetEditorText()...)
+	return append(lines, "", status)
+}
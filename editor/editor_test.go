@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/faiface/pixel"
+)
+
+// click presses and lets go of a mouse button over each grid square in turn, as one stroke
+func click(gameCFG *game.Config, t *Type, erase bool, squares ...pixel.Vec) {
+	for _, square := range squares {
+		pos := gameCFG.GetGridMatrix().Project(square)
+		t.Pointer(gameCFG, pos, !erase, erase)
+	}
+	t.Pointer(gameCFG, pixel.ZV, false, false)
+}
+
+// checkWalls checks the level being edited has exactly the walls wanted, in order
+func checkWalls(t *testing.T, e *Type, want ...pixel.Vec) {
+	t.Helper()
+	got := e.GetLevel().Walls
+	if len(got) != len(want) {
+		t.Fatalf("the level has walls %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("the level has walls %v, want %v", got, want)
+		}
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	e := NewEditor("test", 20, 20)
+	a, b := pixel.V(3, 3), pixel.V(5, 7)
+	click(&gameCFG, &e, false, a)
+	click(&gameCFG, &e, false, b)
+	checkWalls(t, &e, a, b)
+
+	if !e.Undo() {
+		t.Fatal("Undo() returned false after placing a wall")
+	}
+	checkWalls(t, &e, a)
+	if !e.Undo() {
+		t.Fatal("Undo() returned false with a wall left to undo")
+	}
+	checkWalls(t, &e)
+	if e.Undo() {
+		t.Error("Undo() returned true with nothing left to undo")
+	}
+
+	if !e.Redo() {
+		t.Fatal("Redo() returned false after an undo")
+	}
+	checkWalls(t, &e, a)
+	if !e.Redo() {
+		t.Fatal("Redo() returned false with a wall left to redo")
+	}
+	checkWalls(t, &e, a, b)
+	if e.Redo() {
+		t.Error("Redo() returned true with nothing left to redo")
+	}
+
+	// Changing the level after a redo doesn't change what undo goes back to
+	click(&gameCFG, &e, true, a)
+	checkWalls(t, &e, b)
+	e.Undo()
+	checkWalls(t, &e, a, b)
+}
+
+func TestNewEditClearsRedo(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	e := NewEditor("test", 20, 20)
+	a, b := pixel.V(3, 3), pixel.V(5, 7)
+	click(&gameCFG, &e, false, a)
+	e.Undo()
+	click(&gameCFG, &e, false, b)
+	if e.Redo() {
+		t.Error("Redo() returned true after a new edit")
+	}
+	checkWalls(t, &e, b)
+	e.Undo()
+	checkWalls(t, &e)
+}
+
+func TestStrokeIsOneChange(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	e := NewEditor("test", 20, 20)
+	line := []pixel.Vec{pixel.V(3, 3), pixel.V(4, 3), pixel.V(5, 3)}
+	click(&gameCFG, &e, false, line...)
+	checkWalls(t, &e, line...)
+	// A stroke which doesn't change anything isn't a change to undo
+	click(&gameCFG, &e, false, line[0])
+	e.Undo()
+	checkWalls(t, &e)
+}
+
+func TestNoUndoMidStroke(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	e := NewEditor("test", 20, 20)
+	click(&gameCFG, &e, false, pixel.V(3, 3))
+	e.Pointer(&gameCFG, gameCFG.GetGridMatrix().Project(pixel.V(4, 3)), true, false)
+	if e.Undo() {
+		t.Error("Undo() returned true while the mouse button was held down")
+	}
+	e.Pointer(&gameCFG, pixel.ZV, false, false)
+	if !e.Undo() {
+		t.Error("Undo() returned false once the mouse button was let go")
+	}
+	checkWalls(t, &e, pixel.V(3, 3))
+}
@@ -0,0 +1,305 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/modes"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// Event is something which happened in the game. Each kind of event is its own type, so subscribers can use a type
+// switch to pick out the events they are interested in. Positions are in the game area coordinate plane.
+type Event interface {
+	String() string
+}
+
+// Started is published when the player starts a new game
+type Started struct {
+	Direction snake.Direction
+}
+
+// DirectionChanged is published when the snake turns
+type DirectionChanged struct {
+	From snake.Direction
+	To   snake.Direction
+}
+
+// TailMoved is published when the tail of the snake moves out of the grid square at Pos
+type TailMoved struct {
+	Pos pixel.Vec
+}
+
+// BerryEaten is published when the snake eats a berry of the given kind at Pos, scoring Points for it
+type BerryEaten struct {
+	Pos    pixel.Vec
+	Kind   berries.Kind
+	Points int
+}
+
+// SpeedIncreased is published when the snake speeds up, Speed is its new speed multiplier
+type SpeedIncreased struct {
+	Speed float64
+}
+
+// SpeedDecreased is published when the snake slows down, Speed is its new speed multiplier
+type SpeedDecreased struct {
+	Speed float64
+}
+
+// Died is published when the snake's head runs into something at Pos, or a hazard runs into the snake there, and
+// the game ends
+type Died struct {
+	Pos   pixel.Vec
+	Cause snake.Collision
+	Score int
+}
+
+// Finished is published when a game ends because it reached the end condition of its mode, rather than the snake
+// dying. Result is what goes in the mode's high scores table, the time in milliseconds for a race or the score.
+type Finished struct {
+	Mode   modes.Mode
+	Result int
+}
+
+// NewHighScore is published when a game ends with a score which makes the high scores table
+type NewHighScore struct {
+	Score int
+}
+
+// PowerUpCollected is published when the snake collects a power-up at Pos and is given its effect
+type PowerUpCollected struct {
+	Pos    pixel.Vec
+	Effect snake.Effect
+}
+
+// PowerUpEnded is published when an effect wears off the snake, or is used up
+type PowerUpEnded struct {
+	Effect snake.Effect
+}
+
+// ShieldUsed is published when the shield turns the snake, with its head at Pos, away from the wall
+type ShieldUsed struct {
+	Pos pixel.Vec
+}
+
+// Teleported is published when the snake's head goes into the portal at From and comes out of the one at To
+type Teleported struct {
+	From pixel.Vec
+	To   pixel.Vec
+}
+
+// BerrySquashed is published when a hazard moves onto the berry of the given kind at Pos and squashes it
+type BerrySquashed struct {
+	Pos  pixel.Vec
+	Kind berries.Kind
+}
+
+// BerryStolen is published when the rival at index Rival eats the berry of the given kind at Pos, scoring Points
+type BerryStolen struct {
+	Pos    pixel.Vec
+	Kind   berries.Kind
+	Rival  int
+	Points int
+}
+
+// SnakeKilled is published when a rival snake dies at Pos. Victim and Killer are the index of a rival or -1 for the
+// player, Killer is -2 if the rival didn't run into another snake. Points is what the killer scored for it.
+type SnakeKilled struct {
+	Pos    pixel.Vec
+	Victim int
+	Killer int
+	Cause  snake.Collision
+	Points int
+}
+
+// StageCompleted is published when the snake reaches the target of a campaign level
+type StageCompleted struct {
+	Stage int
+	Name  string
+}
+
+// GameSaved is published when the game being played is saved so it can be resumed later
+type GameSaved struct {
+	Mode  modes.Mode
+	Score int
+}
+
+// MenuChanged is published when the player opens or closes a menu screen, moves around it or changes something on it
+type MenuChanged struct{}
+
+// String returns a description of the event
+func (e Started) String() string {
+	return fmt.Sprintf("started heading %s", e.Direction)
+}
+
+// String returns a description of the event
+func (e DirectionChanged) String() string {
+	return fmt.Sprintf("turned from %s to %s", e.From, e.To)
+}
+
+// String returns a description of the event
+func (e TailMoved) String() string {
+	return fmt.Sprintf("tail moved from %v", e.Pos)
+}
+
+// String returns a description of the event
+func (e BerryEaten) String() string {
+	return fmt.Sprintf("ate the %s berry at %v for %d points", e.Kind, e.Pos, e.Points)
+}
+
+// String returns a description of the event
+func (e SpeedIncreased) String() string {
+	return fmt.Sprintf("speed increased to %g", e.Speed)
+}
+
+// String returns a description of the event
+func (e SpeedDecreased) String() string {
+	return fmt.Sprintf("speed decreased to %g", e.Speed)
+}
+
+// String returns a description of the event
+func (e Died) String() string {
+	return fmt.Sprintf("died at %v, %s, scoring %d", e.Pos, e.Cause, e.Score)
+}
+
+// String returns a description of the event
+func (e Finished) String() string {
+	return fmt.Sprintf("finished %s with %d", e.Mode, e.Result)
+}
+
+// String returns a description of the event
+func (e NewHighScore) String() string {
+	return fmt.Sprintf("new high score of %d", e.Score)
+}
+
+// String returns a description of the event
+func (e PowerUpCollected) String() string {
+	return fmt.Sprintf("collected the %s power-up at %v", e.Effect, e.Pos)
+}
+
+// String returns a description of the event
+func (e PowerUpEnded) String() string {
+	return fmt.Sprintf("%s wore off", e.Effect)
+}
+
+// String returns a description of the event
+func (e ShieldUsed) String() string {
+	return fmt.Sprintf("shield turned away from the wall at %v", e.Pos)
+}
+
+// String returns a description of the event
+func (e Teleported) String() string {
+	return fmt.Sprintf("went through the portal at %v to %v", e.From, e.To)
+}
+
+// String returns a description of the event
+func (e BerrySquashed) String() string {
+	return fmt.Sprintf("a hazard squashed the %s berry at %v", e.Kind, e.Pos)
+}
+
+// String returns a description of the event
+func (e BerryStolen) String() string {
+	return fmt.Sprintf("rival %d stole the %s berry at %v for %d points", e.Rival+1, e.Kind, e.Pos, e.Points)
+}
+
+// String returns a description of the event
+func (e SnakeKilled) String() string {
+	switch e.Killer {
+	case -1:
+		return fmt.Sprintf("the player killed rival %d at %v for %d points", e.Victim+1, e.Pos, e.Points)
+	case -2:
+		return fmt.Sprintf("rival %d died at %v, %s", e.Victim+1, e.Pos, e.Cause)
+	}
+	return fmt.Sprintf("rival %d killed rival %d at %v for %d points", e.Killer+1, e.Victim+1, e.Pos, e.Points)
+}
+
+// String returns a description of the event
+func (e StageCompleted) String() string {
+	return fmt.Sprintf("completed stage %d %s", e.Stage+1, e.Name)
+}
+
+// String returns a description of the event
+func (e GameSaved) String() string {
+	return fmt.Sprintf("saved the %s game with a score of %d", e.Mode, e.Score)
+}
+
+// String returns a description of the event
+func (e MenuChanged) String() string {
+	return "menu changed"
+}
+
+// Subscriber is called with each event published on the bus it is subscribed to
+type Subscriber func(e Event)
+
+// Bus passes the events published by the game on to each of its subscribers, in the order they subscribed
+type Bus struct {
+	subscribers []Subscriber
+}
+
+// NewBus returns a bus with no subscribers
+func NewBus() Bus {
+	b := new(Bus)
+	b.subscribers = []Subscriber{}
+	return *b
+}
+
+// Subscribe adds a subscriber which is called with every event published from now on
+func (b *Bus) Subscribe(s Subscriber) {
+	b.subscribers = append(b.subscribers, s)
+}
+
+// Publish passes the event to each subscriber in turn, returning once they have all handled it
+func (b *Bus) Publish(e Event) {
+	for _, s := range b.subscribers {
+		s(e)
+	}
+}
+
+// Recorder is a subscriber which keeps every event it is given, so tests and tools can check what happened
+type Recorder struct {
+	events []Event
+}
+
+// NewRecorder returns a recorder which hasn't recorded anything yet
+func NewRecorder() Recorder {
+	r := new(Recorder)
+	r.events = []Event{}
+	return *r
+}
+
+// Record keeps the event, subscribe it to a bus with bus.Subscribe(recorder.Record)
+func (r *Recorder) Record(e Event) {
+	r.events = append(r.events, e)
+}
+
+// GetEvents returns the events recorded so far, oldest first
+func (r *Recorder) GetEvents() []Event {
+	return r.events
+}
+
+// Count returns how many of the recorded events are the same type as e, e.g. Count(events.BerryEaten{})
+func (r *Recorder) Count(e Event) int {
+	count := 0
+	for _, recorded := range r.events {
+		if reflect.TypeOf(recorded) == reflect.TypeOf(e) {
+			count++
+		}
+	}
+	return count
+}
+
+// Last returns the most recent event recorded, or nil if nothing has been recorded
+func (r *Recorder) Last() Event {
+	if len(r.events) == 0 {
+		return nil
+	}
+	return r.events[len(r.events)-1]
+}
+
+// Clear forgets the events recorded so far
+func (r *Recorder) Clear() {
+	r.events = []Event{}
+}
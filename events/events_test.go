@@ -0,0 +1,155 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		check func(t *testing.T, e Event)
+		text  string
+	}{
+		{
+			name:  "eating a berry",
+			event: BerryEaten{Pos: pixel.V(35, 45), Kind: berries.Golden, Points: 50},
+			check: func(t *testing.T, e Event) {
+				eaten, ok := e.(BerryEaten)
+				if !ok {
+					t.Fatalf("recorded a %T, want a BerryEaten", e)
+				}
+				if eaten.Pos != pixel.V(35, 45) || eaten.Kind != berries.Golden || eaten.Points != 50 {
+					t.Errorf("recorded %+v", eaten)
+				}
+			},
+			text: fmt.Sprintf("ate the Golden berry at %v for 50 points", pixel.V(35, 45)),
+		},
+		{
+			name:  "a speed increase",
+			event: SpeedIncreased{Speed: 3},
+			check: func(t *testing.T, e Event) {
+				speed, ok := e.(SpeedIncreased)
+				if !ok {
+					t.Fatalf("recorded a %T, want a SpeedIncreased", e)
+				}
+				if speed.Speed != 3 {
+					t.Errorf("recorded speed %v, want 3", speed.Speed)
+				}
+			},
+			text: "speed increased to 3",
+		},
+		{
+			name:  "a direction change",
+			event: DirectionChanged{From: snake.RIGHT, To: snake.UP},
+			check: func(t *testing.T, e Event) {
+				turned, ok := e.(DirectionChanged)
+				if !ok {
+					t.Fatalf("recorded a %T, want a DirectionChanged", e)
+				}
+				if turned.From != snake.RIGHT || turned.To != snake.UP {
+					t.Errorf("recorded a turn from %v to %v, want RIGHT to UP", turned.From, turned.To)
+				}
+			},
+			text: fmt.Sprintf("turned from %s to %s", snake.RIGHT, snake.UP),
+		},
+		{
+			name:  "death with its cause",
+			event: Died{Pos: pixel.V(5, 5), Cause: snake.HitSelf, Score: 120},
+			check: func(t *testing.T, e Event) {
+				died, ok := e.(Died)
+				if !ok {
+					t.Fatalf("recorded a %T, want a Died", e)
+				}
+				if died.Cause != snake.HitSelf || died.Score != 120 {
+					t.Errorf("recorded %+v", died)
+				}
+			},
+			text: fmt.Sprintf("died at %v, %s, scoring 120", pixel.V(5, 5), snake.HitSelf),
+		},
+		{
+			name:  "a new high score",
+			event: NewHighScore{Score: 990},
+			check: func(t *testing.T, e Event) {
+				high, ok := e.(NewHighScore)
+				if !ok {
+					t.Fatalf("recorded a %T, want a NewHighScore", e)
+				}
+				if high.Score != 990 {
+					t.Errorf("recorded score %d, want 990", high.Score)
+				}
+			},
+			text: "new high score of 990",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewBus()
+			recorder := NewRecorder()
+			bus.Subscribe(recorder.Record)
+			bus.Publish(tt.event)
+			if len(recorder.GetEvents()) != 1 {
+				t.Fatalf("recorded %d events, want 1", len(recorder.GetEvents()))
+			}
+			tt.check(t, recorder.Last())
+			if recorder.Count(tt.event) != 1 {
+				t.Errorf("Count(%T) = %d, want 1", tt.event, recorder.Count(tt.event))
+			}
+			if got := recorder.Last().String(); got != tt.text {
+				t.Errorf("String() = %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestBusOrder(t *testing.T) {
+	bus := NewBus()
+	order := []string{}
+	bus.Subscribe(func(e Event) { order = append(order, "first") })
+	recorder := NewRecorder()
+	bus.Subscribe(recorder.Record)
+	bus.Subscribe(func(e Event) { order = append(order, "last") })
+
+	published := []Event{
+		Started{Direction: snake.RIGHT},
+		BerryEaten{Kind: berries.Normal, Points: 10},
+		SpeedIncreased{Speed: 3},
+		BerryEaten{Kind: berries.Normal, Points: 10},
+		Died{Cause: snake.HitWall},
+	}
+	for _, e := range published {
+		bus.Publish(e)
+	}
+
+	if len(order) != 2*len(published) || order[0] != "first" || order[1] != "last" {
+		t.Errorf("subscribers were called in the order %v", order)
+	}
+	got := recorder.GetEvents()
+	if len(got) != len(published) {
+		t.Fatalf("recorded %d events, want %d", len(got), len(published))
+	}
+	for i := range published {
+		if got[i] != published[i] {
+			t.Errorf("event %d = %v, want %v", i, got[i], published[i])
+		}
+	}
+	if n := recorder.Count(BerryEaten{}); n != 2 {
+		t.Errorf("Count(BerryEaten) = %d, want 2", n)
+	}
+	if n := recorder.Count(NewHighScore{}); n != 0 {
+		t.Errorf("Count(NewHighScore) = %d, want 0", n)
+	}
+	if died, ok := recorder.Last().(Died); !ok || died.Cause != snake.HitWall {
+		t.Errorf("Last() = %v, want the death", recorder.Last())
+	}
+
+	recorder.Clear()
+	if len(recorder.GetEvents()) != 0 || recorder.Last() != nil {
+		t.Errorf("events were still recorded after Clear()")
+	}
+}
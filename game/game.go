@@ -3,11 +3,8 @@ package game
 import (
 	"errors"
 	"math"
-	"math/rand"
-	"time"
 
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 )
 
 // Config is a struct used to define the configuration of the game
@@ -17,7 +14,11 @@ type Config struct {
 	gameAreaBorderThickness float64
 	gameGridSize            float64
 	gameGridMatrix          pixel.Matrix
+	gameLayoutMatrix        pixel.Matrix
 	gameWindowMatrix        pixel.Matrix
+	layoutBounds            pixel.Rect
+	viewMatrix              pixel.Matrix
+	viewScale               float64
 }
 
 type gameAreaDimsType struct {
@@ -25,19 +26,21 @@ type gameAreaDimsType struct {
 	y float64
 }
 
-// NewGameConfig returns and initialised Game Configuration Struct
-func NewGameConfig(xSize float64, ySize float64, borderWeight float64, gridSize float64, winCFG pixelgl.WindowConfig) Config {
+// NewGameConfig returns and initialised Game Configuration Struct, laid out for a window with the bounds provided
+func NewGameConfig(xSize float64, ySize float64, borderWeight float64, gridSize float64, bounds pixel.Rect) Config {
 	gameCFG := new(Config)
 	if math.Mod(xSize, gridSize) != 0 || math.Mod(ySize, gridSize) != 0 {
 		panic(errors.New("game Area must be a multiple of the grid size"))
 	}
-	gameAreaMargin := (winCFG.Bounds.H() - ySize) / 2
+	gameAreaMargin := (bounds.H() - ySize) / 2
 	gameCFG.gameAreaDims = gameAreaDimsType{x: xSize, y: ySize}
 	gameCFG.gameArea = pixel.R(0, 0, xSize, ySize)
 	gameCFG.gameAreaBorderThickness = borderWeight
 	gameCFG.gameGridSize = gridSize
 	gameCFG.gameGridMatrix = pixel.IM.Scaled(pixel.ZV, gridSize).Moved(pixel.V(gridSize/2, gridSize/2))
-	gameCFG.gameWindowMatrix = pixel.IM.Moved(pixel.V(gameAreaMargin, gameAreaMargin))
+	gameCFG.gameLayoutMatrix = pixel.IM.Moved(pixel.V(gameAreaMargin, gameAreaMargin))
+	gameCFG.layoutBounds = bounds
+	gameCFG.Resize(bounds)
 	// Debug
 	// log.Println("__Game Config__")
 	// log.Printf("Game Area Margin: %v", gameAreaMargin)
@@ -46,10 +49,20 @@ func NewGameConfig(xSize float64, ySize float64, borderWeight float64, gridSize
 	// log.Printf("Border Thichness: %v", gameCFG.gameAreaBorderThickness)
 	// log.Printf("Grid Size: %v", gameCFG.gameGridSize)
 	// log.Printf("Grid Matrix: %v", gameCFG.gameGridMatrix)
+	// log.Printf("Layout Matrix: %v", gameCFG.gameLayoutMatrix)
 	// log.Printf("Window Matrix: %v", gameCFG.gameWindowMatrix)
 	return *gameCFG
 }
 
+// Resize recalculates the matrices used to draw the game for a window with the bounds provided. The layout
+// designed for the initial window bounds is scaled to fit, keeping its aspect ratio, and centred in the window.
+func (cfg *Config) Resize(bounds pixel.Rect) {
+	cfg.viewScale = math.Min(bounds.W()/cfg.layoutBounds.W(), bounds.H()/cfg.layoutBounds.H())
+	offset := bounds.Center().Sub(cfg.layoutBounds.Center().Scaled(cfg.viewScale))
+	cfg.viewMatrix = pixel.IM.Scaled(pixel.ZV, cfg.viewScale).Moved(offset)
+	cfg.gameWindowMatrix = cfg.gameLayoutMatrix.Chained(cfg.viewMatrix)
+}
+
 // GetGridMatrix returns the matrix for the game grid which is used to translate the snake coordinates onto the game area.
 func (cfg *Config) GetGridMatrix() pixel.Matrix {
 	return cfg.gameGridMatrix
@@ -60,6 +73,26 @@ func (cfg *Config) GetWindowMatrix() pixel.Matrix {
 	return cfg.gameWindowMatrix
 }
 
+// GetLayoutMatrix returns the matrix used to translate the game area coordinates onto the layout before it is scaled to the window.
+func (cfg *Config) GetLayoutMatrix() pixel.Matrix {
+	return cfg.gameLayoutMatrix
+}
+
+// GetLayoutBounds returns the rectangle the game is laid out in before it is scaled to the window.
+func (cfg *Config) GetLayoutBounds() pixel.Rect {
+	return cfg.layoutBounds
+}
+
+// GetViewMatrix returns the matrix used to scale and centre the layout in the window.
+func (cfg *Config) GetViewMatrix() pixel.Matrix {
+	return cfg.viewMatrix
+}
+
+// GetViewScale returns the factor the layout is scaled by to fit in the window, sizes in pixels should be multiplied by this.
+func (cfg *Config) GetViewScale() float64 {
+	return cfg.viewScale
+}
+
 // GetGridSize returns the pixel size of the game grid
 func (cfg *Config) GetGridSize() float64 {
 	return cfg.gameGridSize
@@ -84,13 +117,3 @@ func (cfg *Config) GetGameAreaAsRec() pixel.Rect {
 func (cfg *Config) GetBorderWeight() float64 {
 	return cfg.gameAreaBorderThickness
 }
-
-// GenerateRandomBerry generates a new berry in a random location
-func GenerateRandomBerry(gameCFG *Config) pixel.Vec {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	x, y := gameCFG.GetGameAreaDims()
-	berryX := float64(r.Intn(int(x/gameCFG.GetGridSize()) - 1))
-	berryY := float64(r.Intn(int(y/gameCFG.GetGridSize()) - 1))
-	berry := pixel.V(berryX, berryY)
-	return gameCFG.GetGridMatrix().Project(berry)
-}
@@ -2,15 +2,18 @@ package gametext
 
 import (
 	"fmt"
+	"image/color"
+	"math"
 	"strconv"
 
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/editor"
 	"github.com/benjmarshall/gopixelsnake/game"
 	"github.com/benjmarshall/gopixelsnake/scores"
+	"github.com/benjmarshall/gopixelsnake/theme"
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/faiface/pixel/text"
-	"golang.org/x/image/colornames"
-	"golang.org/x/image/font/basicfont"
 )
 
 // Type holds the various text object for the game
@@ -21,28 +24,42 @@ type Type struct {
 	gameover     snaketext
 	gameoverText []string
 	startgame    snaketext
+	paused       snaketext
 	atlas        *text.Atlas
+	textColor    color.Color
+	sizeScale    float64
 }
 
-// snaketext is a wrapper around pixel.text which also holds scale information for drawing
+// snaketext is a wrapper around pixel.text which also holds scale information for drawing. If maxWidth or maxHeight
+// are set the text is scaled down when needed so it fits within them. Text which is rewritten as it is drawn sets
+// width to the width of its widest line at scale 1, otherwise the bounds of the text are used.
 type snaketext struct {
 	text      *text.Text
+	textScale float64
 	drawScale pixel.Matrix
+	width     float64
+	maxWidth  float64
+	maxHeight float64
 }
 
 // NewGameText generates a new game text structure used to control all text display for the game
-func NewGameText(win *pixelgl.Window, gameCFG game.Config) Type {
+func NewGameText(gameCFG game.Config, ctrl *controls.Type, th *theme.Type) Type {
 	t := new(Type)
 	// Create a text Atlas
-	t.atlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	t.atlas = text.NewAtlas(th.GetFace(), text.ASCII)
+	t.textColor = th.Text
+	t.sizeScale = th.TextScale
+
+	// Text is positioned within the layout bounds and scaled to the window when drawn
+	bounds := gameCFG.GetLayoutBounds()
 
 	// Create Game title
-	textColumnWidth := win.Bounds().W() - gameCFG.GetWindowMatrix().Project(gameCFG.GetGameAreaAsRec().Max).X
-	textOrigX := win.Bounds().W() - (textColumnWidth / 2)
-	textOrigY := win.Bounds().H() * 0.9
+	textColumnWidth := bounds.W() - gameCFG.GetLayoutMatrix().Project(gameCFG.GetGameAreaAsRec().Max).X
+	textOrigX := bounds.W() - (textColumnWidth / 2)
+	textOrigY := bounds.H() * 0.9
 	textOrig := pixel.V(textOrigX, textOrigY)
 	t.title.text = text.New(textOrig, t.atlas)
-	t.title.text.Color = colornames.Black
+	t.title.text.Color = t.textColor
 	lines := []string{
 		"Go Pixel",
 		"Snake",
@@ -51,59 +68,51 @@ func NewGameText(win *pixelgl.Window, gameCFG game.Config) Type {
 		t.title.text.Dot.X -= t.title.text.BoundsOf(line).W() / 2
 		fmt.Fprintln(t.title.text, line)
 	}
-	t.title.drawScale = pixel.IM.Scaled(t.title.text.Orig, 4)
+	t.title.textScale = 4
+	t.title.maxWidth = textColumnWidth
 
 	// Create Score Text
-	textOrigX = win.Bounds().W() - (textColumnWidth / 2)
-	textOrigY = win.Bounds().H() * 0.8
+	textOrigX = bounds.W() - (textColumnWidth / 2)
+	textOrigY = bounds.H() * 0.8
 	textOrig = pixel.V(textOrigX, textOrigY)
 	t.score.text = text.New(textOrig, t.atlas)
-	t.score.text.Color = colornames.Black
+	t.score.text.Color = t.textColor
 	scoreText := "0"
 	t.score.text.Dot.X = t.score.text.Orig.X - t.score.text.BoundsOf(scoreText).W()/2
 	fmt.Fprintln(t.score.text, scoreText)
-	t.score.drawScale = pixel.IM.Scaled(t.score.text.Orig, 6)
+	t.score.textScale = 6
 
 	// Create Controls Text
-	textOrigX = win.Bounds().W() - (textColumnWidth / 2)
-	textOrigY = win.Bounds().H() * 0.5
+	textOrigX = bounds.W() - (textColumnWidth / 2)
+	textOrigY = bounds.H() * 0.62
 	textOrig = pixel.V(textOrigX, textOrigY)
 	t.controls.text = text.New(textOrig, t.atlas)
-	lines = []string{
-		"Control Snake",
-		"Arrow Keys\n",
-		"High Scores",
-		"S\n",
-		"Exit",
-		"X",
-	}
-	t.controls.text.Color = colornames.Black
-	for _, line := range lines {
-		t.controls.text.Dot.X -= t.controls.text.BoundsOf(line).W() / 2
-		fmt.Fprintln(t.controls.text, line)
-	}
-	t.controls.text.LineHeight = 1.5
-	t.controls.drawScale = pixel.IM.Scaled(t.controls.text.Orig, 3)
+	t.controls.text.Color = t.textColor
+	t.controls.textScale = 2
+	t.controls.maxWidth = textColumnWidth
+	t.controls.maxHeight = textOrigY - 20
+	t.UpdateControlsText(ctrl)
 
 	// Create Start Game Text
-	textOrig = gameCFG.GetWindowMatrix().Project(gameCFG.GetGameAreaAsRec().Center())
+	textOrig = gameCFG.GetLayoutMatrix().Project(gameCFG.GetGameAreaAsRec().Center())
 	t.startgame.text = text.New(textOrig, t.atlas)
 	lines = []string{
 		"Hit an arrow key",
 		"to start a new game!",
 	}
-	t.startgame.text.Color = colornames.Black
+	t.startgame.text.Color = t.textColor
 	for _, line := range lines {
 		t.startgame.text.Dot.X -= t.startgame.text.BoundsOf(line).W() / 2
 		fmt.Fprintln(t.startgame.text, line)
 	}
 	t.startgame.text.Orig.Add(pixel.V(0, t.startgame.text.BoundsOf(lines[0]).H()))
-	t.startgame.drawScale = pixel.IM.Scaled(t.startgame.text.Orig, 3)
+	t.startgame.textScale = 3
+	t.startgame.maxWidth = gameCFG.GetGameAreaAsRec().W()
 
 	// Create Game Over Text
 	textOrigY = gameCFG.GetGameAreaAsRec().H() * 0.6
 	textOrigX = gameCFG.GetGameAreaAsRec().Center().X
-	textOrig = gameCFG.GetWindowMatrix().Project(pixel.V(textOrigX, textOrigY))
+	textOrig = gameCFG.GetLayoutMatrix().Project(pixel.V(textOrigX, textOrigY))
 	t.gameover.text = text.New(textOrig, t.atlas)
 	t.gameoverText = []string{
 		"Game Over!",
@@ -111,13 +120,50 @@ func NewGameText(win *pixelgl.Window, gameCFG game.Config) Type {
 		"Please type your name and then",
 		"Press Enter to continue...",
 	}
-	t.gameover.text.Color = colornames.Black
-	t.gameover.drawScale = pixel.IM.Scaled(t.gameover.text.Orig, 3)
+	t.gameover.text.Color = t.textColor
+	t.gameover.textScale = 3
+	for _, line := range t.gameoverText {
+		t.gameover.width = math.Max(t.gameover.width, t.gameover.text.BoundsOf(line).W())
+	}
+	t.gameover.maxWidth = gameCFG.GetGameAreaAsRec().W()
+
+	// Create Paused Text
+	textOrig = gameCFG.GetLayoutMatrix().Project(gameCFG.GetGameAreaAsRec().Center())
+	t.paused.text = text.New(textOrig, t.atlas)
+	lines = []string{
+		"Paused",
+	}
+	t.paused.text.Color = t.textColor
+	for _, line := range lines {
+		t.paused.text.Dot.X -= t.paused.text.BoundsOf(line).W() / 2
+		fmt.Fprintln(t.paused.text, line)
+	}
+	t.paused.textScale = 4
+
+	t.Layout(&gameCFG)
 
 	return *t
 
 }
 
+// Layout recalculates where the text is drawn, it should be called whenever the game config is resized
+func (t *Type) Layout(gameCFG *game.Config) {
+	for _, st := range []*snaketext{&t.title, &t.score, &t.controls, &t.gameover, &t.startgame, &t.paused} {
+		scale := st.textScale * t.sizeScale
+		size := st.text.Bounds().Size()
+		if st.width > 0 {
+			size.X = st.width
+		}
+		if st.maxWidth > 0 && size.X*scale > st.maxWidth {
+			scale = st.maxWidth / size.X
+		}
+		if st.maxHeight > 0 && size.Y*scale > st.maxHeight {
+			scale = st.maxHeight / size.Y
+		}
+		st.drawScale = pixel.IM.Scaled(st.text.Orig, scale).Chained(gameCFG.GetViewMatrix())
+	}
+}
+
 // DrawTitleText draws the title text on the window provided
 func (t *Type) DrawTitleText(win *pixelgl.Window) {
 	t.title.text.Draw(win, t.title.drawScale)
@@ -157,6 +203,21 @@ func (t *Type) DrawControlsText(win *pixelgl.Window) {
 	t.controls.text.Draw(win, t.controls.drawScale)
 }
 
+// UpdateControlsText regenerates the controls text from the current key bindings, Layout should be called after
+func (t *Type) UpdateControlsText(ctrl *controls.Type) {
+	t.controls.text.Clear()
+	t.controls.text.Dot.Y = t.controls.text.Orig.Y
+	for _, line := range ctrl.GetControlsText() {
+		t.controls.text.Dot.X -= t.controls.text.BoundsOf(line).W() / 2
+		fmt.Fprintln(t.controls.text, line)
+	}
+}
+
+// DrawPausedText draws the paused text on the provided window
+func (t *Type) DrawPausedText(win *pixelgl.Window) {
+	t.paused.text.Draw(win, t.paused.drawScale)
+}
+
 // DrawScoreText draws the score text on the provided window
 func (t *Type) DrawScoreText(win *pixelgl.Window, score int) {
 	scoreText := strconv.Itoa(score)
@@ -166,19 +227,137 @@ func (t *Type) DrawScoreText(win *pixelgl.Window, score int) {
 	t.score.text.Draw(win, t.score.drawScale)
 }
 
+// DrawTimerText draws a line of text centred above the game area
+func (t *Type) DrawTimerText(win *pixelgl.Window, gameCFG *game.Config, line string) {
+	area := gameCFG.GetGameAreaAsRec()
+	text := text.New(gameCFG.GetLayoutMatrix().Project(pixel.V(area.Center().X, area.Max.Y+8)), t.atlas)
+	text.Color = t.textColor
+	text.Dot.X -= text.BoundsOf(line).W() / 2
+	fmt.Fprint(text, line)
+	text.Draw(win, pixel.IM.Scaled(text.Orig, 2*t.sizeScale).Chained(gameCFG.GetViewMatrix()))
+}
+
+// DrawActiveEffectsText draws a line of text for each of the snake's effects in the panel under the score
+func (t *Type) DrawActiveEffectsText(win *pixelgl.Window, gameCFG *game.Config, lines []string) {
+	bounds := gameCFG.GetLayoutBounds()
+	textColumnWidth := bounds.W() - gameCFG.GetLayoutMatrix().Project(gameCFG.GetGameAreaAsRec().Max).X
+	text := text.New(pixel.V(bounds.W()-(textColumnWidth/2), bounds.H()*0.75), t.atlas)
+	text.Color = t.textColor
+	for _, line := range lines {
+		text.Dot.X -= text.BoundsOf(line).W() / 2
+		fmt.Fprintln(text, line)
+	}
+	text.Draw(win, pixel.IM.Scaled(text.Orig, 1.5*t.sizeScale).Chained(gameCFG.GetViewMatrix()))
+}
+
+// DrawEditorText draws the level editor's panel in place of the controls, see editor.PanelLines
+func (t *Type) DrawEditorText(win *pixelgl.Window, gameCFG *game.Config, ctrl *controls.Type, tools []string, selected int, status string) {
+	bounds := gameCFG.GetLayoutBounds()
+	textColumnWidth := bounds.W() - gameCFG.GetLayoutMatrix().Project(gameCFG.GetGameAreaAsRec().Max).X
+	text := text.New(pixel.V(bounds.W()-(textColumnWidth/2), bounds.H()*0.75), t.atlas)
+	text.Color = t.textColor
+	width := 0.0
+	for _, line := range editor.PanelLines(ctrl, tools, selected, status) {
+		width = math.Max(width, text.BoundsOf(line).W())
+		text.Dot.X -= text.BoundsOf(line).W() / 2
+		fmt.Fprintln(text, line)
+	}
+	// Shrink the panel if it doesn't fit beside the game area
+	scale := math.Min(1.5*t.sizeScale, textColumnWidth/width)
+	scale = math.Min(scale, (text.Orig.Y-20)/text.Bounds().H())
+	text.Draw(win, pixel.IM.Scaled(text.Orig, scale).Chained(gameCFG.GetViewMatrix()))
+}
+
+// DrawPopUpText draws a line of text centred on pos, which is in the game area coordinate plane, in the colour provided
+func (t *Type) DrawPopUpText(win *pixelgl.Window, gameCFG *game.Config, pos pixel.Vec, line string, col color.Color) {
+	text := text.New(gameCFG.GetLayoutMatrix().Project(pos), t.atlas)
+	text.Color = col
+	text.Dot.X -= text.BoundsOf(line).W() / 2
+	fmt.Fprint(text, line)
+	text.Draw(win, pixel.IM.Scaled(text.Orig, 2*t.sizeScale).Chained(gameCFG.GetViewMatrix()))
+}
+
 // DrawScoresListText draws the scores list on the provided window
 func (t *Type) DrawScoresListText(win *pixelgl.Window, gameCFG *game.Config, scoresTable *scores.Type) {
-	orig := gameCFG.GetWindowMatrix().Project(pixel.V(gameCFG.GetGameAreaAsRec().Min.X+35, gameCFG.GetGameAreaAsRec().Max.Y-50))
+	orig := gameCFG.GetLayoutMatrix().Project(pixel.V(gameCFG.GetGameAreaAsRec().Min.X+35, gameCFG.GetGameAreaAsRec().Max.Y-50))
 	lines := scoresTable.GetTopScores()
 	for i := 0; i < 3; i++ {
 		origY := orig.Y
 		origX := orig.X + (float64(i) * gameCFG.GetGameAreaAsRec().W() * 0.35)
 		text := text.New(pixel.V(origX, origY), t.atlas)
-		text.Color = colornames.Black
+		text.Color = t.textColor
 		text.LineHeight *= 1.5
 		for _, line := range lines {
 			fmt.Fprintln(text, line[i])
 		}
-		text.Draw(win, pixel.IM.Scaled(text.Orig, 3))
+		text.Draw(win, pixel.IM.Scaled(text.Orig, 3).Chained(gameCFG.GetViewMatrix()))
+	}
+}
+
+// DrawRebindText draws the controls rebinding screen on the provided window. The action at
+// index selected is highlighted, and if waiting is true the user is prompted to press a key.
+func (t *Type) DrawRebindText(win *pixelgl.Window, gameCFG *game.Config, ctrl *controls.Type, selected int, waiting bool) {
+	orig := gameCFG.GetLayoutMatrix().Project(pixel.V(gameCFG.GetGameAreaAsRec().Min.X+35, gameCFG.GetGameAreaAsRec().Max.Y-50))
+	text := text.New(orig, t.atlas)
+	text.Color = t.textColor
+	text.LineHeight *= 1.5
+	for a := controls.Action(0); a < controls.NumActions; a++ {
+		marker := "  "
+		if int(a) == selected {
+			marker = "> "
+		}
+		key := ctrl.GetBinding(a).String()
+		if int(a) == selected && waiting {
+			key = "..."
+		}
+		fmt.Fprintf(text, "%s%-12s%s\n", marker, a.String(), key)
+	}
+	fmt.Fprintln(text, "")
+	if waiting {
+		fmt.Fprintln(text, "Press the new key")
+	} else {
+		fmt.Fprintf(text, "%s to change a key\n", ctrl.GetBinding(controls.Confirm).String())
+		fmt.Fprintf(text, "%s to reset all keys\n", ctrl.GetBinding(controls.Delete).String())
+		fmt.Fprintf(text, "%s to go back\n", ctrl.GetBinding(controls.Rebind).String())
+	}
+	// Shrink the list if there are too many actions to fit in the game area
+	scale := math.Min(2, (gameCFG.GetGameAreaAsRec().H()-60)/text.Bounds().H())
+	text.Draw(win, pixel.IM.Scaled(text.Orig, scale).Chained(gameCFG.GetViewMatrix()))
+}
+
+// DrawOptionsText draws the accessibility options screen on the provided window, with the option at index selected highlighted
+func (t *Type) DrawOptionsText(win *pixelgl.Window, gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	t.drawMenuText(win, gameCFG, names, values, selected, []string{
+		fmt.Sprintf("%s to change an option", ctrl.GetBinding(controls.Confirm).String()),
+		fmt.Sprintf("%s to go back", ctrl.GetBinding(controls.Options).String()),
+	})
+}
+
+// DrawLevelsText draws the level select screen on the provided window, with the level at index selected highlighted
+func (t *Type) DrawLevelsText(win *pixelgl.Window, gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	t.drawMenuText(win, gameCFG, names, values, selected, []string{
+		fmt.Sprintf("%s to play a level", ctrl.GetBinding(controls.Confirm).String()),
+		fmt.Sprintf("%s to go back", ctrl.GetBinding(controls.Levels).String()),
+	})
+}
+
+// drawMenuText draws a menu with a row for each name and value, highlighting the row at index selected, followed
+// by the help lines explaining how to use it
+func (t *Type) drawMenuText(win *pixelgl.Window, gameCFG *game.Config, names []string, values []string, selected int, help []string) {
+	orig := gameCFG.GetLayoutMatrix().Project(pixel.V(gameCFG.GetGameAreaAsRec().Min.X+35, gameCFG.GetGameAreaAsRec().Max.Y-50))
+	text := text.New(orig, t.atlas)
+	text.Color = t.textColor
+	text.LineHeight *= 1.5
+	for i := range names {
+		marker := "  "
+		if i == selected {
+			marker = "> "
+		}
+		fmt.Fprintf(text, "%s%-12s%s\n", marker, names[i], values[i])
+	}
+	fmt.Fprintln(text, "")
+	for _, line := range help {
+		fmt.Fprintln(text, line)
 	}
+	text.Draw(win, pixel.IM.Scaled(text.Orig, 2).Chained(gameCFG.GetViewMatrix()))
 }
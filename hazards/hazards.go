@@ -0,0 +1,264 @@
+package hazards
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/faiface/pixel"
+)
+
+// Kind is a type of hazard, each moves around the board in its own way
+type Kind int
+
+const (
+	// Patrol moves back and forth along a line, turning round at the end of its range or when something is in the way.
+	Patrol Kind = iota
+	// Ball moves diagonally, bouncing off the walls and the edges of the arena.
+	Ball
+	// Hunter chases the snake's head, going round anything in its way.
+	Hunter
+	// NumKinds is the number of kinds of hazard, it can be used to loop over all kinds.
+	NumKinds
+)
+
+// kindNames are the names used for each kind in level files
+var kindNames = map[Kind]string{
+	Patrol: "Patrol",
+	Ball:   "Ball",
+	Hunter: "Hunter",
+}
+
+// defaultSpeeds are how many grid squares each kind moves a second if its speed isn't set
+var defaultSpeeds = map[Kind]float64{
+	Patrol: 4,
+	Ball:   5,
+	Hunter: 2.5,
+}
+
+// MaxSpeed is the fastest a hazard can move, in grid squares a second
+const MaxSpeed = 50
+
+// CheckSpeed returns an error if a hazard can't move at speed, which is in grid squares a second. A speed of 0
+// uses the default for the hazard's kind.
+func CheckSpeed(speed float64) error {
+	if !(speed >= 0 && speed <= MaxSpeed) {
+		return fmt.Errorf("hazard speeds must be from 0 to %v", MaxSpeed)
+	}
+	return nil
+}
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// KindFromName returns the kind with the given name, ignoring case, and false if there isn't one
+func KindFromName(name string) (Kind, bool) {
+	for k, n := range kindNames {
+		if strings.EqualFold(n, name) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// Hazard is something which moves around the board on its own and kills the snake if they touch. Dir is the
+// grid square it moves by each step, patrols move along one axis and balls along both. Patrols turn round after
+// Range squares, a range of 0 means they only turn round when something is in the way. Speed is how many grid
+// squares it moves a second, 0 uses the default for its kind. Levels give Pos as a grid square, once the hazard
+// is on the board it is in the game area coordinate plane.
+type Hazard struct {
+	Kind      Kind
+	Pos       pixel.Vec
+	Dir       pixel.Vec
+	Range     int
+	Speed     float64
+	travelled int
+	wait      time.Duration
+}
+
+// Type holds the hazards on the board
+type Type struct {
+	hazards []Hazard
+}
+
+// State is a snapshot of a hazard part way through a game, with how far it has travelled along its range and how
+// long it has until its next step, which can be saved and used to restore it later
+type State struct {
+	Hazard    Hazard
+	Travelled int
+	Wait      time.Duration
+}
+
+// NewHazards returns a board with the hazards given, which have their positions as grid squares. Hazards without a
+// speed, or with one they can't move at, move at the default speed for their kind.
+func NewHazards(gameCFG *game.Config, hs []Hazard) Type {
+	t := new(Type)
+	t.hazards = []Hazard{}
+	for _, h := range hs {
+		if h.Speed <= 0 || CheckSpeed(h.Speed) != nil {
+			h.Speed = defaultSpeeds[h.Kind]
+		}
+		h.Pos = gameCFG.GetGridMatrix().Project(h.Pos)
+		h.wait = h.interval()
+		t.hazards = append(t.hazards, h)
+	}
+	return *t
+}
+
+// GetHazards returns the hazards on the board
+func (t *Type) GetHazards() []Hazard {
+	return t.hazards
+}
+
+// Update moves each hazard as many steps as it has had time for since the last update. Target is where the
+// hunters are heading, the snake's head, and blocked returns true for squares the hazards can't move into. Both are
+// in the game area coordinate plane. Hazards don't move into each other either. It returns true if any moved.
+func (t *Type) Update(gameCFG *game.Config, dt time.Duration, target pixel.Vec, blocked func(pixel.Vec) bool) bool {
+	moved := false
+	for i := range t.hazards {
+		h := &t.hazards[i]
+		h.wait -= dt
+		// A hazard too fast for its steps to be timed would never catch up, so it stays where it is
+		if h.interval() <= 0 {
+			continue
+		}
+		for h.wait <= 0 {
+			h.wait += h.interval()
+			free := func(pos pixel.Vec) bool {
+				return !blocked(pos) && !t.occupiedByOther(i, pos)
+			}
+			if t.step(gameCFG, h, target, free) {
+				moved = true
+			}
+		}
+	}
+	return moved
+}
+
+// GetState returns a snapshot of each hazard on the board
+func (t *Type) GetState() []State {
+	states := []State{}
+	for _, h := range t.hazards {
+		states = append(states, State{Hazard: h, Travelled: h.travelled, Wait: h.wait})
+	}
+	return states
+}
+
+// SetState puts the hazards back the way they were in the snapshots
+func (t *Type) SetState(states []State) {
+	t.hazards = []Hazard{}
+	for _, st := range states {
+		h := st.Hazard
+		h.travelled = st.Travelled
+		h.wait = st.Wait
+		t.hazards = append(t.hazards, h)
+	}
+}
+
+// Occupies returns true if there is a hazard in the grid square at pos, which is in the game area coordinate plane
+func (t *Type) Occupies(pos pixel.Vec) bool {
+	return t.occupiedByOther(-1, pos)
+}
+
+// Clear removes all the hazards from the board
+func (t *Type) Clear() {
+	t.hazards = []Hazard{}
+}
+
+// interval returns how long the hazard waits between steps
+func (h Hazard) interval() time.Duration {
+	return time.Duration(float64(time.Second) / h.Speed)
+}
+
+// step moves the hazard one square in the way its kind moves, if it can, returning true if it moved
+func (t *Type) step(gameCFG *game.Config, h *Hazard, target pixel.Vec, free func(pixel.Vec) bool) bool {
+	grid := gameCFG.GetGridMatrix()
+	square := roundVec(grid.Unproject(h.Pos))
+	next := func(dir pixel.Vec) pixel.Vec {
+		return grid.Project(square.Add(dir))
+	}
+	switch h.Kind {
+	case Patrol:
+		if (h.Range > 0 && h.travelled >= h.Range) || !free(next(h.Dir)) {
+			h.Dir = h.Dir.Scaled(-1)
+			h.travelled = 0
+		}
+		h.travelled++
+	case Ball:
+		// Bounce off whichever sides are in the way, or straight back if it has hit a corner
+		if !free(next(h.Dir)) {
+			bounced := false
+			if !free(next(pixel.V(h.Dir.X, 0))) {
+				h.Dir.X = -h.Dir.X
+				bounced = true
+			}
+			if !free(next(pixel.V(0, h.Dir.Y))) {
+				h.Dir.Y = -h.Dir.Y
+				bounced = true
+			}
+			if !bounced {
+				h.Dir = h.Dir.Scaled(-1)
+			}
+		}
+	case Hunter:
+		h.Dir = chase(square, roundVec(grid.Unproject(target)), func(dir pixel.Vec) bool {
+			return free(next(dir))
+		})
+	}
+	if h.Dir == pixel.ZV || !free(next(h.Dir)) {
+		return false
+	}
+	h.Pos = next(h.Dir)
+	return true
+}
+
+// chase returns the step from the square which gets closest to the target, trying the axis with furthest to go
+// first, or zero if none of the squares around it are free
+func chase(square pixel.Vec, target pixel.Vec, free func(pixel.Vec) bool) pixel.Vec {
+	d := target.Sub(square)
+	x, y := pixel.V(sign(d.X), 0), pixel.V(0, sign(d.Y))
+	if math.Abs(d.Y) > math.Abs(d.X) {
+		x, y = y, x
+	}
+	// Head towards the target if possible, otherwise step sideways around whatever is in the way
+	for _, dir := range []pixel.Vec{x, y, pixel.V(x.Y, x.X), pixel.V(-x.Y, -x.X), x.Scaled(-1)} {
+		if dir != pixel.ZV && free(dir) {
+			return dir
+		}
+	}
+	for _, dir := range []pixel.Vec{pixel.V(0, 1), pixel.V(1, 0), pixel.V(0, -1), pixel.V(-1, 0)} {
+		if free(dir) {
+			return dir
+		}
+	}
+	return pixel.ZV
+}
+
+// occupiedByOther returns true if a hazard other than the one at index i is at pos
+func (t *Type) occupiedByOther(i int, pos pixel.Vec) bool {
+	for j, h := range t.hazards {
+		if j != i && h.Pos == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// sign returns -1, 0 or 1 for negative, zero or positive values
+func sign(v float64) float64 {
+	if v < 0 {
+		return -1
+	} else if v > 0 {
+		return 1
+	}
+	return 0
+}
+
+// roundVec rounds a vector to the nearest grid square, so positions projected back to the game area match exactly
+func roundVec(v pixel.Vec) pixel.Vec {
+	return pixel.V(math.Round(v.X), math.Round(v.Y))
+}
@@ -0,0 +1,153 @@
+package hazards
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/faiface/pixel"
+)
+
+// newTestBoard returns a 10x10 grid and a blocked function which only blocks the squares off the grid and the walls
+// given, which are grid squares
+func newTestBoard(walls ...pixel.Vec) (game.Config, func(pixel.Vec) bool) {
+	gameCFG := game.NewGameConfig(100, 100, 2, 10, pixel.R(0, 0, 140, 120))
+	blocked := func(pos pixel.Vec) bool {
+		square := roundVec(gameCFG.GetGridMatrix().Unproject(pos))
+		if square.X < 0 || square.Y < 0 || square.X >= 10 || square.Y >= 10 {
+			return true
+		}
+		for _, w := range walls {
+			if square == w {
+				return true
+			}
+		}
+		return false
+	}
+	return gameCFG, blocked
+}
+
+// squares returns the grid square each hazard on the board is in
+func squares(gameCFG *game.Config, t *Type) []pixel.Vec {
+	ss := []pixel.Vec{}
+	for _, h := range t.GetHazards() {
+		ss = append(ss, roundVec(gameCFG.GetGridMatrix().Unproject(h.Pos)))
+	}
+	return ss
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		walls  []pixel.Vec
+		hazard Hazard
+		steps  int
+		want   pixel.Vec
+	}{
+		{"patrol moves", nil, Hazard{Kind: Patrol, Pos: pixel.V(2, 5), Dir: pixel.V(1, 0)}, 3, pixel.V(5, 5)},
+		{"patrol turns at its range", nil, Hazard{Kind: Patrol, Pos: pixel.V(2, 5), Dir: pixel.V(1, 0), Range: 2}, 3, pixel.V(3, 5)},
+		{"patrol turns at the edge", nil, Hazard{Kind: Patrol, Pos: pixel.V(8, 5), Dir: pixel.V(1, 0)}, 3, pixel.V(7, 5)},
+		{"patrol turns at a wall", []pixel.Vec{pixel.V(4, 5)}, Hazard{Kind: Patrol, Pos: pixel.V(2, 5), Dir: pixel.V(1, 0)}, 2, pixel.V(2, 5)},
+		{"ball moves diagonally", nil, Hazard{Kind: Ball, Pos: pixel.V(2, 2), Dir: pixel.V(1, 1)}, 3, pixel.V(5, 5)},
+		{"ball bounces off the top", nil, Hazard{Kind: Ball, Pos: pixel.V(5, 8), Dir: pixel.V(1, 1)}, 2, pixel.V(7, 8)},
+		{"ball bounces out of a corner", nil, Hazard{Kind: Ball, Pos: pixel.V(8, 8), Dir: pixel.V(1, 1)}, 2, pixel.V(8, 8)},
+		{"ball bounces straight back off the corner of a wall", []pixel.Vec{pixel.V(3, 3)}, Hazard{Kind: Ball, Pos: pixel.V(2, 2), Dir: pixel.V(1, 1)}, 1, pixel.V(1, 1)},
+		{"hunter chases", nil, Hazard{Kind: Hunter, Pos: pixel.V(0, 0), Dir: pixel.V(0, 0)}, 3, pixel.V(3, 0)},
+		{"hunter goes round a wall", []pixel.Vec{pixel.V(1, 0)}, Hazard{Kind: Hunter, Pos: pixel.V(0, 0), Dir: pixel.V(0, 0)}, 1, pixel.V(0, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameCFG, blocked := newTestBoard(tt.walls...)
+			tt.hazard.Speed = 10
+			hs := NewHazards(&gameCFG, []Hazard{tt.hazard})
+			target := gameCFG.GetGridMatrix().Project(pixel.V(9, 0))
+			for i := 0; i < tt.steps; i++ {
+				hs.Update(&gameCFG, 100*time.Millisecond, target, blocked)
+			}
+			if got := squares(&gameCFG, &hs); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("after %d steps the hazard is at %v, want %v", tt.steps, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateWaits(t *testing.T) {
+	gameCFG, blocked := newTestBoard()
+	hs := NewHazards(&gameCFG, []Hazard{{Kind: Patrol, Pos: pixel.V(2, 5), Dir: pixel.V(1, 0), Speed: 10}})
+	if hs.Update(&gameCFG, 50*time.Millisecond, pixel.ZV, blocked) {
+		t.Error("the hazard moved before its first step was due")
+	}
+	if !hs.Update(&gameCFG, 50*time.Millisecond, pixel.ZV, blocked) {
+		t.Error("the hazard didn't move when its first step was due")
+	}
+	// A long update catches up on every step it missed
+	hs.Update(&gameCFG, 300*time.Millisecond, pixel.ZV, blocked)
+	if got := squares(&gameCFG, &hs); got[0] != pixel.V(6, 5) {
+		t.Errorf("the hazard is at %v, want %v", got[0], pixel.V(6, 5))
+	}
+}
+
+func TestHazardsDontCollide(t *testing.T) {
+	gameCFG, blocked := newTestBoard()
+	// Two patrols heading towards each other with a square between them
+	hs := NewHazards(&gameCFG, []Hazard{
+		{Kind: Patrol, Pos: pixel.V(2, 5), Dir: pixel.V(1, 0), Speed: 10},
+		{Kind: Patrol, Pos: pixel.V(4, 5), Dir: pixel.V(-1, 0), Speed: 10},
+	})
+	for i := 0; i < 4; i++ {
+		hs.Update(&gameCFG, 100*time.Millisecond, pixel.ZV, blocked)
+		got := squares(&gameCFG, &hs)
+		if got[0] == got[1] {
+			t.Fatalf("after %d steps both hazards are at %v", i+1, got[0])
+		}
+	}
+	if !hs.Occupies(gameCFG.GetGridMatrix().Project(squares(&gameCFG, &hs)[0])) {
+		t.Error("Occupies() is false for a square with a hazard in it")
+	}
+	if hs.Occupies(gameCFG.GetGridMatrix().Project(pixel.V(9, 9))) {
+		t.Error("Occupies() is true for an empty square")
+	}
+}
+
+func TestNewHazardsSpeed(t *testing.T) {
+	gameCFG, _ := newTestBoard()
+	for _, speed := range []float64{0, -1, MaxSpeed + 1, 1e12, math.Inf(1), math.NaN()} {
+		hs := NewHazards(&gameCFG, []Hazard{{Kind: Ball, Pos: pixel.V(2, 2), Dir: pixel.V(1, 1), Speed: speed}})
+		if got := hs.GetHazards()[0].Speed; got != defaultSpeeds[Ball] {
+			t.Errorf("a hazard with a speed of %v moves at %v, want the default %v", speed, got, defaultSpeeds[Ball])
+		}
+	}
+}
+
+func TestUpdateUnusableSpeed(t *testing.T) {
+	gameCFG, blocked := newTestBoard()
+	for _, speed := range []float64{1e12, math.Inf(1), math.NaN()} {
+		// Saved hazards are put back as they were, so the speed isn't replaced by the default
+		hs := Type{}
+		hs.SetState([]State{{Hazard: Hazard{Kind: Ball, Pos: gameCFG.GetGridMatrix().Project(pixel.V(2, 2)), Dir: pixel.V(1, 1), Speed: speed}}})
+		done := make(chan struct{})
+		go func() {
+			hs.Update(&gameCFG, time.Second, pixel.ZV, blocked)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Update() didn't return for a hazard with a speed of %v", speed)
+		}
+	}
+}
+
+func TestCheckSpeed(t *testing.T) {
+	for _, speed := range []float64{0, 0.5, 4, MaxSpeed} {
+		if err := CheckSpeed(speed); err != nil {
+			t.Errorf("CheckSpeed(%v) = %v, want nil", speed, err)
+		}
+	}
+	for _, speed := range []float64{-1, MaxSpeed + 1, 1e12, math.Inf(1), math.NaN()} {
+		if CheckSpeed(speed) == nil {
+			t.Errorf("CheckSpeed(%v) didn't return an error", speed)
+		}
+	}
+}
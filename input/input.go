@@ -0,0 +1,72 @@
+package input
+
+import (
+	"github.com/benjmarshall/gopixelsnake/controls"
+)
+
+// Source is implemented by anything which can report the actions requested by the player
+type Source interface {
+	// Poll returns the actions which have been requested since the last poll
+	Poll() []controls.Action
+}
+
+// TextSource is implemented by sources which can also report typed text
+type TextSource interface {
+	// Typed returns the text typed since the last poll
+	Typed() string
+}
+
+// Type gathers the actions requested from all of the input sources once per frame
+type Type struct {
+	sources   []Source
+	pressed   map[controls.Action]bool
+	typed     string
+	synthetic []controls.Action
+}
+
+// NewInput returns an initialised input structure reading from the sources provided
+func NewInput(sources ...Source) Type {
+	t := new(Type)
+	t.sources = sources
+	t.pressed = map[controls.Action]bool{}
+	return *t
+}
+
+// AddSource adds another source of actions
+func (t *Type) AddSource(src Source) {
+	t.sources = append(t.sources, src)
+}
+
+// Inject queues an action as if it had come from a source, it will be reported after the next Update.
+// This can be used to feed synthetic events into the game, e.g. from tests or replays.
+func (t *Type) Inject(a controls.Action) {
+	t.synthetic = append(t.synthetic, a)
+}
+
+// Update polls all of the sources, it should be called once per frame before checking for actions
+func (t *Type) Update() {
+	t.pressed = map[controls.Action]bool{}
+	t.typed = ""
+	for _, a := range t.synthetic {
+		t.pressed[a] = true
+	}
+	t.synthetic = []controls.Action{}
+	for _, src := range t.sources {
+		for _, a := range src.Poll() {
+			t.pressed[a] = true
+		}
+		if textSrc, ok := src.(TextSource); ok {
+			t.typed += textSrc.Typed()
+		}
+	}
+}
+
+// JustPressed returns true if the action was requested by any source during the last Update
+func (t *Type) JustPressed(a controls.Action) bool {
+	return t.pressed[a]
+}
+
+// Typed returns the text typed during the last Update
+func (t *Type) Typed() string {
+	return t.typed
+}
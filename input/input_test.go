@@ -0,0 +1,172 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// fakePad is a single gamepad plugged in as the first joystick
+type fakePad struct {
+	buttons int
+	pressed map[int]bool
+	axes    []float64
+}
+
+func (p *fakePad) JoystickPresent(js pixelgl.Joystick) bool {
+	return js == pixelgl.Joystick1
+}
+
+func (p *fakePad) JoystickButtonCount(js pixelgl.Joystick) int {
+	return p.buttons
+}
+
+func (p *fakePad) JoystickAxisCount(js pixelgl.Joystick) int {
+	return len(p.axes)
+}
+
+func (p *fakePad) JoystickJustPressed(js pixelgl.Joystick, button int) bool {
+	return p.pressed[button]
+}
+
+func (p *fakePad) JoystickAxis(js pixelgl.Joystick, axis int) float64 {
+	return p.axes[axis]
+}
+
+// newTestJoystick returns a joystick source reading from the pad with the default buttons and deadzone
+func newTestJoystick(pad *fakePad) *Joystick {
+	// The settings file doesn't exist, so the defaults are used
+	set := settings.NewSettings("input_test_settings.csv")
+	return newJoystick(pad, &set)
+}
+
+func TestInject(t *testing.T) {
+	in := NewInput()
+	in.Inject(controls.MoveUp)
+	in.Inject(controls.Pause)
+	if in.JustPressed(controls.MoveUp) {
+		t.Errorf("an injected action was reported before Update")
+	}
+	in.Update()
+	for _, a := range []controls.Action{controls.MoveUp, controls.Pause} {
+		if !in.JustPressed(a) {
+			t.Errorf("JustPressed(%v) = false after it was injected", controls.SettingsName(a))
+		}
+	}
+	if in.JustPressed(controls.MoveDown) {
+		t.Errorf("JustPressed(MoveDown) = true but it wasn't injected")
+	}
+	in.Update()
+	if in.JustPressed(controls.MoveUp) {
+		t.Errorf("an injected action was still reported on the next Update")
+	}
+}
+
+func TestJoystick(t *testing.T) {
+	tests := []struct {
+		name    string
+		pressed []int
+		axes    []float64
+		inject  []controls.Action
+		want    []controls.Action
+	}{
+		{
+			name:    "D-pad up",
+			pressed: []int{10},
+			axes:    []float64{0, 0},
+			want:    []controls.Action{controls.MoveUp},
+		},
+		{
+			name:    "D-pad left",
+			pressed: []int{13},
+			axes:    []float64{0, 0},
+			want:    []controls.Action{controls.MoveLeft},
+		},
+		{
+			name: "stick right past the deadzone",
+			axes: []float64{0.8, 0.1},
+			want: []controls.Action{controls.MoveRight},
+		},
+		{
+			name: "stick up past the deadzone",
+			axes: []float64{0.1, -0.9},
+			want: []controls.Action{controls.MoveUp},
+		},
+		{
+			name: "stick inside the deadzone",
+			axes: []float64{0.3, -0.3},
+			want: []controls.Action{},
+		},
+		{
+			name:    "start button",
+			pressed: []int{7},
+			axes:    []float64{0, 0},
+			want:    []controls.Action{controls.Pause},
+		},
+		{
+			name:    "A button",
+			pressed: []int{0},
+			axes:    []float64{0, 0},
+			want:    []controls.Action{controls.Confirm},
+		},
+		{
+			name:    "button past the pad's button count",
+			pressed: []int{20},
+			axes:    []float64{0, 0},
+			want:    []controls.Action{},
+		},
+		{
+			name:    "pad and injected actions together",
+			pressed: []int{12},
+			axes:    []float64{0, 0},
+			inject:  []controls.Action{controls.Pause},
+			want:    []controls.Action{controls.MoveDown, controls.Pause},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pad := &fakePad{buttons: 16, pressed: map[int]bool{}, axes: tt.axes}
+			for _, button := range tt.pressed {
+				pad.pressed[button] = true
+			}
+			in := NewInput(newTestJoystick(pad))
+			for _, a := range tt.inject {
+				in.Inject(a)
+			}
+			in.Update()
+			want := map[controls.Action]bool{}
+			for _, a := range tt.want {
+				want[a] = true
+			}
+			for a := controls.Action(0); a < controls.NumActions; a++ {
+				if got := in.JustPressed(a); got != want[a] {
+					t.Errorf("JustPressed(%v) = %v, want %v", controls.SettingsName(a), got, want[a])
+				}
+			}
+		})
+	}
+}
+
+func TestJoystickStickHeld(t *testing.T) {
+	pad := &fakePad{buttons: 16, pressed: map[int]bool{}, axes: []float64{0.9, 0}}
+	in := NewInput(newTestJoystick(pad))
+	in.Update()
+	if !in.JustPressed(controls.MoveRight) {
+		t.Fatalf("JustPressed(MoveRight) = false when the stick was pushed right")
+	}
+	// Holding the stick in the same direction doesn't repeat the action
+	in.Update()
+	if in.JustPressed(controls.MoveRight) {
+		t.Errorf("JustPressed(MoveRight) = true while the stick was held right")
+	}
+	// Letting the stick go back to the middle and pushing it again does
+	pad.axes = []float64{0, 0}
+	in.Update()
+	pad.axes = []float64{0.9, 0}
+	in.Update()
+	if !in.JustPressed(controls.MoveRight) {
+		t.Errorf("JustPressed(MoveRight) = false when the stick was pushed right again")
+	}
+}
@@ -0,0 +1,128 @@
+package input
+
+import (
+	"math"
+
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// defaultPadButtons maps gamepad button numbers onto actions, the defaults follow the usual
+// XInput layout: A, B, Y, Back, Start and the D-pad.
+var defaultPadButtons = map[controls.Action]int{
+	controls.Confirm:    0,
+	controls.Delete:     1,
+	controls.ShowScores: 3,
+	controls.Quit:       6,
+	controls.Pause:      7,
+	controls.MoveUp:     10,
+	controls.MoveRight:  11,
+	controls.MoveDown:   12,
+	controls.MoveLeft:   13,
+}
+
+// joysticks is the part of the window which reports the state of the joysticks
+type joysticks interface {
+	JoystickPresent(js pixelgl.Joystick) bool
+	JoystickButtonCount(js pixelgl.Joystick) int
+	JoystickAxisCount(js pixelgl.Joystick) int
+	JoystickJustPressed(js pixelgl.Joystick, button int) bool
+	JoystickAxis(js pixelgl.Joystick, axis int) float64
+}
+
+// Joystick is a source of actions from any connected joysticks or gamepads. The D-pad and
+// the left stick steer the snake, and joysticks can be plugged in or removed at any time.
+type Joystick struct {
+	win      joysticks
+	deadzone float64
+	buttons  map[controls.Action]int
+	xAxis    int
+	yAxis    int
+	present  map[pixelgl.Joystick]bool
+	stickDir map[pixelgl.Joystick]controls.Action
+}
+
+// NewJoystick returns a joystick source for the window provided, button numbers and the
+// stick deadzone are read from the settings so they can be changed for unusual pads.
+func NewJoystick(win *pixelgl.Window, set *settings.Type) *Joystick {
+	return newJoystick(win, set)
+}
+
+// newJoystick returns a joystick source reading from any joysticks provided
+func newJoystick(win joysticks, set *settings.Type) *Joystick {
+	j := new(Joystick)
+	j.win = win
+	j.deadzone = set.GetFloat("pad.Deadzone", 0.5)
+	j.xAxis = set.GetInt("pad.XAxis", 0)
+	j.yAxis = set.GetInt("pad.YAxis", 1)
+	j.buttons = map[controls.Action]int{}
+	for a, button := range defaultPadButtons {
+		j.buttons[a] = set.GetInt(padSettingsKey(a), button)
+	}
+	j.present = map[pixelgl.Joystick]bool{}
+	j.stickDir = map[pixelgl.Joystick]controls.Action{}
+	return j
+}
+
+// Poll returns the actions requested on any connected joystick since the last window update
+func (j *Joystick) Poll() []controls.Action {
+	actions := []controls.Action{}
+	for js := pixelgl.Joystick1; js <= pixelgl.JoystickLast; js++ {
+		if !j.win.JoystickPresent(js) {
+			if j.present[js] {
+				// Joystick has been unplugged, forget its state
+				delete(j.present, js)
+				delete(j.stickDir, js)
+			}
+			continue
+		}
+		j.present[js] = true
+
+		buttonCount := j.win.JoystickButtonCount(js)
+		for a, button := range j.buttons {
+			if button < buttonCount && j.win.JoystickJustPressed(js, button) {
+				actions = append(actions, a)
+			}
+		}
+
+		// Only report the stick when it moves into a new direction so holding it doesn't repeat
+		if a, ok := j.stickDirection(js); ok {
+			if prev, held := j.stickDir[js]; !held || prev != a {
+				actions = append(actions, a)
+			}
+			j.stickDir[js] = a
+		} else {
+			delete(j.stickDir, js)
+		}
+	}
+	return actions
+}
+
+// stickDirection returns the direction the stick of a joystick is pushed in, if it is outside the deadzone
+func (j *Joystick) stickDirection(js pixelgl.Joystick) (controls.Action, bool) {
+	if j.win.JoystickAxisCount(js) <= j.xAxis || j.win.JoystickAxisCount(js) <= j.yAxis {
+		return controls.NumActions, false
+	}
+	x := j.win.JoystickAxis(js, j.xAxis)
+	y := j.win.JoystickAxis(js, j.yAxis)
+	if math.Hypot(x, y) < j.deadzone {
+		return controls.NumActions, false
+	}
+	// Joystick axes report up as negative
+	if math.Abs(x) > math.Abs(y) {
+		if x > 0 {
+			return controls.MoveRight, true
+		}
+		return controls.MoveLeft, true
+	}
+	if y > 0 {
+		return controls.MoveDown, true
+	}
+	return controls.MoveUp, true
+}
+
+// padSettingsKey returns the key used to store an action's gamepad button in the settings
+func padSettingsKey(a controls.Action) string {
+	return "pad." + controls.SettingsName(a)
+}
@@ -0,0 +1,69 @@
+package input
+
+import (
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// Keyboard is a source of actions from the window keyboard using the current key bindings
+type Keyboard struct {
+	win     *pixelgl.Window
+	ctrl    *controls.Type
+	buttons map[controls.Key]pixelgl.Button
+}
+
+// NewKeyboard returns a keyboard source for the window and key bindings provided
+func NewKeyboard(win *pixelgl.Window, ctrl *controls.Type) *Keyboard {
+	return &Keyboard{win: win, ctrl: ctrl, buttons: map[controls.Key]pixelgl.Button{}}
+}
+
+// Poll returns the actions whose keys were pressed since the last window update
+func (k *Keyboard) Poll() []controls.Action {
+	actions := []controls.Action{}
+	for a := controls.Action(0); a < controls.NumActions; a++ {
+		if button, ok := k.button(k.ctrl.GetBinding(a)); ok && k.win.JustPressed(button) {
+			actions = append(actions, a)
+		}
+	}
+	return actions
+}
+
+// Typed returns the text typed since the last window update
+func (k *Keyboard) Typed() string {
+	return k.win.Typed()
+}
+
+// button returns the window button for a key, remembering it as the bindings are looked up every frame
+func (k *Keyboard) button(key controls.Key) (pixelgl.Button, bool) {
+	if button, ok := k.buttons[key]; ok {
+		return button, true
+	}
+	button, ok := ButtonFromKey(key)
+	if ok {
+		k.buttons[key] = button
+	}
+	return button, ok
+}
+
+// JustPressedAny returns the first key pressed since the last window update, if there was one
+func JustPressedAny(win *pixelgl.Window) (controls.Key, bool) {
+	for button := pixelgl.KeySpace; button <= pixelgl.KeyLast; button++ {
+		if win.JustPressed(button) {
+			return controls.Key(button.String()), true
+		}
+	}
+	return "", false
+}
+
+// ButtonFromKey returns the window button for a key, returning false if no button has the key's name
+func ButtonFromKey(key controls.Key) (pixelgl.Button, bool) {
+	if key == "" {
+		return pixelgl.KeyUnknown, false
+	}
+	for button := pixelgl.KeySpace; button <= pixelgl.KeyLast; button++ {
+		if button.String() == key.String() {
+			return button, true
+		}
+	}
+	return pixelgl.KeyUnknown, false
+}
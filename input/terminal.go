@@ -0,0 +1,118 @@
+package input
+
+import (
+	"io"
+
+	"github.com/benjmarshall/gopixelsnake/controls"
+)
+
+// terminalKeys maps the single byte keys used in the terminal onto actions
+var terminalKeys = map[byte]controls.Action{
+	'p':  controls.Pause,
+	'P':  controls.Pause,
+	's':  controls.ShowScores,
+	'S':  controls.ShowScores,
+	'x':  controls.Quit,
+	'X':  controls.Quit,
+	't':  controls.NextTheme,
+	'T':  controls.NextTheme,
+	'o':  controls.Options,
+	'O':  controls.Options,
+	'l':  controls.Levels,
+	'L':  controls.Levels,
+	'g':  controls.SaveGame,
+	'G':  controls.SaveGame,
+	'\r': controls.Confirm,
+	'\n': controls.Confirm,
+	0x7f: controls.Delete,
+	0x08: controls.Delete,
+}
+
+// terminalArrows maps the final byte of the arrow key escape sequences onto actions
+var terminalArrows = map[byte]controls.Action{
+	'A': controls.MoveUp,
+	'B': controls.MoveDown,
+	'C': controls.MoveRight,
+	'D': controls.MoveLeft,
+}
+
+// Terminal is a source of actions from a terminal in raw mode. The keys are fixed: the arrow keys
+// steer the snake, P pauses, S shows the high scores, X exits, Enter confirms and Backspace deletes.
+type Terminal struct {
+	bytes   chan byte
+	pending []byte
+	typed   string
+}
+
+// NewTerminal returns a terminal source which reads key presses from in
+func NewTerminal(in io.Reader) *Terminal {
+	t := new(Terminal)
+	t.bytes = make(chan byte, 64)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := in.Read(buf)
+			for _, b := range buf[:n] {
+				t.bytes <- b
+			}
+			if err != nil {
+				close(t.bytes)
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// Poll returns the actions for the keys pressed since the last poll
+func (t *Terminal) Poll() []controls.Action {
+	actions := []controls.Action{}
+	t.typed = ""
+	// Collect everything which has been read without blocking
+	for done := false; !done; {
+		select {
+		case b, ok := <-t.bytes:
+			if !ok {
+				// Input has been closed so there is nothing left to do but quit
+				actions = append(actions, controls.Quit)
+				done = true
+				break
+			}
+			t.pending = append(t.pending, b)
+		default:
+			done = true
+		}
+	}
+
+	for len(t.pending) > 0 {
+		b := t.pending[0]
+		if b == 0x1b {
+			// Arrow keys arrive as ESC [ A, wait for the rest of the sequence if it is incomplete
+			if len(t.pending) >= 2 && t.pending[1] != '[' {
+				t.pending = t.pending[1:]
+				continue
+			}
+			if len(t.pending) < 3 {
+				break
+			}
+			if a, ok := terminalArrows[t.pending[2]]; ok && t.pending[1] == '[' {
+				actions = append(actions, a)
+			}
+			t.pending = t.pending[3:]
+			continue
+		}
+		if a, ok := terminalKeys[b]; ok {
+			actions = append(actions, a)
+		}
+		if b >= ' ' && b < 0x7f {
+			t.typed += string(b)
+		}
+		t.pending = t.pending[1:]
+	}
+	return actions
+}
+
+// Typed returns the printable characters typed since the last poll
+func (t *Terminal) Typed() string {
+	return t.typed
+}
@@ -0,0 +1,502 @@
+package levels
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+	"github.com/shibukawa/configdir"
+)
+
+// Level is an arena the snake can be played in. Walls, portals, the hazards' starting points, the berry zones and
+// the spawn point are grid squares, the snake starts with its head on the spawn point heading in the spawn direction
+// at the level's speed. Portals come in pairs, the snake goes in one and comes out of the other. If the level has
+// berry zones berries only appear in them, otherwise they can appear anywhere. The level is complete when the snake
+// is TargetLength long or has scored TargetScore points in it, a target of 0 isn't used.
+type Level struct {
+	Name         string
+	Walls        []pixel.Vec
+	Portals      [][2]pixel.Vec
+	Hazards      []hazards.Hazard
+	BerryZones   []pixel.Vec
+	Spawn        pixel.Vec
+	Heading      snake.Direction
+	Speed        float64
+	TargetLength int
+	TargetScore  int
+}
+
+// Open returns a level with no walls for a cols by rows grid, with the snake starting in the middle heading up
+func Open(name string, cols int, rows int) Level {
+	return Level{
+		Name:       name,
+		Walls:      []pixel.Vec{},
+		Portals:    [][2]pixel.Vec{},
+		Hazards:    []hazards.Hazard{},
+		BerryZones: []pixel.Vec{},
+		Spawn:      pixel.V(float64(cols/2), float64(rows/2)),
+		Heading:    snake.UP,
+		Speed:      2,
+	}
+}
+
+// Copy returns a copy of the level which can be changed without changing this one
+func (l Level) Copy() Level {
+	c := l
+	c.Walls = append([]pixel.Vec{}, l.Walls...)
+	c.Portals = append([][2]pixel.Vec{}, l.Portals...)
+	c.Hazards = append([]hazards.Hazard{}, l.Hazards...)
+	c.BerryZones = append([]pixel.Vec{}, l.BerryZones...)
+	return c
+}
+
+// Completed returns true if the snake has reached one of the level's targets, score is the points scored in the level
+func (l Level) Completed(length int, score int) bool {
+	return (l.TargetLength > 0 && length >= l.TargetLength) || (l.TargetScore > 0 && score >= l.TargetScore)
+}
+
+// GetTargetText returns how close the snake is to the level's target, e.g. 12/20 for a length target, score is
+// the points scored in the level
+func (l Level) GetTargetText(length int, score int) string {
+	if l.TargetLength > 0 {
+		return fmt.Sprintf("%d/%d", length, l.TargetLength)
+	}
+	if l.TargetScore > 0 {
+		return fmt.Sprintf("%d/%d", score, l.TargetScore)
+	}
+	return ""
+}
+
+// GetPortalMap returns the grid square of each portal's partner, keyed by the grid square of the portal
+func (l Level) GetPortalMap() map[pixel.Vec]pixel.Vec {
+	portals := map[pixel.Vec]pixel.Vec{}
+	for _, pair := range l.Portals {
+		portals[pair[0]] = pair[1]
+		portals[pair[1]] = pair[0]
+	}
+	return portals
+}
+
+// InBerryZone returns true if berries can appear in the grid square, which they can anywhere if the level has no
+// berry zones
+func (l Level) InBerryZone(square pixel.Vec) bool {
+	if len(l.BerryZones) == 0 {
+		return true
+	}
+	for _, zone := range l.BerryZones {
+		if zone == square {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWall returns true if there is a wall in the grid square
+func (l Level) IsWall(square pixel.Vec) bool {
+	for _, wall := range l.Walls {
+		if wall == square {
+			return true
+		}
+	}
+	return false
+}
+
+// Block returns the grid squares in the rectangle w squares wide and h squares high with its bottom left at x, y
+func Block(x int, y int, w int, h int) []pixel.Vec {
+	squares := []pixel.Vec{}
+	for i := x; i < x+w; i++ {
+		for j := y; j < y+h; j++ {
+			squares = append(squares, pixel.V(float64(i), float64(j)))
+		}
+	}
+	return squares
+}
+
+// Campaign returns the stages of the campaign for a cols by rows grid, in the order they are played. Each stage
+// is faster and longer than the last, with more walls in the way.
+func Campaign(cols int, rows int) []Level {
+	open := Open("Open Field", cols, rows)
+	open.TargetLength = 15
+
+	pillars := Open("Pillars", cols, rows)
+	pillars.Speed = 3
+	pillars.TargetLength = 20
+	for _, x := range []int{cols / 4, cols * 3 / 4} {
+		for _, y := range []int{rows / 4, rows * 3 / 4} {
+			pillars.Walls = append(pillars.Walls, Block(x-2, y-2, 4, 4)...)
+		}
+	}
+
+	// A cross through the middle with a gap in each arm, the snake starts in the bottom left quarter
+	cross := Open("Cross", cols, rows)
+	cross.Speed = 3
+	cross.TargetLength = 25
+	cross.Spawn = pixel.V(float64(cols/4), float64(rows/4))
+	cross.Heading = snake.RIGHT
+	c, m, gap := cols/2, rows/2, 4
+	for _, arm := range [][4]int{
+		{cols / 8, m, c - 2 - gap - cols/8, 1},
+		{c + 3 + gap, m, cols - cols/8 - (c + 3 + gap), 1},
+		{c, rows / 8, 1, m - 2 - gap - rows/8},
+		{c, m + 3 + gap, 1, rows - rows/8 - (m + 3 + gap)},
+		{c - 2, m - 2, 5, 5},
+	} {
+		cross.Walls = append(cross.Walls, Block(arm[0], arm[1], arm[2], arm[3])...)
+	}
+
+	// Walls across a third and two thirds of the way up, open at opposite ends
+	corridors := Open("Corridors", cols, rows)
+	corridors.Speed = 4
+	corridors.TargetLength = 30
+	corridors.Spawn = pixel.V(float64(cols/2), float64(rows/6))
+	corridors.Heading = snake.RIGHT
+	corridors.Walls = append(corridors.Walls, Block(0, rows/3, cols-cols/6, 1)...)
+	corridors.Walls = append(corridors.Walls, Block(cols/6, rows*2/3, cols-cols/6, 1)...)
+
+	// A wall down the middle, the only way across is through the portals
+	portals := Open("Portals", cols, rows)
+	portals.Speed = 4
+	portals.TargetLength = 30
+	portals.Spawn = pixel.V(float64(cols/4), float64(rows/2))
+	portals.Walls = append(portals.Walls, Block(cols/2, 0, 1, rows)...)
+	portals.Portals = append(portals.Portals,
+		[2]pixel.Vec{pixel.V(float64(cols/4), float64(rows*3/4)), pixel.V(float64(cols*3/4), float64(rows/4))},
+		[2]pixel.Vec{pixel.V(float64(cols/4), float64(rows/4)), pixel.V(float64(cols*3/4), float64(rows*3/4))},
+	)
+
+	// Blocks patrolling across the board, balls bouncing round it and a hunter chasing the snake
+	hunted := Open("Hunted", cols, rows)
+	hunted.Speed = 4
+	hunted.TargetLength = 30
+	hunted.Spawn = pixel.V(float64(cols/2), float64(rows/8))
+	hunted.Heading = snake.RIGHT
+	hunted.Hazards = append(hunted.Hazards,
+		hazards.Hazard{Kind: hazards.Patrol, Pos: pixel.V(0, float64(rows/3)), Dir: pixel.V(1, 0)},
+		hazards.Hazard{Kind: hazards.Patrol, Pos: pixel.V(float64(cols-1), float64(rows*2/3)), Dir: pixel.V(-1, 0)},
+		hazards.Hazard{Kind: hazards.Ball, Pos: pixel.V(float64(cols/4), float64(rows/2)), Dir: pixel.V(1, 1)},
+		hazards.Hazard{Kind: hazards.Ball, Pos: pixel.V(float64(cols*3/4), float64(rows/2)), Dir: pixel.V(-1, -1)},
+		hazards.Hazard{Kind: hazards.Hunter, Pos: pixel.V(float64(cols/2), float64(rows-1))},
+	)
+
+	// A box in the middle with a gap in each side, scoring points is the only way out
+	box := Open("Box", cols, rows)
+	box.Speed = 5
+	box.TargetScore = 5000
+	inset := cols / 6
+	side := cols - 2*inset
+	gap = 3
+	for _, wall := range [][4]int{
+		{inset, inset, side/2 - gap, 1},
+		{inset + side/2 + gap, inset, side - side/2 - gap, 1},
+		{inset, rows - inset - 1, side/2 - gap, 1},
+		{inset + side/2 + gap, rows - inset - 1, side - side/2 - gap, 1},
+		{inset, inset, 1, (rows-2*inset)/2 - gap},
+		{inset, rows/2 + gap, 1, rows - inset - rows/2 - gap},
+		{cols - inset - 1, inset, 1, (rows-2*inset)/2 - gap},
+		{cols - inset - 1, rows/2 + gap, 1, rows - inset - rows/2 - gap},
+	} {
+		box.Walls = append(box.Walls, Block(wall[0], wall[1], wall[2], wall[3])...)
+	}
+
+	return []Level{open, pillars, cross, corridors, portals, hunted, box}
+}
+
+// LoadLevels returns the campaign followed by any level files in the game's config folder, for a cols by rows grid.
+// Level files are named level_<name>.csv, files which can't be loaded are skipped.
+func LoadLevels(cols int, rows int) []Level {
+	levels := Campaign(cols, rows)
+	for _, folder := range configdir.New("benjmarshall", "gopixelsnake").QueryFolders(configdir.All) {
+		files, err := filepath.Glob(filepath.Join(folder.Path, "level_*.csv"))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			l, err := LoadFile(file, cols, rows)
+			if err != nil {
+				continue
+			}
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// LoadFile loads a level for a cols by rows grid from a csv file. Each record starts with a key: "wall,x,y" is a
+// wall in a grid square, "portal,x1,y1,x2,y2" is a pair of portals, "hazard,kind,x,y,dx,dy" is a hazard
+// starting at x,y moving by dx,dy each step, with an optional range and speed after it, "zone,x,y" is a square
+// in a berry zone, "spawn,x,y,direction" is where the snake starts and the way it heads, e.g. up, and "speed",
+// "length" and "score" set the starting speed and the targets. Anything not in the file is taken from an open level.
+func LoadFile(filename string, cols int, rows int) (Level, error) {
+	l := Open(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(filename), "level_"), ".csv"), cols, rows)
+	f, err := os.Open(filename)
+	if err != nil {
+		return l, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return l, err
+	}
+	for _, record := range records {
+		key := strings.ToLower(strings.TrimSpace(record[0]))
+		values := record[1:]
+		switch key {
+		case "wall", "zone":
+			square, err := parseSquare(values, cols, rows)
+			if err != nil {
+				return l, err
+			}
+			if key == "wall" {
+				l.Walls = append(l.Walls, square)
+			} else {
+				l.BerryZones = append(l.BerryZones, square)
+			}
+		case "portal":
+			if len(values) != 4 {
+				return l, errors.New("portal records must be portal,x1,y1,x2,y2")
+			}
+			a, err := parseSquare(values[:2], cols, rows)
+			if err != nil {
+				return l, err
+			}
+			b, err := parseSquare(values[2:], cols, rows)
+			if err != nil {
+				return l, err
+			}
+			l.Portals = append(l.Portals, [2]pixel.Vec{a, b})
+		case "hazard":
+			h, err := parseHazard(values, cols, rows)
+			if err != nil {
+				return l, err
+			}
+			l.Hazards = append(l.Hazards, h)
+		case "spawn":
+			if len(values) != 3 {
+				return l, errors.New("spawn records must be spawn,x,y,direction")
+			}
+			square, err := parseSquare(values[:2], cols, rows)
+			if err != nil {
+				return l, err
+			}
+			heading, ok := snake.DirectionFromName(strings.TrimSpace(values[2]))
+			if !ok || heading == snake.NOCHANGE {
+				return l, errors.New("unknown spawn direction " + values[2])
+			}
+			l.Spawn = square
+			l.Heading = heading
+		case "speed":
+			if len(values) != 1 {
+				return l, errors.New("speed records must be speed,value")
+			}
+			if l.Speed, err = strconv.ParseFloat(strings.TrimSpace(values[0]), 64); err != nil {
+				return l, err
+			}
+		case "length", "score":
+			if len(values) != 1 {
+				return l, errors.New(key + " records must be " + key + ",value")
+			}
+			target, err := strconv.Atoi(strings.TrimSpace(values[0]))
+			if err != nil {
+				return l, err
+			}
+			if key == "length" {
+				l.TargetLength = target
+			} else {
+				l.TargetScore = target
+			}
+		default:
+			return l, errors.New("unknown level key " + key)
+		}
+	}
+	return l, l.Check(cols, rows)
+}
+
+// SaveLevel saves the level to a level file in the game's config folder, named after the level, and returns the
+// path of the file
+func SaveLevel(l Level) (string, error) {
+	folders := configdir.New("benjmarshall", "gopixelsnake").QueryFolders(configdir.Global)
+	name := "level_" + l.Name + ".csv"
+	f, err := folders[0].Create(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return filepath.Join(folders[0].Path, name), writeLevel(f, l)
+}
+
+// SaveFile saves the level to a csv file in the format read by LoadFile
+func SaveFile(l Level, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeLevel(f, l)
+}
+
+// writeLevel writes the level's records to w
+func writeLevel(w io.Writer, l Level) error {
+	records := [][]string{
+		{"speed", strconv.FormatFloat(l.Speed, 'g', -1, 64)},
+		{"spawn", formatInt(l.Spawn.X), formatInt(l.Spawn.Y), l.Heading.String()},
+	}
+	if l.TargetLength > 0 {
+		records = append(records, []string{"length", strconv.Itoa(l.TargetLength)})
+	}
+	if l.TargetScore > 0 {
+		records = append(records, []string{"score", strconv.Itoa(l.TargetScore)})
+	}
+	for _, wall := range l.Walls {
+		records = append(records, []string{"wall", formatInt(wall.X), formatInt(wall.Y)})
+	}
+	for _, pair := range l.Portals {
+		records = append(records, []string{"portal", formatInt(pair[0].X), formatInt(pair[0].Y), formatInt(pair[1].X), formatInt(pair[1].Y)})
+	}
+	for _, h := range l.Hazards {
+		records = append(records, []string{"hazard", strings.ToLower(h.Kind.String()), formatInt(h.Pos.X), formatInt(h.Pos.Y),
+			formatInt(h.Dir.X), formatInt(h.Dir.Y), strconv.Itoa(h.Range), strconv.FormatFloat(h.Speed, 'g', -1, 64)})
+	}
+	for _, zone := range l.BerryZones {
+		records = append(records, []string{"zone", formatInt(zone.X), formatInt(zone.Y)})
+	}
+
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+// StartSquares returns the grid squares the snake's body starts on, head first. The snake can also start by
+// heading the opposite way, which swaps its head and tail over, so it starts on the same squares either way.
+func (l Level) StartSquares() []pixel.Vec {
+	squares := []pixel.Vec{}
+	for i := 0; i < snake.StartLength; i++ {
+		squares = append(squares, l.Spawn.Sub(l.Heading.GetVec().Scaled(float64(i))))
+	}
+	return squares
+}
+
+// Check returns an error if the level can't be played on a cols by rows grid: the snake has to start at a speed it
+// can go at, the whole of it has to start on the grid and not in a wall or a portal, each portal needs a square of
+// its own which isn't a wall, each hazard needs to start in an empty square, and if there are berry zones berries
+// need to fit in them
+func (l Level) Check(cols int, rows int) error {
+	if err := snake.CheckSpeed(l.Speed); err != nil {
+		return err
+	}
+	portals := l.GetPortalMap()
+	if len(portals) != 2*len(l.Portals) {
+		return errors.New("portals can't share a square")
+	}
+	taken := map[pixel.Vec]bool{}
+	for _, square := range l.StartSquares() {
+		if square.X < 0 || square.Y < 0 || square.X >= float64(cols) || square.Y >= float64(rows) {
+			return errors.New("the snake has to start on the grid")
+		}
+		if l.IsWall(square) {
+			return errors.New("the snake can't start in a wall")
+		}
+		if _, ok := portals[square]; ok {
+			return errors.New("the snake can't start in a portal")
+		}
+		taken[square] = true
+	}
+	for square := range portals {
+		if l.IsWall(square) {
+			return errors.New("portals can't be in a wall")
+		}
+	}
+	for _, h := range l.Hazards {
+		if _, ok := portals[h.Pos]; ok || taken[h.Pos] || l.IsWall(h.Pos) {
+			return errors.New("hazards must start in an empty square")
+		}
+		taken[h.Pos] = true
+	}
+	free := len(l.BerryZones) == 0
+	for _, zone := range l.BerryZones {
+		if _, ok := portals[zone]; !ok && !l.IsWall(zone) {
+			free = true
+		}
+	}
+	if !free {
+		return errors.New("berry zones need a square without a wall or portal")
+	}
+	return nil
+}
+
+// formatInt returns a grid coordinate as it is written in level files
+func formatInt(v float64) string {
+	return strconv.Itoa(int(v))
+}
+
+// parseHazard returns the hazard from the values of a hazard record, kind,x,y,dx,dy with an optional range and
+// speed after them, on a cols by rows grid. Patrols move along one axis and balls along both.
+func parseHazard(values []string, cols int, rows int) (hazards.Hazard, error) {
+	h := hazards.Hazard{}
+	if len(values) < 5 || len(values) > 7 {
+		return h, errors.New("hazard records must be hazard,kind,x,y,dx,dy with an optional range and speed")
+	}
+	kind, ok := hazards.KindFromName(strings.TrimSpace(values[0]))
+	if !ok {
+		return h, errors.New("unknown hazard kind " + values[0])
+	}
+	h.Kind = kind
+	square, err := parseSquare(values[1:3], cols, rows)
+	if err != nil {
+		return h, err
+	}
+	h.Pos = square
+	step := [2]int{}
+	for i, value := range values[3:5] {
+		if step[i], err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+			return h, err
+		}
+		if step[i] < -1 || step[i] > 1 {
+			return h, errors.New("hazards move by -1, 0 or 1 on each axis")
+		}
+	}
+	h.Dir = pixel.V(float64(step[0]), float64(step[1]))
+	if (kind == hazards.Patrol && (step[0] == 0) == (step[1] == 0)) || (kind == hazards.Ball && (step[0] == 0 || step[1] == 0)) {
+		return h, errors.New("patrols must move along one axis and balls along both")
+	}
+	if len(values) > 5 {
+		if h.Range, err = strconv.Atoi(strings.TrimSpace(values[5])); err != nil {
+			return h, err
+		}
+	}
+	if len(values) > 6 {
+		if h.Speed, err = strconv.ParseFloat(strings.TrimSpace(values[6]), 64); err != nil {
+			return h, err
+		}
+		if err = hazards.CheckSpeed(h.Speed); err != nil {
+			return h, err
+		}
+	}
+	return h, nil
+}
+
+// parseSquare returns the grid square from an x,y pair of values, which must be on a cols by rows grid
+func parseSquare(values []string, cols int, rows int) (pixel.Vec, error) {
+	if len(values) != 2 {
+		return pixel.ZV, errors.New("grid squares must be x,y")
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(values[0]))
+	if err != nil {
+		return pixel.ZV, err
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(values[1]))
+	if err != nil {
+		return pixel.ZV, err
+	}
+	if x < 0 || x >= cols || y < 0 || y >= rows {
+		return pixel.ZV, errors.New("grid square " + values[0] + "," + values[1] + " is outside the game area")
+	}
+	return pixel.V(float64(x), float64(y)), nil
+}
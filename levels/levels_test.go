@@ -0,0 +1,148 @@
+package levels
+
+import (
+	"math"
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(l *Level)
+		ok     bool
+	}{
+		{
+			name:   "an open level",
+			change: func(l *Level) {},
+			ok:     true,
+		},
+		{
+			name:   "head in a wall",
+			change: func(l *Level) { l.Walls = []pixel.Vec{l.Spawn} },
+		},
+		{
+			name:   "tail in a wall",
+			change: func(l *Level) { l.Walls = []pixel.Vec{pixel.V(10, 6)} },
+		},
+		{
+			name:   "wall just behind the tail",
+			change: func(l *Level) { l.Walls = []pixel.Vec{pixel.V(10, 5)} },
+			ok:     true,
+		},
+		{
+			name:   "body in a portal",
+			change: func(l *Level) { l.Portals = [][2]pixel.Vec{{pixel.V(10, 8), pixel.V(2, 2)}} },
+		},
+		{
+			name: "hazard on the body",
+			change: func(l *Level) {
+				l.Hazards = []hazards.Hazard{{Kind: hazards.Ball, Pos: pixel.V(10, 7), Dir: pixel.V(1, 1)}}
+			},
+		},
+		{
+			name:   "tail off the bottom of the grid",
+			change: func(l *Level) { l.Spawn = pixel.V(10, 3) },
+		},
+		{
+			name:   "tail on the bottom row",
+			change: func(l *Level) { l.Spawn = pixel.V(10, 4) },
+			ok:     true,
+		},
+		{
+			name: "tail off the left of the grid",
+			change: func(l *Level) {
+				l.Spawn = pixel.V(2, 10)
+				l.Heading = snake.RIGHT
+			},
+		},
+		{
+			name:   "speed too slow",
+			change: func(l *Level) { l.Speed = -1 },
+		},
+		{
+			name:   "speed too fast",
+			change: func(l *Level) { l.Speed = 1e12 },
+		},
+		{
+			name:   "speed infinite",
+			change: func(l *Level) { l.Speed = math.Inf(1) },
+		},
+		{
+			name:   "speed not a number",
+			change: func(l *Level) { l.Speed = math.NaN() },
+		},
+		{
+			name:   "fastest speed",
+			change: func(l *Level) { l.Speed = snake.MaxSpeed },
+			ok:     true,
+		},
+		{
+			name:   "portals sharing a square",
+			change: func(l *Level) { l.Portals = [][2]pixel.Vec{{pixel.V(2, 2), pixel.V(2, 2)}} },
+		},
+		{
+			name:   "berry zones all in walls",
+			change: func(l *Level) { l.Walls = []pixel.Vec{pixel.V(1, 1)}; l.BerryZones = []pixel.Vec{pixel.V(1, 1)} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := Open("test", 20, 20)
+			tt.change(&l)
+			if err := l.Check(20, 20); (err == nil) != tt.ok {
+				t.Errorf("Check() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStartSquares(t *testing.T) {
+	l := Open("test", 20, 20)
+	l.Spawn = pixel.V(5, 5)
+	l.Heading = snake.RIGHT
+	want := []pixel.Vec{pixel.V(5, 5), pixel.V(4, 5), pixel.V(3, 5), pixel.V(2, 5), pixel.V(1, 5)}
+	got := l.StartSquares()
+	if len(got) != snake.StartLength {
+		t.Fatalf("StartSquares() has %d squares, want %d", len(got), snake.StartLength)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("StartSquares()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCampaignLevelsCheck(t *testing.T) {
+	// The grids of the window and the terminal
+	for _, size := range [][2]int{{70, 70}, {40, 40}} {
+		for _, l := range Campaign(size[0], size[1]) {
+			if err := l.Check(size[0], size[1]); err != nil {
+				t.Errorf("%s on a %dx%d grid: %v", l.Name, size[0], size[1], err)
+			}
+		}
+	}
+}
+
+func TestParseHazardSpeed(t *testing.T) {
+	tests := []struct {
+		speed string
+		ok    bool
+	}{
+		{"0", true},
+		{"4.5", true},
+		{"-1", false},
+		{"1e12", false},
+		{"Inf", false},
+		{"NaN", false},
+	}
+	for _, tt := range tests {
+		_, err := parseHazard([]string{"ball", "5", "5", "1", "1", "0", tt.speed}, 70, 70)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseHazard() with a speed of %s returned %v, want ok %v", tt.speed, err, tt.ok)
+		}
+	}
+}
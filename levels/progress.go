@@ -0,0 +1,121 @@
+package levels
+
+import (
+	"encoding/csv"
+	"strconv"
+
+	"github.com/shibukawa/configdir"
+)
+
+// Progress holds how far the player has got through the levels, which they have completed and the best score
+// they have had in each. Levels are kept by name so progress isn't lost if levels are added.
+type Progress struct {
+	completed    map[string]bool
+	best         map[string]int
+	progressFile string
+	configDirs   configdir.ConfigDir
+}
+
+// NewProgress creates a new progress struct and loads any saved progress
+func NewProgress(filename string) Progress {
+	p := new(Progress)
+	p.progressFile = filename
+	p.completed = map[string]bool{}
+	p.best = map[string]int{}
+	p.configDirs = configdir.New("benjmarshall", "gopixelsnake")
+	p.LoadProgress()
+	return *p
+}
+
+// IsUnlocked returns true if the level at index i can be played, the first level always can and each other
+// level can once the one before it has been completed
+func (p *Progress) IsUnlocked(levels []Level, i int) bool {
+	return i == 0 || (i < len(levels) && p.completed[levels[i-1].Name])
+}
+
+// GetUnlocked returns the index of the furthest level which can be played
+func (p *Progress) GetUnlocked(levels []Level) int {
+	i := 0
+	for i+1 < len(levels) && p.IsUnlocked(levels, i+1) {
+		i++
+	}
+	return i
+}
+
+// IsCompleted returns true if the level has been completed
+func (p *Progress) IsCompleted(name string) bool {
+	return p.completed[name]
+}
+
+// GetBest returns the best score the player has had in the level, and false if they haven't played it
+func (p *Progress) GetBest(name string) (int, bool) {
+	best, ok := p.best[name]
+	return best, ok
+}
+
+// Record saves the result of playing a level, keeping the best score and unlocking the next level if it was completed
+func (p *Progress) Record(name string, score int, completed bool) {
+	if best, ok := p.best[name]; !ok || score > best {
+		p.best[name] = score
+	}
+	if completed {
+		p.completed[name] = true
+	}
+	p.SaveProgress()
+}
+
+// SaveProgress saves the progress to a csv file of name,completed,best records
+func (p *Progress) SaveProgress() {
+	folders := p.configDirs.QueryFolders(configdir.Global)
+
+	f, err := folders[0].Create(p.progressFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	for name, best := range p.best {
+		err := w.Write([]string{name, strconv.FormatBool(p.completed[name]), strconv.Itoa(best)})
+		if err != nil {
+			return
+		}
+	}
+}
+
+// LoadProgress loads saved progress from a csv file, ignoring any records which can't be read
+func (p *Progress) LoadProgress() {
+	folder := p.configDirs.QueryFolderContainsFile(p.progressFile)
+	if folder == nil {
+		return
+	}
+	f, err := folder.Open(p.progressFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return
+	}
+	for _, record := range records {
+		if len(record) != 3 {
+			continue
+		}
+		completed, err := strconv.ParseBool(record[1])
+		if err != nil {
+			continue
+		}
+		best, err := strconv.Atoi(record[2])
+		if err != nil {
+			continue
+		}
+		p.completed[record[0]] = completed
+		p.best[record[0]] = best
+	}
+}
@@ -1,21 +1,77 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
 	"time"
 
-	"github.com/benjmarshall/gopixelsnake/drawing"
+	"github.com/benjmarshall/gopixelsnake/arenas"
+	"github.com/benjmarshall/gopixelsnake/audio"
+	"github.com/benjmarshall/gopixelsnake/controls"
 	"github.com/benjmarshall/gopixelsnake/game"
-	"github.com/benjmarshall/gopixelsnake/gametext"
-	"github.com/benjmarshall/gopixelsnake/scores"
-	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/input"
+	"github.com/benjmarshall/gopixelsnake/levels"
+	"github.com/benjmarshall/gopixelsnake/modes"
+	"github.com/benjmarshall/gopixelsnake/raster"
+	"github.com/benjmarshall/gopixelsnake/render"
+	"github.com/benjmarshall/gopixelsnake/replay"
+	"github.com/benjmarshall/gopixelsnake/settings"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/imdraw"
 	"github.com/faiface/pixel/pixelgl"
-	"golang.org/x/image/colornames"
 )
 
+const (
+	// windowSquares is how many grid squares across and down the game area is in the window, pictures, generated
+	// levels and exported recordings use the same grid so they match the game played in the window
+	windowSquares = 70
+	// terminalSquares is how many grid squares across and down the game area is in the terminal, it is smaller so it
+	// fits in an 80x24 terminal
+	terminalSquares = 40
+)
+
+// windowBounds is the size the window opens at, the game is laid out the same way when it is drawn without a window
+var windowBounds = pixel.R(0, 0, 1024, 768)
+
+// newPlayConfig returns the config for a game area squares grid squares across and down, laid out for a window with
+// the bounds provided. Everything which plays or draws the game gets its config from here, so they all have the
+// same grid and border sizes.
+func newPlayConfig(squares int, bounds pixel.Rect) game.Config {
+	return game.NewGameConfig(float64(squares)*10, float64(squares)*10, 2, 10, bounds)
+}
+
 func main() {
+	tui := flag.Bool("tui", false, "play in the terminal instead of opening a window")
+	pngFile := flag.String("png", "", "save a picture of a new game to this PNG file and exit, without opening a window")
+	gifFile := flag.String("gif", "", "export a recorded game to this animated GIF file and exit")
+	apngFile := flag.String("apng", "", "export a recorded game to this animated PNG file and exit")
+	replayFile := flag.String("replay", "", "the recorded game to export, defaults to the last game played")
+	fps := flag.Float64("fps", 10, "frame rate of exported animations")
+	scale := flag.Float64("scale", 0.5, "scale of exported animations, 1 is the size of the game window")
+	generate := flag.String("generate", "", "write a generated arena to a level file and exit, the style is maze, rooms or pillars")
+	seed := flag.Int64("seed", 0, "seed for the generated arena, 0 picks one at random")
+	levelFile := flag.String("level", "", "the level file to write the generated arena to, defaults to level_<name>.csv in the config folder")
+	flag.Parse()
+
+	if *pngFile != "" {
+		savePNG(*pngFile)
+		return
+	}
+	if *generate != "" {
+		generateLevel(*generate, *seed, *levelFile)
+		return
+	}
+	if *gifFile != "" || *apngFile != "" {
+		exportAnimation(*replayFile, *gifFile, *apngFile, *fps, *scale)
+		return
+	}
+	if *tui {
+		runTerminal()
+		return
+	}
 	pixelgl.Run(run)
 }
 
@@ -23,8 +79,8 @@ func run() {
 	// Setup Window Configuration
 	cfg := pixelgl.WindowConfig{
 		Title:     "Pixel Rocks!",
-		Bounds:    pixel.R(0, 0, 1024, 768),
-		Resizable: false,
+		Bounds:    windowBounds,
+		Resizable: true,
 		VSync:     true,
 	}
 
@@ -35,180 +91,83 @@ func run() {
 	}
 
 	// Setup Game Configuration
-	gameCFG := game.NewGameConfig(700, 700, 2, 10, cfg)
+	gameCFG := newPlayConfig(windowSquares, cfg.Bounds)
 
-	// Setup text structure
-	textStruct := gametext.NewGameText(win, gameCFG)
+	// Load the user settings
+	userSettings := settings.NewSettings("settings.csv")
 
-	// Setup a scores structure
-	scoresTable := scores.NewScores("high_scores.csv", 10)
+	// Setup the key bindings
+	ctrl := controls.NewControls(&userSettings)
 
-	// Initialize a new snake
-	s := snake.NewSnake(gameCFG)
+	// Setup the input sources, keyboard and any joysticks
+	in := input.NewInput(input.NewKeyboard(win, &ctrl), input.NewJoystick(win, &userSettings))
 
-	// Generate a berry
-	berry := game.GenerateRandomBerry(&gameCFG)
+	// Setup a scores structure for each mode
+	scoresTables := modes.NewScoresTables(10)
 
-	// Create the Game Background Shape
-	imdArea := imdraw.New(nil)
+	// Setup the game
+	g := newGameState(&gameCFG, &ctrl, &in, scoresTables, &userSettings)
 
-	// Create the Game Contents Shape
-	imdGame := imdraw.New(nil)
+	// Play the audio through the speakers
+	g.audio.SetBackend(newAudioBackend(&userSettings))
 
-	// Create a berry Contents Shape
-	imdBerry := imdraw.New(nil)
+	// Setup the renderer
+	r := render.NewPixel(win, &gameCFG, &ctrl, g.getTheme())
+	g.captureKey = func() (controls.Key, bool) {
+		return input.JustPressedAny(win)
+	}
+	g.pointer = func() (pixel.Vec, bool, bool) {
+		return gameCFG.GetWindowMatrix().Unproject(win.MousePosition()), win.Pressed(pixelgl.MouseButtonLeft), win.Pressed(pixelgl.MouseButtonRight)
+	}
 
 	// Create some variables
 	var (
-		frames         = 0
-		second         = time.Tick(time.Second)
-		gameRunning    = false
-		gameOver       = false
-		eaten          = false
-		inputKeyBuffer = []snake.Direction{}
-		dir            snake.Direction
-		score          = 0
-		showScores     = false
-		scoreName      string
-		highScore      = false
+		frames       = 0
+		second       = time.Tick(time.Second)
+		windowBounds = win.Bounds()
 	)
 
-	// Draw the initial frames
-	win.Clear(colornames.Darkcyan)
-	drawing.DrawGameBackground(win, imdArea, &gameCFG)
-	drawing.DrawSnakeRect(win, imdGame, &gameCFG, &s)
-	drawing.DrawBerry(win, imdBerry, &gameCFG, berry)
-	textStruct.DrawTitleText(win)
-	textStruct.DrawScoreText(win, score)
-	textStruct.DrawControlsText(win)
-	win.Update()
+	// Draw the initial frame
+	g.draw(r)
+	lastFrame := time.Now()
 
 	// Keep going till the window is closed
 	for !win.Closed() {
 
-		// Clear the screen
-		win.Clear(colornames.Darkcyan)
-
-		if !gameRunning && !gameOver && !showScores {
-			// Game is not running so wait for user to do something!
-			if win.JustPressed(pixelgl.KeyUp) {
-				s.StartOfGame(snake.UP)
-				gameRunning = true
-			} else if win.JustPressed(pixelgl.KeyDown) {
-				s.StartOfGame(snake.DOWN)
-				gameRunning = true
-			} else if win.JustPressed(pixelgl.KeyLeft) {
-				s.StartOfGame(snake.LEFT)
-				gameRunning = true
-			} else if win.JustPressed(pixelgl.KeyRight) {
-				s.StartOfGame(snake.RIGHT)
-				gameRunning = true
-			} else if win.JustPressed(pixelgl.KeyX) {
-				win.SetClosed(true)
-			}
-			if win.JustPressed(pixelgl.KeyS) {
-				showScores = true
-			}
-		} else if !gameRunning && !gameOver && showScores {
-			if win.JustPressed(pixelgl.KeyS) {
-				showScores = false
-			} else if win.JustPressed(pixelgl.KeyX) {
-				win.SetClosed(true)
-			}
+		// Re-layout the game if the window has changed size
+		if win.Bounds() != windowBounds {
+			windowBounds = win.Bounds()
+			gameCFG.Resize(windowBounds)
+			r.Layout()
 		}
 
-		// Do game logic only if the game is actually running!
-		if gameRunning {
-
-			// Catch user input
-			if win.JustPressed(pixelgl.KeyUp) {
-				inputKeyBuffer = append(inputKeyBuffer, snake.UP)
-			} else if win.JustPressed(pixelgl.KeyDown) {
-				inputKeyBuffer = append(inputKeyBuffer, snake.DOWN)
-			} else if win.JustPressed(pixelgl.KeyLeft) {
-				inputKeyBuffer = append(inputKeyBuffer, snake.LEFT)
-			} else if win.JustPressed(pixelgl.KeyRight) {
-				inputKeyBuffer = append(inputKeyBuffer, snake.RIGHT)
-			}
+		// Collect the actions requested since the last frame
+		in.Update()
 
-			// Update the snake
-			select {
-			case <-s.GetTicker():
-				// Update the snake
-				if len(inputKeyBuffer) == 0 {
-					dir = snake.NOCHANGE
-				} else {
-					dir = inputKeyBuffer[0]
-					inputKeyBuffer = inputKeyBuffer[1:len(inputKeyBuffer)]
-				}
-				s.Update(eaten, dir)
-				// Check the snake is still in bounds
-				if !s.CheckSnakeOK(&gameCFG) {
-					gameOver = true
-					gameRunning = false
-					if score >= scoresTable.GetBottomScore() {
-						highScore = true
-					}
-					break
-				}
-				// Check if the snake has eaten
-				eaten = s.CheckIfSnakeHasEaten(&gameCFG, berry)
-				if eaten {
-					berry = game.GenerateRandomBerry(&gameCFG)
-					s.IncreaseSpeed()
-				}
-				// Update the score
-				score += int((s.GetSpeed() * 10))
-				if eaten {
-					score += int((1000 * s.GetSpeed()))
-				}
-			default:
-			}
-
-		} else if gameOver {
-			// Game has ended, wait for user to continue
-			if win.JustPressed(pixelgl.KeyEnter) {
-				// Submit score and reset for a new game
-				if highScore {
-					scoresTable.AddScore(score, scoreName)
-				}
-				// reset the board
-				scoreName = ""
-				gameOver = false
-				highScore = false
-				score = 0
-				berry = game.GenerateRandomBerry(&gameCFG)
-				s = snake.NewSnake(gameCFG)
-			} else if win.JustPressed(pixelgl.KeyBackspace) {
-				// Add support for deleting charaters from score name
-				scoreName = scoreName[0 : len(scoreName)-1]
-			} else if len(scoreName) < 3 {
-				// Capture input for score name (up to 3 chars)
-				scoreName = scoreName + win.Typed()
+		// Switch between windowed and fullscreen
+		if in.JustPressed(controls.Fullscreen) {
+			if win.Monitor() == nil {
+				win.SetMonitor(pixelgl.PrimaryMonitor())
+			} else {
+				win.SetMonitor(nil)
 			}
 		}
 
-		// Always draw the game
-		drawing.DrawGameBackground(win, imdArea, &gameCFG)
-		if !showScores {
-			// Hide game elements if high scores are being diplayed
-			drawing.DrawSnakeRect(win, imdGame, &gameCFG, &s)
-			drawing.DrawBerry(win, imdBerry, &gameCFG, berry)
-		}
-		textStruct.DrawTitleText(win)
-		textStruct.DrawScoreText(win, score)
-		textStruct.DrawControlsText(win)
-		if !gameRunning && !gameOver && !showScores {
-			// Show the start game message
-			textStruct.DrawStartGameText(win)
-		} else if gameOver {
-			textStruct.DrawGameOverText(win, &gameCFG, scoreName, highScore)
-		} else if showScores {
-			textStruct.DrawScoresListText(win, &gameCFG, &scoresTable)
+		// Save a picture of the current frame
+		if in.JustPressed(controls.Screenshot) {
+			img := raster.NewImage(&gameCFG, 1)
+			g.draw(img)
+			img.SavePNG(fmt.Sprintf("gopixelsnake-%s.png", time.Now().Format("20060102-150405")))
 		}
 
-		// Always update the window
-		win.Update()
+		// Run the game and draw it, this also updates the window
+		now := time.Now()
+		g.update(now.Sub(lastFrame))
+		lastFrame = now
+		if g.quit {
+			win.SetClosed(true)
+		}
+		g.draw(r)
 		frames++
 
 		// Update FPS
@@ -220,4 +179,160 @@ func run() {
 		}
 
 	}
+
+	// Save the game being played so it can be resumed next time
+	g.close()
+}
+
+// runTerminal plays the game in the terminal, it doesn't need OpenGL so can be used over SSH
+func runTerminal() {
+	// Put the terminal into raw mode so key presses arrive immediately and aren't echoed
+	state, err := stty("-g")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "the terminal frontend needs an interactive terminal:", err)
+		os.Exit(1)
+	}
+	stty("raw", "-echo")
+	defer stty(state)
+
+	// Setup Game Configuration
+	gameCFG := newPlayConfig(terminalSquares, pixel.R(0, 0, 600, 400))
+
+	// Load the user settings
+	userSettings := settings.NewSettings("settings.csv")
+
+	// Setup the key bindings, these are only used for the action names as the terminal keys are fixed
+	ctrl := controls.NewControls(&userSettings)
+
+	// Setup the input source
+	in := input.NewInput(input.NewTerminal(os.Stdin))
+
+	// Setup a scores structure for each mode
+	scoresTables := modes.NewScoresTables(10)
+
+	// Setup the renderer
+	r := render.NewTerminal(os.Stdout, &gameCFG)
+	defer r.Close()
+
+	// Setup the game
+	g := newGameState(&gameCFG, &ctrl, &in, scoresTables, &userSettings)
+	g.audio.SetBackend(newAudioBackend(&userSettings))
+
+	// Keep going till the player quits, terminals don't need more than 30 frames a second
+	frame := time.Tick(time.Second / 30)
+	lastFrame := time.Now()
+	for !g.quit {
+		in.Update()
+		now := time.Now()
+		g.update(now.Sub(lastFrame))
+		lastFrame = now
+		g.draw(r)
+		<-frame
+	}
+	g.close()
+}
+
+// newAudioBackend returns a backend which plays through the speakers, or one which plays nothing if there is
+// no sound device or sound has been turned off with the audio.Enabled setting
+func newAudioBackend(userSettings *settings.Type) audio.Backend {
+	if !userSettings.GetBool("audio.Enabled", true) {
+		return audio.Null{}
+	}
+	sp, err := audio.NewSpeaker()
+	if err != nil {
+		return audio.Null{}
+	}
+	return sp
+}
+
+// savePNG draws a new game waiting to be started into a PNG file
+func savePNG(filename string) {
+	gameCFG := newPlayConfig(windowSquares, windowBounds)
+	userSettings := settings.NewSettings("settings.csv")
+	ctrl := controls.NewControls(&userSettings)
+	in := input.NewInput()
+	scoresTables := modes.NewScoresTables(10)
+	g := newGameState(&gameCFG, &ctrl, &in, scoresTables, &userSettings)
+
+	img := raster.NewImage(&gameCFG, 1)
+	g.draw(img)
+	if err := img.SavePNG(filename); err != nil {
+		fmt.Fprintln(os.Stderr, "could not save the picture:", err)
+		os.Exit(1)
+	}
+}
+
+// generateLevel writes an arena in the named style to a level file, so it can be played in the campaign's level
+// select or changed in the level editor
+func generateLevel(styleName string, seed int64, filename string) {
+	style, ok := arenas.StyleFromName(styleName)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown arena style:", styleName)
+		os.Exit(1)
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano() % 1000000
+	}
+	gameCFG := newPlayConfig(windowSquares, windowBounds)
+	x, y := gameCFG.GetGameAreaDims()
+	l := arenas.Generate(style, int(x/gameCFG.GetGridSize()), int(y/gameCFG.GetGridSize()), seed)
+	var err error
+	if filename == "" {
+		filename, err = levels.SaveLevel(l)
+	} else {
+		err = levels.SaveFile(l, filename)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not save the level:", err)
+		os.Exit(1)
+	}
+	fmt.Println("saved", l.Name, "to", filename)
+}
+
+// exportAnimation renders a recorded game to an animated GIF and/or APNG file
+func exportAnimation(replayFile string, gifFile string, apngFile string, fps float64, scale float64) {
+	var (
+		recording replay.Type
+		err       error
+	)
+	if replayFile == "" {
+		recording, err = replay.LoadReplay("last_game.json")
+	} else {
+		recording, err = replay.LoadFile(replayFile)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not load the recording:", err)
+		os.Exit(1)
+	}
+
+	gameCFG := newPlayConfig(windowSquares, windowBounds)
+
+	for _, export := range []struct {
+		filename string
+		write    func(io.Writer, *game.Config, float64, float64) error
+	}{
+		{gifFile, recording.WriteGIF},
+		{apngFile, recording.WriteAPNG},
+	} {
+		if export.filename == "" {
+			continue
+		}
+		f, err := os.Create(export.filename)
+		if err == nil {
+			err = export.write(f, &gameCFG, fps, scale)
+			f.Close()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "could not export the animation:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// stty runs the stty command on the terminal attached to stdin and returns its output
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
 }
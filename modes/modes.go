@@ -0,0 +1,276 @@
+package modes
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/scores"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/faiface/pixel"
+)
+
+// Mode is a way of playing the game, each has its own end condition and high scores table
+type Mode int
+
+const (
+	// Endless is the original game, it carries on until the snake dies.
+	Endless Mode = iota
+	// TimeAttack is scoring as many points as possible before the time runs out.
+	TimeAttack
+	// Race is growing the snake to a set length as quickly as possible.
+	Race
+	// Survival has no berries and the walls slowly close in, the snake has to stay alive for as long as it can.
+	Survival
+	// Daily is an endless game which is the same for everyone on the same day, see DailySeed.
+	Daily
+	// Campaign is a sequence of levels, reaching each level's target moves the snake on to the next.
+	Campaign
+	// Arena is an endless game in an arena with walls which is made up for each game, see the arenas package.
+	Arena
+	// NumModes is the number of modes, it can be used to loop over all modes.
+	NumModes
+)
+
+// modeNames are the names shown for each mode
+var modeNames = map[Mode]string{
+	Endless:    "Endless",
+	TimeAttack: "Time Attack",
+	Race:       "Race",
+	Survival:   "Survival",
+	Daily:      "Daily",
+	Campaign:   "Campaign",
+	Arena:      "Arena",
+}
+
+// scoresFiles are the files each mode's high scores are kept in, endless uses the original file and the daily
+// challenge keeps its results by day in the file from DailyScoresFile
+var scoresFiles = map[Mode]string{
+	Endless:    "high_scores.csv",
+	TimeAttack: "high_scores_timeattack.csv",
+	Race:       "high_scores_race.csv",
+	Survival:   "high_scores_survival.csv",
+	Campaign:   "high_scores_campaign.csv",
+	Arena:      "high_scores_arena.csv",
+}
+
+// String returns the name of the mode
+func (m Mode) String() string {
+	return modeNames[m]
+}
+
+// NewScores returns the mode's high scores table with room for numScores entries. Races are ranked by time,
+// where the lowest is best, and the other modes by points.
+func (m Mode) NewScores(numScores int) scores.Type {
+	if m == Race {
+		return scores.NewTimes(scoresFiles[m], numScores)
+	}
+	return scores.NewScores(scoresFiles[m], numScores)
+}
+
+// NewScoresTables returns the high scores table for each mode other than the daily challenge, which has its own
+// scores.Daily table
+func NewScoresTables(numScores int) map[Mode]*scores.Type {
+	tables := map[Mode]*scores.Type{}
+	for m := Mode(0); m < NumModes; m++ {
+		if m == Daily {
+			continue
+		}
+		table := m.NewScores(numScores)
+		tables[m] = &table
+	}
+	return tables
+}
+
+// DailyScoresFile returns the file the daily challenge results on a cols by rows grid are kept in. The day's board
+// depends on the size of the grid, so the window and the terminal, which have different grids, keep separate results.
+func DailyScoresFile(cols int, rows int) string {
+	return fmt.Sprintf("daily_scores_%dx%d.csv", cols, rows)
+}
+
+// Today returns the date of the daily challenge being played now, in the YYYY-MM-DD format. The date is taken
+// in UTC so everyone plays the same game at the same time wherever they are.
+func Today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+// DailySeed returns the random seed for the daily challenge on a date, it is the same for everyone so they all
+// start in the same place and get the same berries
+func DailySeed(date string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("gopixelsnake daily " + date))
+	return int64(h.Sum64())
+}
+
+// HasBerries returns true if berries are put on the board in this mode
+func (m Mode) HasBerries() bool {
+	return m != Survival
+}
+
+// Config sets the time limit for time attack, the length to race to and how quickly the walls close in survival
+type Config struct {
+	TimeLimit    time.Duration
+	RaceLength   int
+	WallInterval time.Duration
+}
+
+// DefaultConfig is the config used for any values which aren't in the settings file
+var DefaultConfig = Config{
+	TimeLimit:    60 * time.Second,
+	RaceLength:   30,
+	WallInterval: 5 * time.Second,
+}
+
+// LoadConfig returns the modes config from the settings. The keys are "modes.TimeLimit" and "modes.WallInterval"
+// in seconds and "modes.RaceLength" in grid squares.
+func LoadConfig(set *settings.Type) Config {
+	d := DefaultConfig
+	return Config{
+		TimeLimit:    time.Duration(set.GetFloat("modes.TimeLimit", d.TimeLimit.Seconds()) * float64(time.Second)),
+		RaceLength:   set.GetInt("modes.RaceLength", d.RaceLength),
+		WallInterval: time.Duration(set.GetFloat("modes.WallInterval", d.WallInterval.Seconds()) * float64(time.Second)),
+	}
+}
+
+// Type holds the mode chosen by the player and times the game being played in it
+type Type struct {
+	mode     Mode
+	config   Config
+	elapsed  time.Duration
+	date     string
+	settings *settings.Type
+}
+
+// NewModes returns the mode chosen last time, loading it and the modes config from the settings provided
+func NewModes(set *settings.Type) Type {
+	t := new(Type)
+	t.settings = set
+	t.config = LoadConfig(set)
+	t.mode = Mode(set.GetInt("modes.Mode", int(Endless)))
+	if t.mode < 0 || t.mode >= NumModes {
+		t.mode = Endless
+	}
+	return *t
+}
+
+// GetMode returns the mode chosen
+func (t *Type) GetMode() Mode {
+	return t.mode
+}
+
+// GetConfig returns the modes config
+func (t *Type) GetConfig() Config {
+	return t.config
+}
+
+// Next changes to the next mode and saves the choice
+func (t *Type) Next() {
+	t.SetMode((t.mode + 1) % NumModes)
+}
+
+// SetMode changes to the mode provided and saves the choice
+func (t *Type) SetMode(m Mode) {
+	t.mode = m
+	t.settings.SetInt("modes.Mode", int(t.mode))
+	t.settings.SaveSettings()
+	t.Reset()
+}
+
+// Reset sets the timer back to zero for a new game
+func (t *Type) Reset() {
+	t.elapsed = 0
+}
+
+// Update adds dt to the time the game has been played for, it should only be called while the game is running
+func (t *Type) Update(dt time.Duration) {
+	t.elapsed += dt
+}
+
+// SetElapsed sets the time the game has been played for, to carry on a saved game
+func (t *Type) SetElapsed(elapsed time.Duration) {
+	t.elapsed = elapsed
+}
+
+// SetDailyDate sets the date of the daily challenge being played, which the timer shows
+func (t *Type) SetDailyDate(date string) {
+	t.date = date
+}
+
+// GetElapsed returns the time the game has been played for
+func (t *Type) GetElapsed() time.Duration {
+	return t.elapsed
+}
+
+// Finished returns true if the game has reached the end condition of the mode, other than the snake dying. Time
+// attack finishes when the time runs out and a race when the snake reaches the length being raced to.
+func (t *Type) Finished(length int) bool {
+	switch t.mode {
+	case TimeAttack:
+		return t.elapsed >= t.config.TimeLimit
+	case Race:
+		return length >= t.config.RaceLength
+	}
+	return false
+}
+
+// Ranked returns true if a game which ended this way can go in the high scores table, a race only counts if the
+// snake finished it
+func (t *Type) Ranked(finished bool) bool {
+	return t.mode != Race || finished
+}
+
+// GetResult returns what goes in the mode's high scores table for a game, the time in milliseconds for a race
+// and the score for the other modes
+func (t *Type) GetResult(score int) int {
+	if t.mode == Race {
+		return int(t.elapsed / time.Millisecond)
+	}
+	return score
+}
+
+// GetWalls returns how many grid squares the walls have closed in from each side, which is only more than 0 in
+// survival
+func (t *Type) GetWalls() int {
+	if t.mode != Survival || t.config.WallInterval <= 0 {
+		return 0
+	}
+	return int(t.elapsed / t.config.WallInterval)
+}
+
+// GetArena returns the part of the game area, in the game area coordinate plane, which is still inside the walls
+func (t *Type) GetArena(gameCFG *game.Config) pixel.Rect {
+	area := gameCFG.GetGameAreaAsRec()
+	inset := math.Min(float64(t.GetWalls())*gameCFG.GetGridSize(), math.Min(area.W(), area.H())/2)
+	return pixel.R(area.Min.X+inset, area.Min.Y+inset, area.Max.X-inset, area.Max.Y-inset)
+}
+
+// GetTimerText returns the timer shown while a game is played in the mode: the time left in time attack, the
+// length and time taken in a race, the time survived in survival and the date of the daily challenge being played,
+// see SetDailyDate, rather than today's date, which changes if the board is left running past midnight. Endless
+// has no timer so it is empty, as does the campaign which shows the level's target instead and the arena which
+// shows the arena's name.
+func (t *Type) GetTimerText(length int) string {
+	switch t.mode {
+	case TimeAttack:
+		left := t.config.TimeLimit - t.elapsed
+		if left < 0 {
+			left = 0
+		}
+		return formatTime(left)
+	case Race:
+		return fmt.Sprintf("%d/%d %s", length, t.config.RaceLength, formatTime(t.elapsed))
+	case Survival:
+		return formatTime(t.elapsed)
+	case Daily:
+		return t.date
+	}
+	return ""
+}
+
+// formatTime returns a time as minutes, seconds and tenths of a second
+func formatTime(d time.Duration) string {
+	tenths := int(d / (time.Second / 10))
+	return fmt.Sprintf("%d:%02d.%d", tenths/600, tenths/10%60, tenths%10)
+}
@@ -0,0 +1,34 @@
+package modes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/settings"
+)
+
+func TestGetTimerText(t *testing.T) {
+	tests := []struct {
+		mode    Mode
+		elapsed time.Duration
+		want    string
+	}{
+		{Endless, 5 * time.Second, ""},
+		{TimeAttack, 15 * time.Second, "0:45.0"},
+		{TimeAttack, 2 * time.Minute, "0:00.0"},
+		{Race, 75*time.Second + 300*time.Millisecond, "12/30 1:15.3"},
+		{Survival, 9 * time.Second, "0:09.0"},
+		// The daily challenge shows the date of its board, even once the day has changed
+		{Daily, time.Hour, "2020-02-29"},
+	}
+	for _, tt := range tests {
+		set := settings.NewSettings("modes_test_settings.csv")
+		m := NewModes(&set)
+		m.mode = tt.mode
+		m.SetDailyDate("2020-02-29")
+		m.SetElapsed(tt.elapsed)
+		if got := m.GetTimerText(12); got != tt.want {
+			t.Errorf("%s after %v: GetTimerText() = %q, want %q", tt.mode, tt.elapsed, got, tt.want)
+		}
+	}
+}
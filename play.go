@@ -0,0 +1,1223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/access"
+	"github.com/benjmarshall/gopixelsnake/arenas"
+	"github.com/benjmarshall/gopixelsnake/audio"
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/drawing"
+	"github.com/benjmarshall/gopixelsnake/editor"
+	"github.com/benjmarshall/gopixelsnake/events"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/input"
+	"github.com/benjmarshall/gopixelsnake/levels"
+	"github.com/benjmarshall/gopixelsnake/modes"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/render"
+	"github.com/benjmarshall/gopixelsnake/replay"
+	"github.com/benjmarshall/gopixelsnake/rivals"
+	"github.com/benjmarshall/gopixelsnake/savegame"
+	"github.com/benjmarshall/gopixelsnake/scores"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+)
+
+// gameState holds everything which changes as the game is played, independent of the frontend it is played on
+type gameState struct {
+	gameCFG        *game.Config
+	ctrl           *controls.Type
+	in             *input.Type
+	scoresTables   map[modes.Mode]*scores.Type
+	daily          scores.Daily
+	dailyDate      string
+	scoresDate     string
+	userSettings   *settings.Type
+	themes         []theme.Type
+	themeIndex     int
+	theme          theme.Type
+	access         access.Type
+	modes          modes.Type
+	effects        drawing.Effects
+	audio          audio.Type
+	bus            events.Bus
+	s              snake.Type
+	berries        berries.Type
+	powerUps       powerups.Type
+	hazards        hazards.Type
+	rivals         rivals.Type
+	levels         []levels.Level
+	progress       levels.Progress
+	level          levels.Level
+	seed           int64
+	walls          []pixel.Vec
+	wallSet        map[pixel.Vec]bool
+	portals        []pixel.Vec
+	zones          []pixel.Vec
+	stage          int
+	stageScore     int
+	turnQueue      snake.TurnQueue
+	gameRunning    bool
+	gameOver       bool
+	eaten          bool
+	score          int
+	result         int
+	showScores     bool
+	scoreName      string
+	highScore      bool
+	paused         bool
+	showControls   bool
+	rebindSelected int
+	rebindWaiting  bool
+	showOptions    bool
+	optionSelected int
+	showLevels     bool
+	levelSelected  int
+	editor         editor.Type
+	showEditor     bool
+	editorLevel    int
+	editorStatus   string
+	testing        bool
+	canResume      bool
+	saved          bool
+	status         string
+	statusLeft     time.Duration
+	recording      replay.Type
+	captureKey     func() (controls.Key, bool)
+	pointer        func() (pixel.Vec, bool, bool)
+	quit           bool
+}
+
+// newGameState returns the state for a new game waiting to be started, with a high scores table for each mode
+func newGameState(gameCFG *game.Config, ctrl *controls.Type, in *input.Type, scoresTables map[modes.Mode]*scores.Type, userSettings *settings.Type) *gameState {
+	g := new(gameState)
+	g.gameCFG = gameCFG
+	g.ctrl = ctrl
+	g.in = in
+	g.scoresTables = scoresTables
+	g.userSettings = userSettings
+	// Load the themes and pick the one used last time
+	g.themes = theme.LoadThemes()
+	name := userSettings.GetString("theme.Name", theme.Classic.Name)
+	for i, th := range g.themes {
+		if th.Name == name {
+			g.themeIndex = i
+		}
+	}
+	g.access = access.NewAccess(userSettings)
+	g.modes = modes.NewModes(userSettings)
+	// Load the levels and carry on from the one played last time, as long as it is still unlocked
+	x, y := gameCFG.GetGameAreaDims()
+	g.daily = scores.NewDaily(modes.DailyScoresFile(int(x/gameCFG.GetGridSize()), int(y/gameCFG.GetGridSize())), 10)
+	g.levels = levels.LoadLevels(int(x/gameCFG.GetGridSize()), int(y/gameCFG.GetGridSize()))
+	g.progress = levels.NewProgress("campaign.csv")
+	g.stage = userSettings.GetInt("levels.Stage", 0)
+	if g.stage < 0 || g.stage > g.progress.GetUnlocked(g.levels) {
+		g.stage = g.progress.GetUnlocked(g.levels)
+	}
+	g.editor = editor.NewEditor(g.newLevelName(), int(x/gameCFG.GetGridSize()), int(y/gameCFG.GetGridSize()))
+	g.effects = drawing.NewEffects(drawing.LoadEffectStyle(userSettings))
+	g.applyTheme()
+	// The audio is silent until the frontend gives it a backend which can play it
+	g.audio = audio.NewAudio(audio.Null{}, userSettings)
+	// Publish what happens in the game to anything which reacts to it
+	g.bus = events.NewBus()
+	g.bus.Subscribe(g.playEffects)
+	g.bus.Subscribe(g.playSounds)
+	g.turnQueue = snake.NewTurnQueue(userSettings.GetInt("input.TurnQueueSize", 2))
+	// Initialize a new snake and fill the board with berries, power-ups appear as they are eaten
+	g.berries = berries.NewBerries(berries.LoadConfig(userSettings))
+	g.powerUps = powerups.NewPowerUps(powerups.LoadConfig(userSettings))
+	g.rivals = rivals.NewRivals(userSettings)
+	g.newBoard()
+	g.canResume = savegame.Exists()
+	g.recording = replay.NewReplay()
+	return g
+}
+
+// update runs one frame of game logic, dt is the time since the last frame and the input should have been updated first
+func (g *gameState) update(dt time.Duration) {
+	// Play the effects, unless the game is paused
+	if !g.paused {
+		g.effects.Update(dt)
+	}
+	if g.statusLeft > 0 {
+		g.statusLeft -= dt
+	}
+
+	// Switch theme, unless a high score name is being typed
+	if !g.gameOver && g.in.JustPressed(controls.NextTheme) {
+		g.nextTheme()
+	}
+
+	if !g.gameRunning && !g.gameOver && !g.showScores && !g.showControls && !g.showOptions && !g.showLevels && !g.showEditor {
+		// Game is not running so wait for user to do something!
+		if g.in.JustPressed(controls.MoveUp) {
+			g.startGame(snake.UP)
+		} else if g.in.JustPressed(controls.MoveDown) {
+			g.startGame(snake.DOWN)
+		} else if g.in.JustPressed(controls.MoveLeft) {
+			g.startGame(snake.LEFT)
+		} else if g.in.JustPressed(controls.MoveRight) {
+			g.startGame(snake.RIGHT)
+		} else if g.in.JustPressed(controls.Quit) {
+			g.quit = true
+		} else if g.in.JustPressed(controls.Confirm) && g.canResume {
+			g.resumeGame()
+		} else if g.in.JustPressed(controls.ShowScores) {
+			g.showScores = true
+			g.scoresDate = modes.Today()
+			g.bus.Publish(events.MenuChanged{})
+		} else if g.in.JustPressed(controls.Rebind) && g.captureKey != nil {
+			g.showControls = true
+			g.rebindSelected = 0
+			g.bus.Publish(events.MenuChanged{})
+		} else if g.in.JustPressed(controls.Options) {
+			g.showOptions = true
+			g.optionSelected = 0
+			g.bus.Publish(events.MenuChanged{})
+		} else if g.in.JustPressed(controls.Levels) {
+			g.showLevels = true
+			g.levelSelected = g.stage
+			g.bus.Publish(events.MenuChanged{})
+		} else if g.in.JustPressed(controls.Editor) && g.pointer != nil {
+			// The editor is painted with the mouse, so it can only be opened by frontends which have one
+			g.showEditor = true
+			g.testing = false
+			g.editorStatus = ""
+			g.newBoard()
+			g.bus.Publish(events.MenuChanged{})
+		}
+	} else if !g.gameRunning && !g.gameOver && g.showScores {
+		if g.in.JustPressed(controls.ShowScores) {
+			g.showScores = false
+			g.bus.Publish(events.MenuChanged{})
+		} else if g.in.JustPressed(controls.Quit) {
+			g.quit = true
+		} else if g.modes.GetMode() == modes.Daily && g.in.JustPressed(controls.MoveLeft) {
+			// Browse back through the days the daily challenge has been played
+			g.browseDaily(1)
+		} else if g.modes.GetMode() == modes.Daily && g.in.JustPressed(controls.MoveRight) {
+			g.browseDaily(-1)
+		}
+	} else if !g.gameRunning && !g.gameOver && g.showControls {
+		changed := true
+		if g.rebindWaiting {
+			// Bind the next key pressed to the selected action
+			if key, ok := g.captureKey(); ok {
+				g.ctrl.SetBinding(controls.Action(g.rebindSelected), key)
+				g.rebindWaiting = false
+			} else {
+				changed = false
+			}
+		} else if g.in.JustPressed(controls.Rebind) {
+			g.showControls = false
+		} else if g.in.JustPressed(controls.MoveUp) {
+			g.rebindSelected = (g.rebindSelected + int(controls.NumActions) - 1) % int(controls.NumActions)
+		} else if g.in.JustPressed(controls.MoveDown) {
+			g.rebindSelected = (g.rebindSelected + 1) % int(controls.NumActions)
+		} else if g.in.JustPressed(controls.Confirm) {
+			g.rebindWaiting = true
+		} else if g.in.JustPressed(controls.Delete) {
+			g.ctrl.ResetBindings()
+		} else {
+			changed = false
+		}
+		if changed {
+			g.bus.Publish(events.MenuChanged{})
+		}
+	} else if !g.gameRunning && !g.gameOver && g.showOptions {
+		// The first rows of the options screen are the theme, the mode and the rivals, followed by the
+		// accessibility options and the volumes
+		rows := 4 + int(access.NumOptions) + int(audio.NumChannels)
+		changed := true
+		if g.in.JustPressed(controls.Options) {
+			g.showOptions = false
+		} else if g.in.JustPressed(controls.Quit) {
+			g.quit = true
+		} else if g.in.JustPressed(controls.MoveUp) {
+			g.optionSelected = (g.optionSelected + rows - 1) % rows
+		} else if g.in.JustPressed(controls.MoveDown) {
+			g.optionSelected = (g.optionSelected + 1) % rows
+		} else if g.in.JustPressed(controls.Confirm) || g.in.JustPressed(controls.MoveRight) {
+			if g.optionSelected == 0 {
+				g.nextTheme()
+			} else if g.optionSelected == 1 {
+				g.modes.Next()
+				g.score = 0
+				g.stageScore = 0
+				g.newBoard()
+			} else if g.optionSelected == 2 {
+				g.rivals.NextCount()
+				g.newBoard()
+			} else if g.optionSelected == 3 {
+				g.rivals.NextDifficulty()
+				g.newBoard()
+			} else if g.optionSelected <= 3+int(access.NumOptions) {
+				g.access.Next(access.Option(g.optionSelected - 4))
+				g.applyTheme()
+			} else {
+				g.audio.Next(audio.Channel(g.optionSelected - 4 - int(access.NumOptions)))
+			}
+		} else {
+			changed = false
+		}
+		if changed {
+			g.bus.Publish(events.MenuChanged{})
+		}
+	} else if !g.gameRunning && !g.gameOver && g.showEditor {
+		// The board is set up again whenever the level changes, painting with the mouse changes it without the
+		// menu sound so dragging across the board stays quiet
+		pos, paint, erase := g.pointer()
+		edited := g.editor.Pointer(g.gameCFG, pos, paint, erase)
+		changed := true
+		if g.in.JustPressed(controls.Editor) {
+			g.showEditor = false
+			edited = true
+		} else if g.in.JustPressed(controls.Quit) {
+			g.quit = true
+		} else if g.in.JustPressed(controls.MoveLeft) {
+			g.editor.NextTool(-1)
+		} else if g.in.JustPressed(controls.MoveRight) {
+			g.editor.NextTool(1)
+		} else if g.in.JustPressed(controls.MoveUp) {
+			g.loadEditorLevel(-1)
+			edited = true
+		} else if g.in.JustPressed(controls.MoveDown) {
+			g.loadEditorLevel(1)
+			edited = true
+		} else if g.in.JustPressed(controls.Undo) {
+			edited = g.editor.Undo()
+			changed = edited
+		} else if g.in.JustPressed(controls.Redo) {
+			edited = g.editor.Redo()
+			changed = edited
+		} else if g.in.JustPressed(controls.SaveLevel) {
+			g.saveEditorLevel()
+		} else if g.in.JustPressed(controls.Confirm) {
+			// Play the level straight away, the player picks a direction to start as usual
+			x, y := g.gameCFG.GetGameAreaDims()
+			if err := g.editor.GetLevel().Check(int(x/g.gameCFG.GetGridSize()), int(y/g.gameCFG.GetGridSize())); err != nil {
+				g.editorStatus = err.Error()
+			} else {
+				g.showEditor = false
+				g.testing = true
+				g.score = 0
+				g.stageScore = 0
+				edited = true
+			}
+		} else {
+			changed = false
+		}
+		if edited {
+			g.newBoard()
+		}
+		if changed {
+			g.bus.Publish(events.MenuChanged{})
+		}
+	} else if !g.gameRunning && !g.gameOver && g.showLevels {
+		changed := true
+		if g.in.JustPressed(controls.Levels) {
+			g.showLevels = false
+		} else if g.in.JustPressed(controls.Quit) {
+			g.quit = true
+		} else if g.in.JustPressed(controls.MoveUp) {
+			g.levelSelected = (g.levelSelected + len(g.levels) - 1) % len(g.levels)
+		} else if g.in.JustPressed(controls.MoveDown) {
+			g.levelSelected = (g.levelSelected + 1) % len(g.levels)
+		} else if g.in.JustPressed(controls.Confirm) && g.progress.IsUnlocked(g.levels, g.levelSelected) {
+			// Play the campaign from the level picked
+			g.showLevels = false
+			g.testing = false
+			g.setStage(g.levelSelected)
+			g.modes.SetMode(modes.Campaign)
+			g.score = 0
+			g.stageScore = 0
+			g.newBoard()
+		} else {
+			changed = false
+		}
+		if changed {
+			g.bus.Publish(events.MenuChanged{})
+		}
+	}
+
+	// Do game logic only if the game is actually running!
+	if g.gameRunning {
+
+		// Catch user input
+		if g.in.JustPressed(controls.Pause) {
+			g.paused = !g.paused
+		} else if g.in.JustPressed(controls.Quit) {
+			// The game is saved as it closes, see close
+			g.quit = true
+		} else if g.in.JustPressed(controls.SaveGame) {
+			g.saveGame()
+		} else if g.paused {
+			// Ignore turns while the game is paused
+		} else if g.in.JustPressed(controls.MoveUp) {
+			g.turnQueue.Push(snake.UP, g.s.GetDirection())
+		} else if g.in.JustPressed(controls.MoveDown) {
+			g.turnQueue.Push(snake.DOWN, g.s.GetDirection())
+		} else if g.in.JustPressed(controls.MoveLeft) {
+			g.turnQueue.Push(snake.LEFT, g.s.GetDirection())
+		} else if g.in.JustPressed(controls.MoveRight) {
+			g.turnQueue.Push(snake.RIGHT, g.s.GetDirection())
+		}
+
+		// Count down the berries which disappear and the mode's timer, unless the game is paused
+		if !g.paused {
+			if g.berries.Update(dt) {
+				g.berries.Fill(g.gameCFG, g.berryBlocked)
+			}
+			g.modes.Update(dt)
+		}
+		// Time attack ends when the time runs out, even between steps
+		if g.modes.Finished(g.s.GetLength()) {
+			g.endGame(true)
+		}
+		// Move the hazards on their own schedule, the hunters chase the snake's head
+		if g.gameRunning && !g.paused && g.hazards.Update(g.gameCFG, dt, g.s.GetHeadPos(), g.hazardBlocked) {
+			g.checkHazards()
+		}
+		// Move the rivals on their own schedule, they go after the same berries as the snake but keep away from
+		// the poison ones
+		if g.gameRunning && !g.paused && len(g.rivals.GetRivals()) > 0 {
+			targets := []pixel.Vec{}
+			for _, b := range g.berries.GetBerries() {
+				if b.Kind != berries.Poison {
+					targets = append(targets, b.Pos)
+				}
+			}
+			if !g.rivalsDied(g.rivals.Update(g.gameCFG, dt, &g.s, targets, g.wallBlocked, g.hazards.Occupies)) {
+				g.feedRivals()
+			}
+		}
+
+		// Update the snake, unless the game is paused
+		select {
+		case <-g.s.GetTicker():
+			if g.paused || !g.gameRunning {
+				break
+			}
+			// Update the snake
+			tail := g.s.GetTailPos()
+			heading := g.s.GetDirection()
+			g.s.Update(g.eaten, g.turnQueue.Pop())
+			if g.s.GetDirection() != heading {
+				g.bus.Publish(events.DirectionChanged{From: heading, To: g.s.GetDirection()})
+			}
+			if g.s.GetTailPos() != tail {
+				g.bus.Publish(events.TailMoved{Pos: tail})
+			}
+			if g.s.GetTeleported() {
+				g.bus.Publish(events.Teleported{From: g.portalPartner(g.s.GetHeadPos()), To: g.s.GetHeadPos()})
+			}
+			if g.s.GetShielded() {
+				g.bus.Publish(events.ShieldUsed{Pos: g.s.GetHeadPos()})
+			}
+			for _, effect := range g.s.GetEndedEffects() {
+				g.bus.Publish(events.PowerUpEnded{Effect: effect})
+			}
+			// Check the snake is still in bounds, including any walls the mode has closed in or the level has, and
+			// hasn't hit itself
+			collision := g.s.CheckCollision(g.gameCFG)
+			if collision == snake.NoCollision && (!g.modes.GetArena(g.gameCFG).Contains(g.s.GetHeadPos()) || g.isWall(g.s.GetHeadPos())) {
+				collision = snake.HitWall
+			}
+			if collision == snake.NoCollision && g.hazards.Occupies(g.s.GetHeadPos()) {
+				collision = snake.HitHazard
+			}
+			if collision != snake.NoCollision {
+				g.bus.Publish(events.Died{Pos: g.s.GetHeadPos(), Cause: collision, Score: g.score})
+				g.endGame(false)
+				break
+			}
+			// Running into a rival kills the snake, unless it is a head on collision with a shorter rival
+			if g.rivalsDied(g.rivals.HitBy(&g.s)) {
+				break
+			}
+			// Pull nearby berries towards the head if the snake has the magnet
+			head := g.s.GetHeadPos()
+			if g.s.HasEffect(snake.Magnet) {
+				g.berries.Attract(g.gameCFG, head, g.powerUps.GetConfig().MagnetReach, func(pos pixel.Vec) bool {
+					return pos != head && g.occupied(pos)
+				})
+			}
+			// Check if the snake has eaten a berry, which might leave a power-up behind
+			g.eaten = false
+			if b, ok := g.berries.Eat(head); ok {
+				g.eatBerry(b)
+				g.berries.Fill(g.gameCFG, g.berryBlocked)
+				g.powerUps.Spawn(g.gameCFG, func(pos pixel.Vec) bool {
+					return g.occupied(pos) || g.berries.Occupies(pos)
+				})
+			}
+			// Check if the snake has collected a power-up
+			g.powerUps.Update()
+			if p, ok := g.powerUps.Collect(head); ok {
+				g.s.AddEffect(p.Effect, g.powerUps.GetConfig().Durations[p.Effect])
+				g.bus.Publish(events.PowerUpCollected{Pos: p.Pos, Effect: p.Effect})
+			}
+			// Update the score
+			g.score += int(g.s.GetSpeed() * 10 * g.s.GetScoreMultiplier())
+			g.recording.AddFrame(&g.s, g.berries.GetBerries(), g.score)
+			// A race ends as soon as the snake is long enough, and a campaign level once its target is reached
+			if g.modes.Finished(g.s.GetLength()) {
+				g.endGame(true)
+			} else if g.testing && g.level.Completed(g.s.GetLength(), g.score) {
+				g.endGame(true)
+			} else if g.modes.GetMode() == modes.Campaign && g.level.Completed(g.s.GetLength(), g.score-g.stageScore) {
+				g.completeStage()
+			}
+		default:
+		}
+
+	} else if g.gameOver {
+		// Game has ended, wait for user to continue
+		if g.in.JustPressed(controls.Confirm) {
+			// Submit score and reset for a new game
+			if g.highScore && g.modes.GetMode() == modes.Daily {
+				// Only the first result for each name counts each day
+				if !g.daily.AddScore(g.dailyDate, g.result, g.scoreName) {
+					g.setStatus("Not counted, that name played today")
+				}
+			} else if g.highScore {
+				g.scoresTable().AddScore(g.result, g.scoreName)
+			}
+			// reset the board
+			g.scoreName = ""
+			g.gameOver = false
+			g.highScore = false
+			g.paused = false
+			g.score = 0
+			g.stageScore = 0
+			g.result = 0
+			g.modes.Reset()
+			if g.testing {
+				// Go back to the editor after testing a level
+				g.testing = false
+				g.showEditor = true
+			}
+			g.newBoard()
+		} else if g.in.JustPressed(controls.Delete) {
+			// Add support for deleting charaters from score name
+			if len(g.scoreName) > 0 {
+				g.scoreName = g.scoreName[0 : len(g.scoreName)-1]
+			}
+		} else if len(g.scoreName) < 3 {
+			// Capture input for score name (up to 3 chars)
+			g.scoreName = g.scoreName + g.in.Typed()
+		}
+	}
+
+	// Play the music while the game is running, in time with the snake
+	g.audio.SetMusic(g.gameRunning && !g.paused, g.s.GetSpeed())
+}
+
+// startGame starts the snake moving in the direction chosen by the player and starts a new recording
+func (g *gameState) startGame(dir snake.Direction) {
+	g.s.SetSpeedRamp(g.access.GetSpeedRamp())
+	g.s.StartOfGame(dir)
+	g.modes.Reset()
+	g.gameRunning = true
+	g.recording = replay.NewReplay()
+	g.recording.AddFrame(&g.s, g.berries.GetBerries(), g.score)
+	g.bus.Publish(events.Started{Direction: dir})
+}
+
+// endGame ends the game, either because the snake died or because it reached the end condition of the mode, and
+// checks whether the result makes the mode's high scores table
+func (g *gameState) endGame(finished bool) {
+	g.gameOver = true
+	g.gameRunning = false
+	g.turnQueue.Clear()
+	g.result = g.modes.GetResult(g.score)
+	if finished {
+		g.bus.Publish(events.Finished{Mode: g.modes.GetMode(), Result: g.result})
+	}
+	if g.testing {
+		// Testing a level from the editor doesn't count towards anything
+		return
+	}
+	if g.saved {
+		// The game is over so it can't be carried on from where it was saved
+		savegame.Delete()
+		g.saved = false
+		g.canResume = false
+	}
+	if g.modes.GetMode() == modes.Campaign && !finished {
+		g.progress.Record(g.level.Name, g.score-g.stageScore, false)
+	}
+	if g.modes.Ranked(finished) && g.scoresTable().Qualifies(g.result) {
+		g.highScore = true
+		g.bus.Publish(events.NewHighScore{Score: g.result})
+	} else if g.modes.GetMode() == modes.Daily {
+		// Every daily challenge result is recorded so the player's name is needed, even if it isn't a high score
+		g.highScore = true
+	}
+	// Keep a recording of the last game so it can be exported
+	g.recording.AddFrame(&g.s, g.berries.GetBerries(), g.score)
+	g.recording.SaveReplay("last_game.json")
+}
+
+// newBoard sets up the level for the mode, puts a new snake and any rivals on the board, clears the berries and
+// power-ups, and fills the board with berries if the mode has them. The campaign plays the stage the player is on,
+// and the daily challenge uses the day's seed to pick a campaign level and the berries so everyone starts with the
+// same board, without rivals. The arena is made up for each game. The other modes have no walls and the snake
+// starts somewhere random. The level in the editor is shown while it is being edited, without any berries or
+// rivals, and played in any mode while it is being tested.
+func (g *gameState) newBoard() {
+	g.newSeededBoard(time.Now().UnixNano())
+}
+
+// newSeededBoard sets up the board the same way as newBoard, using the seed for everything random so the same seed
+// always gives the same board, except for the daily challenge which uses the day's seed
+func (g *gameState) newSeededBoard(seed int64) {
+	g.seed = seed
+	x, y := g.gameCFG.GetGameAreaDims()
+	g.level = levels.Open("", int(x/g.gameCFG.GetGridSize()), int(y/g.gameCFG.GetGridSize()))
+	switch g.modes.GetMode() {
+	case modes.Daily:
+		g.dailyDate = modes.Today()
+		seed = modes.DailySeed(g.dailyDate)
+		g.modes.SetDailyDate(g.dailyDate)
+		campaign := levels.Campaign(int(x/g.gameCFG.GetGridSize()), int(y/g.gameCFG.GetGridSize()))
+		g.level = campaign[uint64(seed)%uint64(len(campaign))]
+	case modes.Campaign:
+		g.level = g.levels[g.stage]
+	case modes.Arena:
+		// Keep the seed short so the arena's name can be read, it can be made again with -generate and -seed
+		g.level = arenas.Random(int(x/g.gameCFG.GetGridSize()), int(y/g.gameCFG.GetGridSize()), seed%1000000)
+	}
+	if g.showEditor || g.testing {
+		g.level = g.editor.GetLevel()
+	}
+	g.walls = []pixel.Vec{}
+	g.wallSet = map[pixel.Vec]bool{}
+	for _, wall := range g.level.Walls {
+		pos := g.gameCFG.GetGridMatrix().Project(wall)
+		g.walls = append(g.walls, pos)
+		g.wallSet[pos] = true
+	}
+	g.portals = []pixel.Vec{}
+	for _, pair := range g.level.Portals {
+		g.portals = append(g.portals, g.gameCFG.GetGridMatrix().Project(pair[0]), g.gameCFG.GetGridMatrix().Project(pair[1]))
+	}
+	g.zones = []pixel.Vec{}
+	for _, zone := range g.level.BerryZones {
+		g.zones = append(g.zones, g.gameCFG.GetGridMatrix().Project(zone))
+	}
+	if g.modes.GetMode() == modes.Daily || g.modes.GetMode() == modes.Campaign || g.modes.GetMode() == modes.Arena || g.showEditor || g.testing {
+		g.s = snake.NewSnakeAt(*g.gameCFG, g.level.Spawn, g.level.Heading)
+		g.s.SetSpeed(g.level.Speed)
+	} else {
+		g.s = snake.NewSeededSnake(*g.gameCFG, seed)
+	}
+	g.s.SetPortals(g.level.GetPortalMap())
+	g.s.SetBlocked(g.wallBlocked)
+	g.hazards = hazards.NewHazards(g.gameCFG, g.level.Hazards)
+	if g.modes.GetMode() == modes.Daily || g.showEditor {
+		g.rivals.Clear()
+	} else {
+		g.rivals.Reset(g.gameCFG, seed, g.level.Speed, g.level.GetPortalMap(), &g.s, func(pos pixel.Vec) bool {
+			return g.wallBlocked(pos) || g.hazards.Occupies(pos)
+		})
+	}
+	g.berries.SetSeed(seed)
+	g.powerUps.SetSeed(seed)
+	g.berries.Clear()
+	g.powerUps.Clear()
+	if g.modes.GetMode().HasBerries() && !g.showEditor {
+		g.berries.Fill(g.gameCFG, g.berryBlocked)
+	}
+}
+
+// saveGame saves the game being played so it can be resumed, the next time the game is launched or after it is
+// closed. Levels being tested from the editor aren't saved as they might not have been saved themselves.
+func (g *gameState) saveGame() {
+	if !g.gameRunning {
+		return
+	}
+	if g.testing {
+		g.setStatus("Levels being tested can't be saved")
+		return
+	}
+	saved := savegame.Type{
+		Version:    savegame.Version,
+		Mode:       g.modes.GetMode(),
+		Level:      g.level.Name,
+		Stage:      g.stage,
+		Seed:       g.seed,
+		DailyDate:  g.dailyDate,
+		Elapsed:    g.modes.GetElapsed(),
+		Score:      g.score,
+		StageScore: g.stageScore,
+		Snake:      g.s.GetState(),
+		Effects:    g.s.GetEffects(),
+		Eaten:      g.eaten,
+		Berries:    g.berries.GetState(),
+		PowerUps:   g.powerUps.GetState(),
+		Hazards:    g.hazards.GetState(),
+		Rivals:     g.rivals.GetState(),
+	}
+	if err := saved.Save(); err != nil {
+		g.setStatus("Could not save the game")
+		return
+	}
+	g.saved = true
+	g.canResume = true
+	g.setStatus("Game saved")
+	g.bus.Publish(events.GameSaved{Mode: saved.Mode, Score: saved.Score})
+}
+
+// resumeGame carries on the saved game, paused so the player can get ready. The save is deleted so the game can
+// only be carried on once, and if it can't be carried on the reason is shown instead.
+func (g *gameState) resumeGame() {
+	saved, err := savegame.Load(g.gameCFG)
+	savegame.Delete()
+	g.canResume = false
+	if err == nil && saved.Mode == modes.Campaign && (saved.Stage < 0 || saved.Stage >= len(g.levels)) {
+		err = errors.New("the level has gone")
+	}
+	if err != nil {
+		g.setStatus("Can't resume, " + err.Error())
+		return
+	}
+	// Set up the board the game started with, then put everything back where it was
+	g.modes.SetMode(saved.Mode)
+	if saved.Mode == modes.Campaign {
+		g.setStage(saved.Stage)
+	}
+	g.newSeededBoard(saved.Seed)
+	if g.level.Name != saved.Level {
+		g.setStatus("Can't resume, the level has changed")
+		g.newBoard()
+		return
+	}
+	g.s = snake.NewSnakeFromState(*g.gameCFG, saved.Snake)
+	g.s.SetPortals(g.level.GetPortalMap())
+	g.s.SetBlocked(g.wallBlocked)
+	for _, e := range saved.Effects {
+		g.s.AddEffect(e.Effect, e.TicksLeft)
+	}
+	g.eaten = saved.Eaten
+	g.berries.SetState(saved.Berries)
+	g.powerUps.SetState(saved.PowerUps)
+	g.hazards.SetState(saved.Hazards)
+	g.rivals.SetState(g.gameCFG, saved.Rivals)
+	g.score = saved.Score
+	g.stageScore = saved.StageScore
+	g.startGame(g.s.GetDirection())
+	g.modes.SetElapsed(saved.Elapsed)
+	g.paused = true
+}
+
+// close saves the game being played, if there is one, so it can be resumed the next time the game is launched. It
+// should be called when the game is closing.
+func (g *gameState) close() {
+	if g.gameRunning && !g.testing {
+		g.saveGame()
+	}
+}
+
+// setStatus shows a message, e.g. about saving or resuming the game, above the game area for a few seconds
+func (g *gameState) setStatus(status string) {
+	g.status = status
+	g.statusLeft = 3 * time.Second
+}
+
+// getStatusText returns the line shown above the game area instead of the timer: the last status message, a warning
+// while a name which has already played today's daily challenge is typed or, on the start screen, how to resume the
+// saved game. It returns an empty string if there is nothing to show.
+func (g *gameState) getStatusText() string {
+	if g.statusLeft > 0 {
+		return g.status
+	}
+	if g.gameOver && g.highScore && g.modes.GetMode() == modes.Daily && g.daily.HasPlayed(g.dailyDate, g.scoreName) {
+		return "That name played today, won't count"
+	}
+	if g.canResume && !g.gameRunning && !g.gameOver && !g.showEditor {
+		return fmt.Sprintf("%s to resume the saved game", g.ctrl.GetBinding(controls.Confirm).String())
+	}
+	return ""
+}
+
+// newLevelName returns a name for a new level in the editor which none of the levels have
+func (g *gameState) newLevelName() string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("custom%d", i)
+		taken := false
+		for _, l := range g.levels {
+			taken = taken || l.Name == name
+		}
+		if !taken {
+			return name
+		}
+	}
+}
+
+// loadEditorLevel loads the level steps along the list of levels from the last one loaded into the editor, back
+// for a negative number of steps. Campaign levels are given a new name so saving them doesn't replace the original.
+func (g *gameState) loadEditorLevel(steps int) {
+	g.editorLevel = (g.editorLevel + steps + len(g.levels)) % len(g.levels)
+	l := g.levels[g.editorLevel]
+	x, y := g.gameCFG.GetGameAreaDims()
+	if g.editorLevel < len(levels.Campaign(int(x/g.gameCFG.GetGridSize()), int(y/g.gameCFG.GetGridSize()))) {
+		l.Name = g.newLevelName()
+	}
+	g.editor.Load(l)
+	g.editorStatus = "Loaded " + g.levels[g.editorLevel].Name
+}
+
+// saveEditorLevel saves the level in the editor to a level file, as long as it can be played, and loads the levels
+// again so it can be picked on the level select screen
+func (g *gameState) saveEditorLevel() {
+	l := g.editor.GetLevel()
+	x, y := g.gameCFG.GetGameAreaDims()
+	if err := l.Check(int(x/g.gameCFG.GetGridSize()), int(y/g.gameCFG.GetGridSize())); err != nil {
+		g.editorStatus = err.Error()
+		return
+	}
+	filename, err := levels.SaveLevel(l)
+	if err != nil {
+		g.editorStatus = "Could not save " + l.Name
+		return
+	}
+	g.editorStatus = "Saved " + filepath.Base(filename)
+	g.levels = levels.LoadLevels(int(x/g.gameCFG.GetGridSize()), int(y/g.gameCFG.GetGridSize()))
+}
+
+// completeStage records the campaign level as completed and moves on to the next level, which starts when the
+// player picks a direction, keeping the score. Completing the last level finishes the game.
+func (g *gameState) completeStage() {
+	g.progress.Record(g.level.Name, g.score-g.stageScore, true)
+	g.bus.Publish(events.StageCompleted{Stage: g.stage, Name: g.level.Name})
+	if g.stage+1 >= len(g.levels) {
+		g.endGame(true)
+		return
+	}
+	g.setStage(g.stage + 1)
+	g.gameRunning = false
+	g.turnQueue.Clear()
+	g.eaten = false
+	g.stageScore = g.score
+	g.newBoard()
+}
+
+// setStage changes the campaign level being played and saves it, so the campaign carries on from it next time
+func (g *gameState) setStage(stage int) {
+	g.stage = stage
+	g.userSettings.SetInt("levels.Stage", stage)
+	g.userSettings.SaveSettings()
+}
+
+// getLevelsMenu returns the names and values shown on the level select screen, with each level's best score
+func (g *gameState) getLevelsMenu() ([]string, []string) {
+	names := []string{}
+	values := []string{}
+	for i, l := range g.levels {
+		names = append(names, fmt.Sprintf("%d %s", i+1, l.Name))
+		best, played := g.progress.GetBest(l.Name)
+		switch {
+		case !g.progress.IsUnlocked(g.levels, i):
+			values = append(values, "Locked")
+		case g.progress.IsCompleted(l.Name):
+			values = append(values, fmt.Sprintf("Complete  Best %d", best))
+		case played:
+			values = append(values, fmt.Sprintf("Best %d", best))
+		default:
+			values = append(values, "New")
+		}
+	}
+	return names, values
+}
+
+// getTimerText returns the line shown above the game area, the mode and its timer, the arena's name, or the level
+// and how close the snake is to its target in the campaign and when testing a level from the editor, followed by
+// the rivals' scores if there are any
+func (g *gameState) getTimerText() string {
+	line := g.getModeText()
+	if len(g.rivals.GetRivals()) > 0 {
+		scores := []string{}
+		for _, r := range g.rivals.GetRivals() {
+			scores = append(scores, fmt.Sprint(r.Score))
+		}
+		line = strings.TrimSpace(line) + "  Rivals " + strings.Join(scores, " ")
+	}
+	return line
+}
+
+// getModeText returns the part of the line above the game area for the mode, see getTimerText
+func (g *gameState) getModeText() string {
+	if g.showEditor {
+		return "Editing " + g.level.Name
+	}
+	if g.testing {
+		return "Testing " + g.level.Name + "  " + g.level.GetTargetText(g.s.GetLength(), g.score)
+	}
+	if g.modes.GetMode() == modes.Arena {
+		return g.modes.GetMode().String() + "  " + g.level.Name
+	}
+	if g.modes.GetMode() == modes.Campaign {
+		return fmt.Sprintf("%d %s  %s", g.stage+1, g.level.Name, g.level.GetTargetText(g.s.GetLength(), g.score-g.stageScore))
+	}
+	return g.modes.GetMode().String() + "  " + g.modes.GetTimerText(g.s.GetLength())
+}
+
+// portalPartner returns the other portal of the pair the portal at pos is in, both are in the game area coordinate
+// plane. The portals are kept in pairs, so the partner is next to the portal in the list.
+func (g *gameState) portalPartner(pos pixel.Vec) pixel.Vec {
+	for i, portal := range g.portals {
+		if portal == pos {
+			return g.portals[i^1]
+		}
+	}
+	return pos
+}
+
+// isWall returns true if there is a level wall in the grid square at pos, which is in the game area coordinate plane.
+// The walls are kept in a set as well as a list since generated arenas can have thousands of them.
+func (g *gameState) isWall(pos pixel.Vec) bool {
+	return g.wallSet[pos]
+}
+
+// wallBlocked returns true if a snake can't move into the grid square at pos, which is in the game area coordinate
+// plane, because it is outside the arena or there is a wall in it
+func (g *gameState) wallBlocked(pos pixel.Vec) bool {
+	return !g.modes.GetArena(g.gameCFG).Contains(pos) || g.isWall(pos)
+}
+
+// rivalsDied publishes the deaths of the snakes which died running into each other or a hazard and gives the
+// player the points for any rivals it killed. If the player's snake is one of them the game ends and it returns true.
+func (g *gameState) rivalsDied(deaths []rivals.Death) bool {
+	died := false
+	for _, d := range deaths {
+		if d.Killer == rivals.Player {
+			g.score += d.Points
+		}
+		if d.Victim == rivals.Player {
+			died = true
+			continue
+		}
+		g.bus.Publish(events.SnakeKilled{Pos: d.Pos, Victim: d.Victim, Killer: d.Killer, Cause: d.Cause, Points: d.Points})
+	}
+	if died {
+		for _, d := range deaths {
+			if d.Victim == rivals.Player {
+				g.bus.Publish(events.Died{Pos: d.Pos, Cause: d.Cause, Score: g.score})
+			}
+		}
+		g.endGame(false)
+	}
+	return died
+}
+
+// feedRivals gives any rivals whose heads have reached a berry the berry, filling the board up again
+func (g *gameState) feedRivals() {
+	for i, r := range g.rivals.GetRivals() {
+		if !r.IsAlive() {
+			continue
+		}
+		if b, ok := g.berries.Eat(r.Snake.GetHeadPos()); ok {
+			points := g.rivals.Feed(i, b, g.berries.GetConfig().PoisonShrink)
+			g.bus.Publish(events.BerryStolen{Pos: b.Pos, Kind: b.Kind, Rival: i, Points: points})
+			g.berries.Fill(g.gameCFG, g.berryBlocked)
+		}
+	}
+}
+
+// hazardBlocked returns true if a hazard can't move into the grid square at pos, which is in the game area
+// coordinate plane, because it is outside the arena or there is a wall, a portal or a power-up in it
+func (g *gameState) hazardBlocked(pos pixel.Vec) bool {
+	if !g.modes.GetArena(g.gameCFG).Contains(pos) || g.isWall(pos) || g.powerUps.Occupies(pos) {
+		return true
+	}
+	for _, portal := range g.portals {
+		if portal == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHazards squashes any berries the hazards have moved onto, filling the board up again, kills any rivals they
+// have run into, and ends the game if one of them has run into the snake
+func (g *gameState) checkHazards() {
+	for _, h := range g.hazards.GetHazards() {
+		if b, ok := g.berries.Eat(h.Pos); ok {
+			g.bus.Publish(events.BerrySquashed{Pos: b.Pos, Kind: b.Kind})
+			g.berries.Fill(g.gameCFG, g.berryBlocked)
+		}
+	}
+	for _, h := range g.hazards.GetHazards() {
+		g.rivalsDied(g.rivals.Crush(h.Pos))
+	}
+	for _, h := range g.hazards.GetHazards() {
+		if g.s.Occupies(h.Pos) {
+			g.bus.Publish(events.Died{Pos: h.Pos, Cause: snake.HitHazard, Score: g.score})
+			g.endGame(false)
+			return
+		}
+	}
+}
+
+// berryBlocked returns true if no berry can be put in the grid square at pos, which is in the game area coordinate
+// plane, because something is already there or it is outside the level's berry zones
+func (g *gameState) berryBlocked(pos pixel.Vec) bool {
+	if g.occupied(pos) {
+		return true
+	}
+	if len(g.zones) == 0 {
+		return false
+	}
+	for _, zone := range g.zones {
+		if zone == pos {
+			return false
+		}
+	}
+	return true
+}
+
+// scoresTable returns the high scores table for the mode being played. For the daily challenge it is the table
+// for the day being browsed on the high scores screen.
+func (g *gameState) scoresTable() *scores.Type {
+	if g.modes.GetMode() == modes.Daily {
+		date := g.scoresDate
+		if !g.showScores {
+			date = g.dailyDate
+		}
+		table := g.daily.GetTable(date)
+		return &table
+	}
+	return g.scoresTables[g.modes.GetMode()]
+}
+
+// browseDaily moves the daily challenge table being shown by steps days which have results, back in time for a
+// positive number of steps and forward for a negative number
+func (g *gameState) browseDaily(steps int) {
+	dates := g.daily.GetDates()
+	if len(dates) == 0 || dates[0] != modes.Today() {
+		dates = append([]string{modes.Today()}, dates...)
+	}
+	for i, date := range dates {
+		if date == g.scoresDate {
+			if i+steps >= 0 && i+steps < len(dates) {
+				g.scoresDate = dates[i+steps]
+				g.bus.Publish(events.MenuChanged{})
+			}
+			return
+		}
+	}
+}
+
+// occupied returns true if the snake, a rival, a power-up, a hazard, a wall or a portal is in the grid square at pos,
+// so no berry can be put there
+func (g *gameState) occupied(pos pixel.Vec) bool {
+	if g.s.Occupies(pos) || g.rivals.Occupies(pos) || g.powerUps.Occupies(pos) || g.hazards.Occupies(pos) || g.isWall(pos) {
+		return true
+	}
+	for _, portal := range g.portals {
+		if portal == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// eatBerry does what the berry the snake has just eaten does and scores it. Normal and golden berries make
+// the snake grow on its next step and speed it up, poison berries shrink it and slow down berries slow it down.
+func (g *gameState) eatBerry(b berries.Berry) {
+	speed := g.s.GetSpeed()
+	switch b.Kind {
+	case berries.Normal, berries.Golden:
+		g.eaten = true
+		g.s.IncreaseSpeed()
+	case berries.Poison:
+		g.s.Shrink(g.berries.GetConfig().PoisonShrink)
+	case berries.SlowDown:
+		g.s.DecreaseSpeed()
+	}
+	points := int(float64(b.Kind.Points(g.s.GetSpeed())) * g.s.GetScoreMultiplier())
+	g.score += points
+	g.bus.Publish(events.BerryEaten{Pos: b.Pos, Kind: b.Kind, Points: points})
+	if g.s.GetSpeed() > speed {
+		g.bus.Publish(events.SpeedIncreased{Speed: g.s.GetSpeed()})
+	} else if g.s.GetSpeed() < speed {
+		g.bus.Publish(events.SpeedDecreased{Speed: g.s.GetSpeed()})
+	}
+}
+
+// playEffects starts the effects for the events in the game which have them
+func (g *gameState) playEffects(e events.Event) {
+	switch e := e.(type) {
+	case events.TailMoved:
+		g.effects.SnakeMoved(g.gameCFG, e.Pos, &g.theme)
+	case events.BerryEaten:
+		g.effects.BerryEaten(e.Pos, e.Points, drawing.BerryColour(e.Kind, &g.theme), &g.theme)
+	case events.PowerUpCollected:
+		g.effects.BerryEaten(e.Pos, 0, g.theme.PowerUp, &g.theme)
+	case events.ShieldUsed:
+		g.effects.BerryEaten(e.Pos, 0, g.theme.Border, &g.theme)
+	case events.BerrySquashed:
+		g.effects.BerryEaten(e.Pos, 0, drawing.BerryColour(e.Kind, &g.theme), &g.theme)
+	case events.BerryStolen:
+		g.effects.BerryEaten(e.Pos, 0, drawing.BerryColour(e.Kind, &g.theme), &g.theme)
+	case events.SnakeKilled:
+		points := 0
+		if e.Killer == rivals.Player {
+			points = e.Points
+		}
+		g.effects.BerryEaten(e.Pos, points, g.theme.Rival, &g.theme)
+	case events.Teleported:
+		g.effects.BerryEaten(e.From, 0, g.theme.Portal, &g.theme)
+		g.effects.BerryEaten(e.To, 0, g.theme.Portal, &g.theme)
+	case events.Died:
+		g.effects.SnakeDied(e.Pos, &g.theme)
+	}
+}
+
+// playSounds plays the sound effects for the events in the game which have them
+func (g *gameState) playSounds(e events.Event) {
+	switch e := e.(type) {
+	case events.DirectionChanged:
+		g.audio.Play(audio.Turn)
+	case events.BerryEaten:
+		if e.Kind == berries.Poison {
+			g.audio.Play(audio.Shrink)
+		} else {
+			g.audio.Play(audio.Eat)
+		}
+	case events.BerrySquashed:
+		g.audio.Play(audio.Shrink)
+	case events.SpeedIncreased:
+		g.audio.Play(audio.SpeedUp)
+	case events.Died, events.SnakeKilled:
+		g.audio.Play(audio.Death)
+	case events.NewHighScore, events.StageCompleted:
+		g.audio.Play(audio.HighScore)
+	case events.PowerUpCollected:
+		g.audio.Play(audio.PowerUp)
+	case events.PowerUpEnded:
+		g.audio.Play(audio.PowerDown)
+	case events.Teleported:
+		g.audio.Play(audio.Teleport)
+	case events.MenuChanged, events.GameSaved:
+		g.audio.Play(audio.Menu)
+	}
+}
+
+// nextTheme switches to the next theme and saves the choice
+func (g *gameState) nextTheme() {
+	g.themeIndex = (g.themeIndex + 1) % len(g.themes)
+	g.userSettings.SetString("theme.Name", g.themes[g.themeIndex].Name)
+	g.userSettings.SaveSettings()
+	g.applyTheme()
+}
+
+// applyTheme sets the theme used for drawing from the chosen theme and the accessibility options, and turns the
+// effects on or off to match the options
+func (g *gameState) applyTheme() {
+	g.theme = g.access.Apply(g.themes[g.themeIndex])
+	g.effects.SetEnabled(g.access.GetEffects(), g.access.GetFlash())
+}
+
+// getTheme returns the theme currently in use
+func (g *gameState) getTheme() *theme.Type {
+	return &g.theme
+}
+
+// draw draws the current frame with the renderer provided
+func (g *gameState) draw(r render.Renderer) {
+	r.SetTheme(g.getTheme())
+	r.Clear()
+	// Always draw the game
+	r.DrawBackground(g.gameCFG)
+	if !g.showScores && !g.showControls && !g.showOptions && !g.showLevels {
+		// Hide game elements if high scores, controls or options are being diplayed
+		r.DrawWalls(g.gameCFG, g.modes.GetArena(g.gameCFG), g.walls)
+		if g.showEditor {
+			// Show where berries can grow and the portal waiting for its partner while editing
+			r.DrawZones(g.gameCFG, g.zones)
+			portals := g.portals
+			if square, ok := g.editor.GetPendingPortal(); ok {
+				portals = append([]pixel.Vec{g.gameCFG.GetGridMatrix().Project(square)}, portals...)
+			}
+			r.DrawPortals(g.gameCFG, portals)
+		} else {
+			r.DrawPortals(g.gameCFG, g.portals)
+		}
+		r.DrawEffects(g.gameCFG, &g.effects)
+		r.DrawSnake(g.gameCFG, &g.s)
+		r.DrawRivals(g.gameCFG, g.rivals.GetSnakes())
+		r.DrawBerries(g.gameCFG, g.berries.GetBerries())
+		r.DrawPowerUps(g.gameCFG, g.powerUps.GetPowerUps())
+		r.DrawHazards(g.gameCFG, g.hazards.GetHazards())
+		if g.gameRunning && g.access.GetAssist() {
+			safe := []pixel.Vec{}
+			for _, cell := range g.s.SafeMoves(g.gameCFG) {
+				if !g.wallBlocked(cell) && !g.hazards.Occupies(cell) && !g.rivals.Occupies(cell) {
+					safe = append(safe, cell)
+				}
+			}
+			r.DrawAssist(g.gameCFG, safe)
+		}
+		if text := g.getStatusText(); text != "" {
+			r.DrawTimer(g.gameCFG, text)
+		} else if g.modes.GetMode() != modes.Endless || g.showEditor || g.testing || len(g.rivals.GetRivals()) > 0 {
+			r.DrawTimer(g.gameCFG, g.getTimerText())
+		}
+	}
+	r.DrawTitle()
+	if g.showEditor {
+		toolNames := []string{}
+		for t := editor.Tool(0); t < editor.NumTools; t++ {
+			toolNames = append(toolNames, t.String())
+		}
+		r.DrawEditor(g.gameCFG, g.ctrl, toolNames, int(g.editor.GetTool()), g.editorStatus)
+	} else {
+		r.DrawScore(g.score)
+		r.DrawActiveEffects(g.s.GetEffects())
+		r.DrawControls(g.ctrl)
+	}
+	if !g.gameRunning && !g.gameOver && !g.showScores && !g.showControls && !g.showOptions && !g.showLevels && !g.showEditor {
+		// Show the start game message
+		r.DrawStartGame()
+	} else if g.gameRunning && g.paused {
+		r.DrawPaused()
+	} else if g.gameOver {
+		r.DrawGameOver(g.gameCFG, g.scoreName, g.highScore)
+	} else if g.showScores {
+		r.DrawScoresList(g.gameCFG, g.scoresTable())
+		if g.modes.GetMode() == modes.Daily {
+			r.DrawTimer(g.gameCFG, "Daily  "+g.scoresDate)
+		}
+	} else if g.showControls {
+		r.DrawRebind(g.gameCFG, g.ctrl, g.rebindSelected, g.rebindWaiting)
+	} else if g.showOptions {
+		names := []string{"Theme", "Mode", "Rivals", "Rival Skill"}
+		values := []string{g.themes[g.themeIndex].Name, g.modes.GetMode().String(), fmt.Sprint(g.rivals.GetCount()), g.rivals.GetDifficulty().String()}
+		for o := access.Option(0); o < access.NumOptions; o++ {
+			names = append(names, o.String())
+			values = append(values, g.access.GetValueText(o))
+		}
+		for c := audio.Channel(0); c < audio.NumChannels; c++ {
+			names = append(names, c.String())
+			values = append(values, g.audio.GetValueText(c))
+		}
+		r.DrawOptions(g.gameCFG, g.ctrl, names, values, g.optionSelected)
+	} else if g.showLevels {
+		names, values := g.getLevelsMenu()
+		r.DrawLevels(g.gameCFG, g.ctrl, names, values, g.levelSelected)
+	}
+	r.Update()
+}
@@ -0,0 +1,186 @@
+package powerups
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/random"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// PowerUp is a power-up on the board, Pos is in the game area coordinate plane. The snake is given its Effect when
+// it collects it, and it disappears from the board once the snake has taken another Lifetime steps.
+type PowerUp struct {
+	Pos       pixel.Vec
+	Effect    snake.Effect
+	Lifetime  int
+	TicksLeft int
+}
+
+// Life returns the fraction of the power-up's lifetime it has left, from 1 when it appears to 0 when it disappears
+func (p PowerUp) Life() float64 {
+	if p.Lifetime == 0 {
+		return 1
+	}
+	return float64(p.TicksLeft) / float64(p.Lifetime)
+}
+
+// Config sets how often power-ups appear, how long they stay on the board and how long each effect lasts. Times
+// are counted in steps of the snake.
+type Config struct {
+	Chance      float64
+	Lifetime    int
+	Durations   [snake.NumEffects]int
+	MagnetReach int
+}
+
+// DefaultConfig is the config used for any values which aren't in the settings file
+var DefaultConfig = Config{
+	Chance:      0.25,
+	Lifetime:    60,
+	Durations:   [snake.NumEffects]int{40, 30, 50, 100, 50},
+	MagnetReach: 5,
+}
+
+// LoadConfig returns the power-up config from the settings. The keys are "powerups.Chance", the chance from 0 to 1
+// of a power-up appearing each time a berry is eaten, "powerups.Lifetime", "powerups.MagnetReach" in grid squares
+// and the duration of each effect, e.g. "powerups.GhostTicks". An effect with a duration of 0 never appears.
+func LoadConfig(set *settings.Type) Config {
+	d := DefaultConfig
+	c := Config{
+		Chance:      set.GetFloat("powerups.Chance", d.Chance),
+		Lifetime:    set.GetInt("powerups.Lifetime", d.Lifetime),
+		MagnetReach: set.GetInt("powerups.MagnetReach", d.MagnetReach),
+	}
+	for e := snake.Effect(0); e < snake.NumEffects; e++ {
+		c.Durations[e] = set.GetInt("powerups."+e.String()+"Ticks", d.Durations[e])
+	}
+	return c
+}
+
+// Type holds the power-ups on the board
+type Type struct {
+	config   Config
+	powerUps []PowerUp
+	src      *random.Source
+	r        *rand.Rand
+}
+
+// State is a snapshot of the power-ups on the board and how far through their random sequence they have got, which
+// can be saved and used to restore them later
+type State struct {
+	PowerUps []PowerUp
+	Rand     random.State
+}
+
+// NewPowerUps returns an empty board which power-ups appear on as described by the config
+func NewPowerUps(config Config) Type {
+	t := new(Type)
+	t.config = config
+	t.powerUps = []PowerUp{}
+	t.SetSeed(time.Now().UnixNano())
+	return *t
+}
+
+// SetSeed restarts the sequence of random power-ups, so the same seed always gives the same power-ups
+func (t *Type) SetSeed(seed int64) {
+	t.src = random.NewSource(seed)
+	t.r = rand.New(t.src)
+}
+
+// GetState returns a snapshot of the power-ups
+func (t *Type) GetState() State {
+	return State{PowerUps: append([]PowerUp{}, t.powerUps...), Rand: t.src.GetState()}
+}
+
+// SetState puts the power-ups back the way they were in the snapshot, carrying on the random sequence where it left
+// off
+func (t *Type) SetState(state State) {
+	t.powerUps = append([]PowerUp{}, state.PowerUps...)
+	t.src.SetState(state.Rand)
+}
+
+// GetPowerUps returns the power-ups on the board
+func (t *Type) GetPowerUps() []PowerUp {
+	return t.powerUps
+}
+
+// GetConfig returns the config the power-ups were created with
+func (t *Type) GetConfig() Config {
+	return t.config
+}
+
+// Spawn has a chance, set by the config, of putting a power-up with a random effect in a random empty grid
+// square. Occupied returns true for squares, in the game area coordinate plane, which already have something in
+// them. Only one power-up is on the board at a time.
+func (t *Type) Spawn(gameCFG *game.Config, occupied func(pixel.Vec) bool) {
+	if len(t.powerUps) > 0 || t.r.Float64() >= t.config.Chance {
+		return
+	}
+	effects := []snake.Effect{}
+	for e := snake.Effect(0); e < snake.NumEffects; e++ {
+		if t.config.Durations[e] > 0 {
+			effects = append(effects, e)
+		}
+	}
+	if len(effects) == 0 {
+		return
+	}
+	x, y := gameCFG.GetGameAreaDims()
+	for try := 0; try < 1000; try++ {
+		square := pixel.V(float64(t.r.Intn(int(x/gameCFG.GetGridSize()))), float64(t.r.Intn(int(y/gameCFG.GetGridSize()))))
+		pos := gameCFG.GetGridMatrix().Project(square)
+		if occupied(pos) {
+			continue
+		}
+		t.powerUps = append(t.powerUps, PowerUp{
+			Pos:       pos,
+			Effect:    effects[t.r.Intn(len(effects))],
+			Lifetime:  t.config.Lifetime,
+			TicksLeft: t.config.Lifetime,
+		})
+		return
+	}
+}
+
+// Update counts down the steps left for each power-up, removing them when they run out. It should be called each
+// time the snake takes a step.
+func (t *Type) Update() {
+	left := t.powerUps[:0]
+	for _, p := range t.powerUps {
+		p.TicksLeft--
+		if p.TicksLeft > 0 {
+			left = append(left, p)
+		}
+	}
+	t.powerUps = left
+}
+
+// Collect removes and returns the power-up at pos, which is in the game area coordinate plane, if there is one
+func (t *Type) Collect(pos pixel.Vec) (PowerUp, bool) {
+	for i, p := range t.powerUps {
+		if p.Pos == pos {
+			t.powerUps = append(t.powerUps[:i], t.powerUps[i+1:]...)
+			return p, true
+		}
+	}
+	return PowerUp{}, false
+}
+
+// Occupies returns true if there is a power-up in the grid square at pos, which is in the game area coordinate plane
+func (t *Type) Occupies(pos pixel.Vec) bool {
+	for _, p := range t.powerUps {
+		if p.Pos == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes all the power-ups from the board
+func (t *Type) Clear() {
+	t.powerUps = []PowerUp{}
+}
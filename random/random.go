@@ -0,0 +1,59 @@
+package random
+
+import (
+	"math/rand"
+)
+
+// MaxCount is the furthest through its sequence a State can be picked up from. SetState has to skip every number
+// already given out, so a larger count would hold up loading a game, and no game gets anywhere near it.
+const MaxCount = 1 << 24
+
+// State is how far a Source has got through its sequence, the seed it was started with and how many numbers it
+// has given out since
+type State struct {
+	Seed  int64
+	Count int64
+}
+
+// Source is a source of random numbers for rand.New which counts the numbers it gives out, so how far it has got
+// can be saved and picked up again later. The numbers are the same as rand.NewSource gives for the same seed.
+type Source struct {
+	src   rand.Source
+	seed  int64
+	count int64
+}
+
+// NewSource returns a source started with the seed
+func NewSource(seed int64) *Source {
+	s := new(Source)
+	s.src = rand.NewSource(seed)
+	s.seed = seed
+	return s
+}
+
+// Int63 returns the next random number in the sequence
+func (s *Source) Int63() int64 {
+	s.count++
+	return s.src.Int63()
+}
+
+// Seed starts the sequence again from the seed
+func (s *Source) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed = seed
+	s.count = 0
+}
+
+// GetState returns how far the source has got through its sequence
+func (s *Source) GetState() State {
+	return State{Seed: s.seed, Count: s.count}
+}
+
+// SetState starts the sequence again from the state's seed and skips the numbers it had already given out, up to
+// MaxCount of them
+func (s *Source) SetState(state State) {
+	s.Seed(state.Seed)
+	for s.count < state.Count && s.count < MaxCount {
+		s.Int63()
+	}
+}
@@ -0,0 +1,44 @@
+package random
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSetStateCarriesOn(t *testing.T) {
+	s := NewSource(42)
+	for i := 0; i < 100; i++ {
+		s.Int63()
+	}
+	state := s.GetState()
+	want := []int64{s.Int63(), s.Int63(), s.Int63()}
+
+	restored := NewSource(0)
+	restored.SetState(state)
+	for i, w := range want {
+		if got := restored.Int63(); got != w {
+			t.Errorf("number %d after SetState = %d, want %d", i, got, w)
+		}
+	}
+}
+
+func TestSameNumbersAsRand(t *testing.T) {
+	s := NewSource(7)
+	r := rand.NewSource(7)
+	for i := 0; i < 10; i++ {
+		if got, want := s.Int63(), r.Int63(); got != want {
+			t.Fatalf("number %d = %d, want %d", i, got, want)
+		}
+	}
+	if got := s.GetState(); got != (State{Seed: 7, Count: 10}) {
+		t.Errorf("GetState() = %+v, want seed 7 and count 10", got)
+	}
+}
+
+func TestSetStateStopsAtMaxCount(t *testing.T) {
+	s := NewSource(0)
+	s.SetState(State{Seed: 1, Count: MaxCount * 1000})
+	if got := s.GetState().Count; got != MaxCount {
+		t.Errorf("count after SetState = %d, want %d", got, MaxCount)
+	}
+}
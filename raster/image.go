@@ -0,0 +1,525 @@
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/drawing"
+	"github.com/benjmarshall/gopixelsnake/editor"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/scores"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/sprites"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Image is a render.Renderer which draws the game into an image.RGBA in pure Go, without needing a window or OpenGL.
+// Nothing it imports uses pixelgl, so it can be built and tested on machines without GLFW.
+// It lays the frame out in the same way as the pixel window so it can be used for screenshots and thumbnails.
+type Image struct {
+	img     *image.RGBA
+	gameCFG *game.Config
+	theme   *theme.Type
+	sheet   *sprites.Sheet
+	scale   float64
+	matrix  pixel.Matrix
+}
+
+// NewImage returns a renderer which draws the game layout scaled by scale, so 1 gives an image the size of the
+// window the game was designed for.
+func NewImage(gameCFG *game.Config, scale float64) *Image {
+	r := new(Image)
+	r.gameCFG = gameCFG
+	r.theme = &theme.Classic
+	r.scale = scale
+	r.matrix = gameCFG.GetLayoutMatrix().Chained(pixel.IM.Scaled(pixel.ZV, scale))
+	bounds := gameCFG.GetLayoutBounds()
+	r.img = image.NewRGBA(image.Rect(0, 0, int(math.Ceil(bounds.W()*scale)), int(math.Ceil(bounds.H()*scale))))
+	return r
+}
+
+// GetImage returns the image the frame has been drawn to
+func (r *Image) GetImage() *image.RGBA {
+	return r.img
+}
+
+// WritePNG encodes the frame as a PNG image
+func (r *Image) WritePNG(w io.Writer) error {
+	return png.Encode(w, r.img)
+}
+
+// SavePNG saves the frame to a PNG file
+func (r *Image) SavePNG(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.WritePNG(f)
+}
+
+// SetTheme changes the colours, shapes, sprites and font used to draw the game
+func (r *Image) SetTheme(th *theme.Type) {
+	r.theme = th
+	// Fall back to drawing the snake with shapes if the theme has no sprite sheet or it can't be loaded
+	r.sheet = nil
+	if th.Sprites != "" {
+		if sheet, err := sprites.Load(th.Sprites); err == nil {
+			r.sheet = sheet
+		}
+	}
+}
+
+// Clear starts a new frame
+func (r *Image) Clear() {
+	draw.Draw(r.img, r.img.Bounds(), image.NewUniform(r.theme.Background), image.ZP, draw.Src)
+}
+
+// DrawBackground draws the game area border
+func (r *Image) DrawBackground(gameCFG *game.Config) {
+	min, max := gameCFG.GetGameAreaAsVecs()
+	min = r.matrix.Project(min)
+	max = r.matrix.Project(max)
+	border := gameCFG.GetBorderWeight() * r.scale
+	min = min.Sub(pixel.V(border, border))
+	max = max.Add(pixel.V(border, border))
+	r.fillRect(min, pixel.V(max.X, min.Y+border), r.theme.Border)
+	r.fillRect(pixel.V(min.X, max.Y-border), max, r.theme.Border)
+	r.fillRect(min, pixel.V(min.X+border, max.Y), r.theme.Border)
+	r.fillRect(pixel.V(max.X-border, min.Y), max, r.theme.Border)
+}
+
+// DrawEffects draws the effects playing in the game area
+func (r *Image) DrawEffects(gameCFG *game.Config, fx *drawing.Effects) {
+	for _, p := range fx.Parts(gameCFG) {
+		r.fillPart(drawing.Part{Shape: p.Shape, Pos: r.matrix.Project(p.Pos), Size: p.Size.Scaled(r.scale)}, p.Colour)
+	}
+	for _, popUp := range fx.GetPopUps() {
+		r.drawColouredText(r.matrix.Project(popUp.Pos), []string{popUp.Text}, 2*r.theme.TextScale, 1, true, popUp.Colour)
+	}
+}
+
+// DrawWalls fills in the part of the game area outside the arena and the level's walls
+func (r *Image) DrawWalls(gameCFG *game.Config, arena pixel.Rect, walls []pixel.Vec) {
+	parts := append(drawing.WallParts(gameCFG.GetGameAreaAsRec(), arena), drawing.SquareParts(walls, gameCFG.GetGridSize())...)
+	for _, part := range parts {
+		r.fillPart(drawing.Part{Shape: part.Shape, Pos: r.matrix.Project(part.Pos), Size: part.Size.Scaled(r.scale)}, r.theme.Border)
+	}
+}
+
+// DrawPortals draws a ring in each of the portals
+func (r *Image) DrawPortals(gameCFG *game.Config, portals []pixel.Vec) {
+	for _, portal := range portals {
+		ring := drawing.PortalParts(r.matrix.Project(portal), gameCFG.GetGridSize()*r.scale)
+		r.fillPart(ring[0], r.theme.Portal)
+		r.fillPart(ring[1], r.theme.Background)
+	}
+}
+
+// DrawSnake draws the snake with the same pieces as drawing.DrawSnake, or the theme's sprites if it has them,
+// with the head and tail part way through their step if the snake is moving
+func (r *Image) DrawSnake(gameCFG *game.Config, s *snake.Type) {
+	size := gameCFG.GetGridSize() * r.scale
+	segments := s.InterpolatedSegments(s.GetTickFraction())
+	if r.sheet != nil {
+		for i := len(segments) - 1; i >= 0; i-- {
+			seg := segments[i]
+			if seg.Piece == snake.Tail && seg.Slide != pixel.ZV {
+				r.drawSprite(snake.Straight, sprites.Angle(seg), r.matrix.Project(seg.Pos), size)
+			}
+			r.drawSprite(seg.Piece, sprites.Angle(seg), r.matrix.Project(seg.Pos).Add(seg.Slide.Scaled(r.scale)), size)
+		}
+		return
+	}
+	r.drawSnakeShapes(segments, size, r.theme)
+}
+
+// DrawRivals draws the rival snakes with the same pieces as the player's snake, all in the rival colour
+func (r *Image) DrawRivals(gameCFG *game.Config, ss []*snake.Type) {
+	rt := drawing.RivalTheme(r.theme)
+	for _, s := range ss {
+		r.drawSnakeShapes(s.InterpolatedSegments(s.GetTickFraction()), gameCFG.GetGridSize()*r.scale, &rt)
+	}
+}
+
+// drawSnakeShapes draws the segments of a snake with the shapes and colours of the theme, see drawing.DrawSnake
+func (r *Image) drawSnakeShapes(segments []snake.Segment, size float64, th *theme.Type) {
+	w := 0.0
+	if th.Outlines {
+		for _, seg := range segments {
+			for _, part := range drawing.SegmentParts(seg, r.matrix.Project(seg.Pos), size, r.scale, 0, th) {
+				r.fillPart(part, th.Text)
+			}
+		}
+		w = drawing.OutlineWeight * r.scale
+	}
+	// Draw from the tail to the head, so the head is drawn over the body as it slides
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := segments[i]
+		for _, part := range drawing.SegmentParts(seg, r.matrix.Project(seg.Pos), size, r.scale, w, th) {
+			r.fillPart(part, drawing.SegmentColour(seg, th))
+		}
+	}
+	for _, part := range drawing.EyeParts(segments[0], r.matrix.Project(segments[0].Pos), size, r.scale) {
+		r.fillPart(part, th.Background)
+	}
+}
+
+// DrawBerries draws the berries, each with the mark for its kind
+func (r *Image) DrawBerries(gameCFG *game.Config, bs []berries.Berry) {
+	for _, b := range bs {
+		pos := r.matrix.Project(b.Pos)
+		size := drawing.BerrySize(b, gameCFG.GetGridSize()*r.scale)
+		r.fillOutlinedShape(r.theme.BerryShape, pos, size, drawing.BerryColour(b.Kind, r.theme))
+		for _, part := range drawing.BerryMarks(b.Kind, pos, size) {
+			r.fillPart(part, r.theme.Background)
+		}
+	}
+}
+
+// DrawPowerUps draws the power-ups, each with the mark for its effect
+func (r *Image) DrawPowerUps(gameCFG *game.Config, ps []powerups.PowerUp) {
+	for _, p := range ps {
+		pos := r.matrix.Project(p.Pos)
+		size := drawing.PowerUpSize(p, gameCFG.GetGridSize()*r.scale)
+		r.fillOutlinedShape(theme.Square, pos, size, r.theme.PowerUp)
+		for _, part := range drawing.PowerUpMarks(p.Effect, pos, size) {
+			r.fillPart(part, r.theme.Background)
+		}
+	}
+}
+
+// DrawHazards draws the hazards, each in the shape for its kind with its marks
+func (r *Image) DrawHazards(gameCFG *game.Config, hs []hazards.Hazard) {
+	size := gameCFG.GetGridSize() * r.scale
+	for _, h := range hs {
+		pos := r.matrix.Project(h.Pos)
+		r.fillOutlinedShape(drawing.HazardShape(h.Kind), pos, size, r.theme.Hazard)
+		for _, part := range drawing.HazardMarks(h.Kind, pos, size) {
+			r.fillPart(part, r.theme.Background)
+		}
+	}
+}
+
+// DrawZones draws a dot in each of the squares in a berry zone
+func (r *Image) DrawZones(gameCFG *game.Config, zones []pixel.Vec) {
+	for _, zone := range zones {
+		r.fillPart(drawing.ZonePart(r.matrix.Project(zone), gameCFG.GetGridSize()*r.scale), r.theme.Berry)
+	}
+}
+
+// DrawAssist outlines the squares the snake can safely move into
+func (r *Image) DrawAssist(gameCFG *game.Config, cells []pixel.Vec) {
+	half := gameCFG.GetGridSize() / 2 * r.scale
+	for _, cell := range cells {
+		pos := r.matrix.Project(cell)
+		min, max := pos.Sub(pixel.V(half, half)), pos.Add(pixel.V(half, half))
+		r.fillRect(min, pixel.V(max.X, min.Y+r.scale), r.theme.Text)
+		r.fillRect(pixel.V(min.X, max.Y-r.scale), max, r.theme.Text)
+		r.fillRect(min, pixel.V(min.X+r.scale, max.Y), r.theme.Text)
+		r.fillRect(pixel.V(max.X-r.scale, min.Y), max, r.theme.Text)
+	}
+}
+
+// DrawTitle draws the title panel
+func (r *Image) DrawTitle() {
+	lines := []string{"Go Pixel", "Snake"}
+	r.drawText(r.panelOrig(0.9), lines, r.fitScale(lines, 4, 1, r.panelWidth(), 0), 1, true)
+}
+
+// DrawScore draws the score panel
+func (r *Image) DrawScore(score int) {
+	lines := []string{strconv.Itoa(score)}
+	r.drawText(r.panelOrig(0.8), lines, r.fitScale(lines, 6, 1, r.panelWidth(), 0), 1, true)
+}
+
+// DrawTimer draws the mode and its timer above the game area
+func (r *Image) DrawTimer(gameCFG *game.Config, line string) {
+	area := gameCFG.GetGameAreaAsRec()
+	r.drawText(r.matrix.Project(pixel.V(area.Center().X, area.Max.Y+8)), []string{line}, 2*r.theme.TextScale, 1, true)
+}
+
+// DrawActiveEffects draws the snake's effects under the score
+func (r *Image) DrawActiveEffects(active []snake.ActiveEffect) {
+	lines := []string{}
+	for _, a := range active {
+		lines = append(lines, a.String())
+	}
+	r.drawText(r.panelOrig(0.75), lines, 1.5*r.theme.TextScale, 1, true)
+}
+
+// DrawControls draws the controls panel for the current bindings
+func (r *Image) DrawControls(ctrl *controls.Type) {
+	lines := strings.Split(strings.Join(ctrl.GetControlsText(), "\n"), "\n")
+	r.drawText(r.panelOrig(0.62), lines, r.fitScale(lines, 2, 1, r.panelWidth(), r.panelOrig(0.62).Y/r.scale-20), 1, true)
+}
+
+// DrawStartGame draws the start game message
+func (r *Image) DrawStartGame() {
+	orig := r.matrix.Project(r.gameCFG.GetGameAreaAsRec().Center())
+	lines := []string{"Hit an arrow key", "to start a new game!"}
+	r.drawText(orig, lines, r.fitScale(lines, 3, 1, r.gameCFG.GetGameAreaAsRec().W(), 0), 1, true)
+}
+
+// DrawPaused draws the paused message
+func (r *Image) DrawPaused() {
+	orig := r.matrix.Project(r.gameCFG.GetGameAreaAsRec().Center())
+	lines := []string{"Paused"}
+	r.drawText(orig, lines, r.fitScale(lines, 4, 1, r.gameCFG.GetGameAreaAsRec().W(), 0), 1, true)
+}
+
+// DrawGameOver draws the game over message
+func (r *Image) DrawGameOver(gameCFG *game.Config, name string, highScore bool) {
+	area := gameCFG.GetGameAreaAsRec()
+	orig := r.matrix.Project(pixel.V(area.Center().X, area.H()*0.6))
+	lines := []string{"Game Over!"}
+	if highScore {
+		if name == "" {
+			name = "___"
+		}
+		lines = append(lines, "You have a new high score.", "Please type your name and then", "Press Enter to continue...", name)
+	} else {
+		lines = append(lines, "Press Enter to continue...")
+	}
+	r.drawText(orig, lines, r.fitScale(lines, 3, 1, area.W(), 0), 1, true)
+}
+
+// DrawScoresList draws the high scores table
+func (r *Image) DrawScoresList(gameCFG *game.Config, scoresTable *scores.Type) {
+	area := gameCFG.GetGameAreaAsRec()
+	lines := scoresTable.GetTopScores()
+	for i := 0; i < 3; i++ {
+		orig := r.matrix.Project(pixel.V(area.Min.X+35+float64(i)*area.W()*0.35, area.Max.Y-50))
+		column := []string{}
+		for _, line := range lines {
+			column = append(column, line[i])
+		}
+		r.drawText(orig, column, 3, 1.5, false)
+	}
+}
+
+// DrawRebind draws the controls rebinding screen
+func (r *Image) DrawRebind(gameCFG *game.Config, ctrl *controls.Type, selected int, waiting bool) {
+	area := gameCFG.GetGameAreaAsRec()
+	orig := r.matrix.Project(pixel.V(area.Min.X+35, area.Max.Y-50))
+	lines := []string{}
+	for a := controls.Action(0); a < controls.NumActions; a++ {
+		marker := "  "
+		if int(a) == selected {
+			marker = "> "
+		}
+		key := ctrl.GetBinding(a).String()
+		if int(a) == selected && waiting {
+			key = "..."
+		}
+		lines = append(lines, fmt.Sprintf("%s%-12s%s", marker, a.String(), key))
+	}
+	r.drawText(orig, lines, r.fitScale(lines, 2, 1.5, 0, area.H()-60), 1.5, false)
+}
+
+// DrawOptions draws the options screen
+func (r *Image) DrawOptions(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	area := gameCFG.GetGameAreaAsRec()
+	orig := r.matrix.Project(pixel.V(area.Min.X+35, area.Max.Y-50))
+	lines := []string{}
+	for i := range names {
+		marker := "  "
+		if i == selected {
+			marker = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%-12s%s", marker, names[i], values[i]))
+	}
+	r.drawText(orig, lines, 2, 1.5, false)
+}
+
+// DrawLevels draws the level select screen
+func (r *Image) DrawLevels(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	r.DrawOptions(gameCFG, ctrl, names, values, selected)
+}
+
+// DrawEditor draws the level editor's panel where the controls usually are
+func (r *Image) DrawEditor(gameCFG *game.Config, ctrl *controls.Type, tools []string, selected int, status string) {
+	lines := editor.PanelLines(ctrl, tools, selected, status)
+	orig := r.panelOrig(0.75)
+	r.drawText(orig, lines, r.fitScale(lines, 1.5, 1, r.panelWidth(), orig.Y/r.scale-20), 1, true)
+}
+
+// Update does nothing, the frame is complete once it has been drawn
+func (r *Image) Update() {}
+
+// fillRect fills the rectangle between two corners given in window coordinates, which have y pointing up
+func (r *Image) fillRect(a pixel.Vec, b pixel.Vec, c color.Color) {
+	h := float64(r.img.Bounds().Dy())
+	rect := image.Rect(
+		int(math.Floor(math.Min(a.X, b.X)+0.5)),
+		int(math.Floor(h-math.Max(a.Y, b.Y)+0.5)),
+		int(math.Floor(math.Max(a.X, b.X)+0.5)),
+		int(math.Floor(h-math.Min(a.Y, b.Y)+0.5)),
+	)
+	draw.Draw(r.img, rect, image.NewUniform(c), image.ZP, draw.Over)
+}
+
+// fillShape fills a shape of the given size centred on pos, which is in window coordinates
+func (r *Image) fillShape(shape theme.Shape, pos pixel.Vec, size float64, c color.Color) {
+	r.fillPart(drawing.Part{Shape: shape, Pos: pos, Size: pixel.V(size, size)}, c)
+}
+
+// fillPart fills a part given in window coordinates, round and diamond parts use the smaller of their width and height
+func (r *Image) fillPart(part drawing.Part, c color.Color) {
+	half := part.Size.Scaled(0.5)
+	switch part.Shape {
+	case theme.Round:
+		r.fillCircle(part.Pos, math.Min(half.X, half.Y), c)
+	case theme.Diamond:
+		r.fillDiamond(part.Pos, math.Min(half.X, half.Y), c)
+	default:
+		r.fillRect(part.Pos.Sub(half), part.Pos.Add(half), c)
+	}
+}
+
+// drawSprite draws the sprite for a piece of the snake, rotated by angle, in the square of the given size centred
+// on pos, which is in window coordinates
+func (r *Image) drawSprite(piece snake.Piece, angle float64, pos pixel.Vec, size float64) {
+	h := float64(r.img.Bounds().Dy())
+	min := image.Pt(int(math.Floor(pos.X-size/2+0.5)), int(math.Floor(h-pos.Y-size/2+0.5)))
+	for y := min.Y; y < min.Y+int(size+0.5); y++ {
+		for x := min.X; x < min.X+int(size+0.5); x++ {
+			// Sample the sprite at the centre of the pixel, as a fraction of the square with y pointing up
+			fx := (float64(x) + 0.5 - pos.X) / size
+			fy := (h - float64(y) - 0.5 - pos.Y) / size
+			c := r.sheet.At(piece, angle, fx, fy)
+			if _, _, _, a := c.RGBA(); a == 0 {
+				continue
+			}
+			draw.Draw(r.img, image.Rect(x, y, x+1, y+1), image.NewUniform(c), image.ZP, draw.Over)
+		}
+	}
+}
+
+// fillOutlinedShape fills a shape, with an outline if the theme has outlines turned on
+func (r *Image) fillOutlinedShape(shape theme.Shape, pos pixel.Vec, size float64, c color.Color) {
+	if r.theme.Outlines {
+		r.fillShape(shape, pos, size, r.theme.Text)
+		size -= 2 * drawing.OutlineWeight * r.scale
+	}
+	r.fillShape(shape, pos, size, c)
+}
+
+// fillDiamond fills a square rotated onto its corner, given in window coordinates
+func (r *Image) fillDiamond(centre pixel.Vec, radius float64, c color.Color) {
+	h := float64(r.img.Bounds().Dy())
+	cx, cy := centre.X, h-centre.Y
+	for y := int(cy - radius); y <= int(cy+radius); y++ {
+		for x := int(cx - radius); x <= int(cx+radius); x++ {
+			if math.Abs(float64(x)+0.5-cx)+math.Abs(float64(y)+0.5-cy) <= radius {
+				r.img.Set(x, y, c)
+			}
+		}
+	}
+}
+
+// fillCircle fills a circle given in window coordinates
+func (r *Image) fillCircle(centre pixel.Vec, radius float64, c color.Color) {
+	h := float64(r.img.Bounds().Dy())
+	cx, cy := centre.X, h-centre.Y
+	for y := int(cy - radius); y <= int(cy+radius); y++ {
+		for x := int(cx - radius); x <= int(cx+radius); x++ {
+			if math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy) <= radius {
+				r.img.Set(x, y, c)
+			}
+		}
+	}
+}
+
+// drawText draws lines of text scaled up from the theme's font, orig is the baseline of the first line in window
+// coordinates and is either the left edge of the lines or, if centred is true, their centre.
+func (r *Image) drawText(orig pixel.Vec, lines []string, textScale float64, lineHeight float64, centred bool) {
+	r.drawColouredText(orig, lines, textScale, lineHeight, centred, r.theme.Text)
+}
+
+// drawColouredText draws lines of text like drawText, in the colour provided rather than the theme's text colour
+func (r *Image) drawColouredText(orig pixel.Vec, lines []string, textScale float64, lineHeight float64, centred bool, c color.Color) {
+	k := textScale * r.scale
+	h := float64(r.img.Bounds().Dy())
+	face := r.theme.GetFace()
+	glyphHeight := face.Metrics().Height.Ceil()
+	glyphAscent := face.Metrics().Ascent.Ceil()
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		// Draw the line at its natural size and then scale each pixel of it up
+		mask := image.NewRGBA(image.Rect(0, 0, font.MeasureString(face, line).Ceil(), glyphHeight))
+		d := font.Drawer{
+			Dst:  mask,
+			Src:  image.NewUniform(c),
+			Face: face,
+			Dot:  fixed.P(0, glyphAscent),
+		}
+		d.DrawString(line)
+
+		left := orig.X
+		if centred {
+			left -= float64(mask.Bounds().Dx()) * k / 2
+		}
+		baseline := orig.Y - float64(i*glyphHeight)*lineHeight*k
+		for py := 0; py < glyphHeight; py++ {
+			for px := 0; px < mask.Bounds().Dx(); px++ {
+				if mask.RGBAAt(px, py).A == 0 {
+					continue
+				}
+				x0 := left + float64(px)*k
+				y0 := h - (baseline + float64(glyphAscent-py)*k)
+				rect := image.Rect(int(x0), int(y0), int(math.Ceil(x0+k)), int(math.Ceil(y0+k)))
+				draw.Draw(r.img, rect, image.NewUniform(c), image.ZP, draw.Over)
+			}
+		}
+	}
+}
+
+// fitScale returns the text scale for lines of text drawn with the theme's text size, reduced if needed so they fit
+// within maxWidth and maxHeight, which are in game layout pixels. A max of 0 doesn't limit that dimension.
+func (r *Image) fitScale(lines []string, textScale float64, lineHeight float64, maxWidth float64, maxHeight float64) float64 {
+	textScale *= r.theme.TextScale
+	face := r.theme.GetFace()
+	width := 0.0
+	for _, line := range lines {
+		width = math.Max(width, float64(font.MeasureString(face, line).Ceil()))
+	}
+	height := float64(len(lines)*face.Metrics().Height.Ceil()) * lineHeight
+	if maxWidth > 0 && width*textScale > maxWidth {
+		textScale = maxWidth / width
+	}
+	if maxHeight > 0 && height*textScale > maxHeight {
+		textScale = maxHeight / height
+	}
+	return textScale
+}
+
+// panelWidth returns the width of the text panel to the right of the game area, in game layout pixels
+func (r *Image) panelWidth() float64 {
+	return r.gameCFG.GetLayoutBounds().W() - r.gameCFG.GetLayoutMatrix().Project(r.gameCFG.GetGameAreaAsRec().Max).X
+}
+
+// panelOrig returns the origin of a line of the text panel at a fraction of the window height
+func (r *Image) panelOrig(height float64) pixel.Vec {
+	bounds := r.gameCFG.GetLayoutBounds()
+	areaRight := r.gameCFG.GetLayoutMatrix().Project(r.gameCFG.GetGameAreaAsRec().Max).X
+	x := bounds.W() - (bounds.W()-areaRight)/2
+	return pixel.V(x, bounds.H()*height).Scaled(r.scale)
+}
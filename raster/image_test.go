@@ -0,0 +1,121 @@
+package raster
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+)
+
+var update = flag.Bool("update", false, "save the frames drawn by the golden image tests to testdata")
+
+func TestGoldenImages(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	grid := gameCFG.GetGridMatrix()
+	// The settings file doesn't exist, so the default key bindings are shown
+	set := settings.NewSettings("raster_test_settings.csv")
+	ctrl := controls.NewControls(&set)
+	s := snake.NewSnakeFromState(gameCFG, snake.State{
+		HeadPos:   pixel.V(12, 14),
+		TailPos:   pixel.V(6, 10),
+		Points:    []pixel.Vec{pixel.V(12, 10)},
+		Length:    11,
+		Speed:     1,
+		Direction: snake.UP.GetVec(),
+	})
+	bs := []berries.Berry{
+		{Pos: grid.Project(pixel.V(4, 10)), Kind: berries.Normal},
+		{Pos: grid.Project(pixel.V(8, 10)), Kind: berries.Golden},
+		{Pos: grid.Project(pixel.V(12, 10)), Kind: berries.Poison},
+		{Pos: grid.Project(pixel.V(16, 10)), Kind: berries.SlowDown},
+		{Pos: grid.Project(pixel.V(8, 6)), Kind: berries.Golden, Lifetime: 10 * time.Second, TimeLeft: 5 * time.Second},
+	}
+	tests := []struct {
+		name string
+		draw func(r *Image)
+	}{
+		{"border", func(r *Image) {
+			r.DrawBackground(&gameCFG)
+		}},
+		{"snake", func(r *Image) {
+			r.DrawBackground(&gameCFG)
+			r.DrawSnake(&gameCFG, &s)
+		}},
+		{"berries", func(r *Image) {
+			r.DrawBackground(&gameCFG)
+			r.DrawBerries(&gameCFG, bs)
+		}},
+		{"score_panel", func(r *Image) {
+			r.DrawTitle()
+			r.DrawScore(1234)
+			r.DrawActiveEffects([]snake.ActiveEffect{{Effect: snake.Shield, TicksLeft: 12}})
+			r.DrawControls(&ctrl)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := theme.Classic
+			r := NewImage(&gameCFG, 1)
+			r.SetTheme(&th)
+			r.Clear()
+			tt.draw(r)
+			golden := filepath.Join("testdata", tt.name+".png")
+			if *update {
+				if err := r.SavePNG(golden); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := loadPNG(golden)
+			if err != nil {
+				t.Fatalf("could not load the golden image, run the tests with -update to create it: %v", err)
+			}
+			if n := countDifferent(r.GetImage(), want); n > 0 {
+				got := filepath.Join(t.TempDir(), tt.name+".png")
+				r.SavePNG(got)
+				t.Errorf("%d pixels are different from %s, the frame drawn was saved to %s", n, golden, got)
+			}
+		})
+	}
+}
+
+// loadPNG loads a golden image
+func loadPNG(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// countDifferent returns the number of pixels which are different in the two images, every pixel is different if
+// they aren't the same size
+func countDifferent(got image.Image, want image.Image) int {
+	bounds := got.Bounds()
+	if bounds != want.Bounds() {
+		return bounds.Dx() * bounds.Dy()
+	}
+	n := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, a1 := got.At(x, y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,245 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/drawing"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/gametext"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/scores"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/sprites"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// Pixel is a Renderer which draws the game in a pixel window using OpenGL
+type Pixel struct {
+	win          *pixelgl.Window
+	gameCFG      *game.Config
+	ctrl         *controls.Type
+	theme        theme.Type
+	textStruct   gametext.Type
+	controlsText string
+	imdWindow    *imdraw.IMDraw
+	imdArea      *imdraw.IMDraw
+	imdGame      *imdraw.IMDraw
+	imdBerry     *imdraw.IMDraw
+	imdAssist    *imdraw.IMDraw
+	imdEffects   *imdraw.IMDraw
+	imdPowerUps  *imdraw.IMDraw
+	imdWalls     *imdraw.IMDraw
+	imdPortals   *imdraw.IMDraw
+	imdHazards   *imdraw.IMDraw
+	imdRivals    *imdraw.IMDraw
+	imdZones     *imdraw.IMDraw
+	snakeSprites *snakeSprites
+}
+
+// NewPixel returns a renderer which draws on the window provided
+func NewPixel(win *pixelgl.Window, gameCFG *game.Config, ctrl *controls.Type, th *theme.Type) *Pixel {
+	r := new(Pixel)
+	r.win = win
+	r.gameCFG = gameCFG
+	r.ctrl = ctrl
+	r.SetTheme(th)
+	// Create the Window Background Shape
+	r.imdWindow = imdraw.New(nil)
+	// Create the Game Background Shape
+	r.imdArea = imdraw.New(nil)
+	// Create the Game Contents Shape
+	r.imdGame = imdraw.New(nil)
+	// Create a berry Contents Shape
+	r.imdBerry = imdraw.New(nil)
+	// Create the assist highlight Shape
+	r.imdAssist = imdraw.New(nil)
+	// Create the effects Shape
+	r.imdEffects = imdraw.New(nil)
+	// Create the power-ups Shape
+	r.imdPowerUps = imdraw.New(nil)
+	// Create the closing walls Shape
+	r.imdWalls = imdraw.New(nil)
+	// Create the portals Shape
+	r.imdPortals = imdraw.New(nil)
+	// Create the hazards Shape
+	r.imdHazards = imdraw.New(nil)
+	r.imdRivals = imdraw.New(nil)
+	// Create the berry zones Shape
+	r.imdZones = imdraw.New(nil)
+	return r
+}
+
+// Layout re-lays out the text, it should be called whenever the game config is resized
+func (r *Pixel) Layout() {
+	r.textStruct.Layout(r.gameCFG)
+}
+
+// SetTheme changes the colours and shapes used to draw the game, the text is regenerated if the theme changes
+func (r *Pixel) SetTheme(th *theme.Type) {
+	if *th == r.theme {
+		return
+	}
+	r.theme = *th
+	r.textStruct = gametext.NewGameText(*r.gameCFG, r.ctrl, th)
+	r.controlsText = strings.Join(r.ctrl.GetControlsText(), "")
+	// Fall back to drawing the snake with shapes if the theme has no sprite sheet or it can't be loaded
+	r.snakeSprites = nil
+	if th.Sprites != "" {
+		if sheet, err := sprites.Load(th.Sprites); err == nil {
+			r.snakeSprites = newSnakeSprites(sheet)
+		}
+	}
+}
+
+// Clear starts a new frame
+func (r *Pixel) Clear() {
+	r.win.Clear(r.theme.Letterbox)
+	drawWindowBackground(r.win, r.imdWindow, r.gameCFG, &r.theme)
+}
+
+// DrawBackground draws the game area border
+func (r *Pixel) DrawBackground(gameCFG *game.Config) {
+	drawGameBackground(r.win, r.imdArea, gameCFG, &r.theme)
+}
+
+// DrawEffects draws the effects playing in the game area
+func (r *Pixel) DrawEffects(gameCFG *game.Config, fx *drawing.Effects) {
+	drawEffects(r.win, r.imdEffects, gameCFG, fx)
+	for _, popUp := range fx.GetPopUps() {
+		r.textStruct.DrawPopUpText(r.win, gameCFG, popUp.Pos, popUp.Text, popUp.Colour)
+	}
+}
+
+// DrawWalls fills in the part of the game area outside the arena and the level's walls
+func (r *Pixel) DrawWalls(gameCFG *game.Config, arena pixel.Rect, walls []pixel.Vec) {
+	drawWalls(r.win, r.imdWalls, gameCFG, arena, walls, &r.theme)
+}
+
+// DrawPortals draws the portals
+func (r *Pixel) DrawPortals(gameCFG *game.Config, portals []pixel.Vec) {
+	drawPortals(r.win, r.imdPortals, gameCFG, portals, &r.theme)
+}
+
+// DrawSnake draws the snake, using the theme's sprites if it has them
+func (r *Pixel) DrawSnake(gameCFG *game.Config, s *snake.Type) {
+	if r.snakeSprites != nil {
+		r.snakeSprites.Draw(r.win, gameCFG, s)
+		return
+	}
+	drawSnake(r.win, r.imdGame, gameCFG, s, &r.theme)
+}
+
+// DrawRivals draws the rival snakes, always with shapes since the sprites are the player's
+func (r *Pixel) DrawRivals(gameCFG *game.Config, ss []*snake.Type) {
+	drawRivals(r.win, r.imdRivals, gameCFG, ss, &r.theme)
+}
+
+// DrawBerries draws the berries
+func (r *Pixel) DrawBerries(gameCFG *game.Config, bs []berries.Berry) {
+	drawBerries(r.win, r.imdBerry, gameCFG, bs, &r.theme)
+}
+
+// DrawPowerUps draws the power-ups
+func (r *Pixel) DrawPowerUps(gameCFG *game.Config, ps []powerups.PowerUp) {
+	drawPowerUps(r.win, r.imdPowerUps, gameCFG, ps, &r.theme)
+}
+
+// DrawHazards draws the hazards
+func (r *Pixel) DrawHazards(gameCFG *game.Config, hs []hazards.Hazard) {
+	drawHazards(r.win, r.imdHazards, gameCFG, hs, &r.theme)
+}
+
+// DrawZones marks the berry zones
+func (r *Pixel) DrawZones(gameCFG *game.Config, zones []pixel.Vec) {
+	drawZones(r.win, r.imdZones, gameCFG, zones, &r.theme)
+}
+
+// DrawAssist highlights the squares the snake can safely move into
+func (r *Pixel) DrawAssist(gameCFG *game.Config, cells []pixel.Vec) {
+	drawAssist(r.win, r.imdAssist, gameCFG, cells, &r.theme)
+}
+
+// DrawTitle draws the title panel
+func (r *Pixel) DrawTitle() {
+	r.textStruct.DrawTitleText(r.win)
+}
+
+// DrawScore draws the score panel
+func (r *Pixel) DrawScore(score int) {
+	r.textStruct.DrawScoreText(r.win, score)
+}
+
+// DrawTimer draws the mode and its timer above the game area
+func (r *Pixel) DrawTimer(gameCFG *game.Config, line string) {
+	r.textStruct.DrawTimerText(r.win, gameCFG, line)
+}
+
+// DrawActiveEffects draws the snake's effects under the score
+func (r *Pixel) DrawActiveEffects(active []snake.ActiveEffect) {
+	lines := []string{}
+	for _, a := range active {
+		lines = append(lines, a.String())
+	}
+	r.textStruct.DrawActiveEffectsText(r.win, r.gameCFG, lines)
+}
+
+// DrawControls draws the controls panel, regenerating the text if the bindings have changed
+func (r *Pixel) DrawControls(ctrl *controls.Type) {
+	if text := strings.Join(ctrl.GetControlsText(), ""); text != r.controlsText {
+		r.controlsText = text
+		r.textStruct.UpdateControlsText(ctrl)
+		r.textStruct.Layout(r.gameCFG)
+	}
+	r.textStruct.DrawControlsText(r.win)
+}
+
+// DrawStartGame draws the start game message
+func (r *Pixel) DrawStartGame() {
+	r.textStruct.DrawStartGameText(r.win)
+}
+
+// DrawPaused draws the paused message
+func (r *Pixel) DrawPaused() {
+	r.textStruct.DrawPausedText(r.win)
+}
+
+// DrawGameOver draws the game over message
+func (r *Pixel) DrawGameOver(gameCFG *game.Config, name string, highScore bool) {
+	r.textStruct.DrawGameOverText(r.win, gameCFG, name, highScore)
+}
+
+// DrawScoresList draws the high scores table
+func (r *Pixel) DrawScoresList(gameCFG *game.Config, scoresTable *scores.Type) {
+	r.textStruct.DrawScoresListText(r.win, gameCFG, scoresTable)
+}
+
+// DrawRebind draws the controls rebinding screen
+func (r *Pixel) DrawRebind(gameCFG *game.Config, ctrl *controls.Type, selected int, waiting bool) {
+	r.textStruct.DrawRebindText(r.win, gameCFG, ctrl, selected, waiting)
+}
+
+// DrawOptions draws the options screen
+func (r *Pixel) DrawOptions(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	r.textStruct.DrawOptionsText(r.win, gameCFG, ctrl, names, values, selected)
+}
+
+// DrawLevels draws the level select screen
+func (r *Pixel) DrawLevels(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	r.textStruct.DrawLevelsText(r.win, gameCFG, ctrl, names, values, selected)
+}
+
+// DrawEditor draws the level editor's panel
+func (r *Pixel) DrawEditor(gameCFG *game.Config, ctrl *controls.Type, tools []string, selected int, status string) {
+	r.textStruct.DrawEditorText(r.win, gameCFG, ctrl, tools, selected, status)
+}
+
+// Update shows the frame in the window
+func (r *Pixel) Update() {
+	r.win.Update()
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/drawing"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/scores"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+)
+
+// Renderer is implemented by each of the frontends the game can be drawn with. A frame is started with
+// Clear, the game elements and text panels are drawn, and then the frame is shown with Update.
+type Renderer interface {
+	// SetTheme changes the colours and shapes used to draw the game
+	SetTheme(th *theme.Type)
+	// Clear starts a new frame
+	Clear()
+	// DrawBackground draws the game area border
+	DrawBackground(gameCFG *game.Config)
+	// DrawEffects draws the effects playing in the game area, under the snake and berries
+	DrawEffects(gameCFG *game.Config, fx *drawing.Effects)
+	// DrawWalls fills in the part of the game area outside the arena, where the walls have closed in, and the
+	// level's walls, which are grid squares in the game area coordinate plane
+	DrawWalls(gameCFG *game.Config, arena pixel.Rect, walls []pixel.Vec)
+	// DrawPortals draws the portals, which are grid squares in the game area coordinate plane
+	DrawPortals(gameCFG *game.Config, portals []pixel.Vec)
+	// DrawSnake draws the snake
+	DrawSnake(gameCFG *game.Config, s *snake.Type)
+	// DrawRivals draws the rival snakes on the board
+	DrawRivals(gameCFG *game.Config, ss []*snake.Type)
+	// DrawBerries draws the berries on the board
+	DrawBerries(gameCFG *game.Config, bs []berries.Berry)
+	// DrawPowerUps draws the power-ups on the board
+	DrawPowerUps(gameCFG *game.Config, ps []powerups.PowerUp)
+	// DrawHazards draws the hazards on the board
+	DrawHazards(gameCFG *game.Config, hs []hazards.Hazard)
+	// DrawZones marks the squares, in the game area coordinate plane, which are in a level's berry zones
+	DrawZones(gameCFG *game.Config, zones []pixel.Vec)
+	// DrawAssist highlights the squares, in the game area coordinate plane, which the snake can safely move into
+	DrawAssist(gameCFG *game.Config, cells []pixel.Vec)
+	// DrawTitle draws the title panel
+	DrawTitle()
+	// DrawScore draws the score panel
+	DrawScore(score int)
+	// DrawTimer draws a line of text above the game area, for the game mode and its timer
+	DrawTimer(gameCFG *game.Config, line string)
+	// DrawActiveEffects draws the effects the snake has and the steps each has left, under the score
+	DrawActiveEffects(active []snake.ActiveEffect)
+	// DrawControls draws the controls panel for the current bindings
+	DrawControls(ctrl *controls.Type)
+	// DrawStartGame draws the start game message
+	DrawStartGame()
+	// DrawPaused draws the paused message
+	DrawPaused()
+	// DrawGameOver draws the game over message, prompting for a name if a high score was achieved
+	DrawGameOver(gameCFG *game.Config, name string, highScore bool)
+	// DrawScoresList draws the high scores table
+	DrawScoresList(gameCFG *game.Config, scoresTable *scores.Type)
+	// DrawRebind draws the controls rebinding screen
+	DrawRebind(gameCFG *game.Config, ctrl *controls.Type, selected int, waiting bool)
+	// DrawOptions draws the options screen, with the option at index selected highlighted
+	DrawOptions(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int)
+	// DrawLevels draws the level select screen, with the level at index selected highlighted
+	DrawLevels(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int)
+	// DrawEditor draws the level editor's panel in place of the controls, listing the tools with the one at index
+	// selected highlighted, how to use the editor and a status line
+	DrawEditor(gameCFG *game.Config, ctrl *controls.Type, tools []string, selected int, status string)
+	// Update shows the frame
+	Update()
+}
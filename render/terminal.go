@@ -0,0 +1,379 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/controls"
+	"github.com/benjmarshall/gopixelsnake/drawing"
+	"github.com/benjmarshall/gopixelsnake/editor"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/scores"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+)
+
+// terminalPanelWidth is the number of columns used for the text panel to the right of the game area
+const terminalPanelWidth = 24
+
+// cell is a single character on the terminal. Cells in the game area show two grid squares each
+// by drawing the upper half block in the foreground colour over the background colour.
+type cell struct {
+	ch rune
+	fg color.RGBA
+	bg color.RGBA
+}
+
+// Terminal is a Renderer which draws the game on an ANSI terminal using 24 bit colour escape codes, so the
+// game can be played over SSH or anywhere OpenGL is not available. Each grid square is half a character high.
+type Terminal struct {
+	out      *bufio.Writer
+	gameCFG  *game.Config
+	theme    *theme.Type
+	gridCols int
+	gridRows int
+	cells    [][]cell
+}
+
+// NewTerminal returns a renderer which writes frames to out, which should be a terminal
+func NewTerminal(out io.Writer, gameCFG *game.Config) *Terminal {
+	r := new(Terminal)
+	r.out = bufio.NewWriter(out)
+	r.gameCFG = gameCFG
+	r.theme = &theme.Classic
+	x, y := gameCFG.GetGameAreaDims()
+	r.gridCols = int(x / gameCFG.GetGridSize())
+	r.gridRows = int(y / gameCFG.GetGridSize())
+	rows := (r.gridRows+1)/2 + 2
+	cols := r.gridCols + 3 + terminalPanelWidth
+	r.cells = make([][]cell, rows)
+	for i := range r.cells {
+		r.cells[i] = make([]cell, cols)
+	}
+	// Switch to the alternate screen and hide the cursor
+	fmt.Fprint(r.out, "\x1b[?1049h\x1b[?25l\x1b[2J")
+	return r
+}
+
+// Close restores the terminal screen and cursor
+func (r *Terminal) Close() {
+	fmt.Fprint(r.out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	r.out.Flush()
+}
+
+// SetTheme changes the colours used to draw the game, the terminal can only draw squares so shapes and outlines are ignored
+func (r *Terminal) SetTheme(th *theme.Type) {
+	r.theme = th
+}
+
+// Clear starts a new frame
+func (r *Terminal) Clear() {
+	for i := range r.cells {
+		for j := range r.cells[i] {
+			r.cells[i][j] = cell{ch: ' ', fg: r.theme.Text, bg: r.theme.Background}
+		}
+	}
+}
+
+// DrawBackground draws the game area border
+func (r *Terminal) DrawBackground(gameCFG *game.Config) {
+	last := len(r.cells) - 1
+	for col := 0; col < r.gridCols+2; col++ {
+		r.cells[0][col].bg = r.theme.Border
+		r.cells[last][col].bg = r.theme.Border
+	}
+	for row := 1; row < last; row++ {
+		r.cells[row][0].bg = r.theme.Border
+		r.cells[row][r.gridCols+1].bg = r.theme.Border
+		for col := 1; col <= r.gridCols; col++ {
+			r.cells[row][col] = cell{ch: '▀', fg: r.theme.Background, bg: r.theme.Background}
+		}
+	}
+}
+
+// DrawEffects draws the trail and particles in the squares they are in, blended into the background as they fade.
+// The terminal can't draw the flash or pop-ups over the game.
+func (r *Terminal) DrawEffects(gameCFG *game.Config, fx *drawing.Effects) {
+	for _, p := range fx.Parts(gameCFG) {
+		if p.Size.X > gameCFG.GetGridSize() {
+			continue
+		}
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(p.Pos), blend(p.Colour, r.theme.Background))
+	}
+}
+
+// DrawWalls fills in the grid squares outside the arena and the level's walls
+func (r *Terminal) DrawWalls(gameCFG *game.Config, arena pixel.Rect, walls []pixel.Vec) {
+	for _, wall := range walls {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(wall), r.theme.Border)
+	}
+	for x := 0; x < r.gridCols; x++ {
+		for y := 0; y < r.gridRows; y++ {
+			square := pixel.V(float64(x), float64(y))
+			if !arena.Contains(gameCFG.GetGridMatrix().Project(square)) {
+				r.setSquare(square, r.theme.Border)
+			}
+		}
+	}
+}
+
+// DrawPortals draws the portals, the terminal can only tell them apart by colour
+func (r *Terminal) DrawPortals(gameCFG *game.Config, portals []pixel.Vec) {
+	for _, portal := range portals {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(portal), r.theme.Portal)
+	}
+}
+
+// DrawSnake draws each grid square of the snake, the terminal can only draw squares so corners aren't rounded
+func (r *Terminal) DrawSnake(gameCFG *game.Config, s *snake.Type) {
+	for _, seg := range s.Segments() {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(seg.Pos), drawing.SegmentColour(seg, r.theme))
+	}
+}
+
+// DrawRivals draws each grid square of the rival snakes in the rival colour
+func (r *Terminal) DrawRivals(gameCFG *game.Config, ss []*snake.Type) {
+	for _, s := range ss {
+		for _, seg := range s.Segments() {
+			r.setSquare(gameCFG.GetGridMatrix().Unproject(seg.Pos), r.theme.Rival)
+		}
+	}
+}
+
+// DrawBerries draws the berries, the terminal can only tell the kinds apart by colour
+func (r *Terminal) DrawBerries(gameCFG *game.Config, bs []berries.Berry) {
+	for _, b := range bs {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(b.Pos), drawing.BerryColour(b.Kind, r.theme))
+	}
+}
+
+// DrawPowerUps draws the power-ups, the terminal can only tell them apart from berries by colour
+func (r *Terminal) DrawPowerUps(gameCFG *game.Config, ps []powerups.PowerUp) {
+	for _, p := range ps {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(p.Pos), r.theme.PowerUp)
+	}
+}
+
+// DrawHazards draws the hazards, the terminal can only tell them apart from the snake and berries by colour
+func (r *Terminal) DrawHazards(gameCFG *game.Config, hs []hazards.Hazard) {
+	for _, h := range hs {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(h.Pos), r.theme.Hazard)
+	}
+}
+
+// DrawZones marks the berry zones in the berry colour, the terminal can only tell them apart from berries by colour
+func (r *Terminal) DrawZones(gameCFG *game.Config, zones []pixel.Vec) {
+	for _, zone := range zones {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(zone), r.theme.Berry)
+	}
+}
+
+// DrawAssist highlights the squares the snake can safely move into with a colour half way between the text and background
+func (r *Terminal) DrawAssist(gameCFG *game.Config, cells []pixel.Vec) {
+	c := color.RGBA{
+		uint8((int(r.theme.Text.R) + int(r.theme.Background.R)) / 2),
+		uint8((int(r.theme.Text.G) + int(r.theme.Background.G)) / 2),
+		uint8((int(r.theme.Text.B) + int(r.theme.Background.B)) / 2),
+		255,
+	}
+	for _, cell := range cells {
+		r.setSquare(gameCFG.GetGridMatrix().Unproject(cell), c)
+	}
+}
+
+// DrawTitle draws the title panel
+func (r *Terminal) DrawTitle() {
+	r.panelText(1, "Go Pixel Snake")
+}
+
+// DrawScore draws the score panel
+func (r *Terminal) DrawScore(score int) {
+	r.panelText(3, strconv.Itoa(score))
+}
+
+// DrawTimer draws the mode and its timer over the top border of the game area
+func (r *Terminal) DrawTimer(gameCFG *game.Config, line string) {
+	r.text(0, 1+(r.gridCols-len(line))/2, line)
+}
+
+// DrawActiveEffects draws the snake's effects under the controls, as there is no room under the score
+func (r *Terminal) DrawActiveEffects(active []snake.ActiveEffect) {
+	for i, a := range active {
+		r.panelText(14+i, a.String())
+	}
+}
+
+// DrawControls draws the controls panel. The terminal uses fixed keys, so the bindings are not shown.
+func (r *Terminal) DrawControls(ctrl *controls.Type) {
+	lines := []string{
+		"Control Snake",
+		"Arrow Keys",
+		"",
+		"Pause  P",
+		"High Scores  S",
+		"Options  O",
+		"Levels  L",
+		"Exit  X",
+	}
+	for i, line := range lines {
+		r.panelText(6+i, line)
+	}
+}
+
+// DrawStartGame draws the start game message
+func (r *Terminal) DrawStartGame() {
+	r.centredText([]string{
+		"Hit an arrow key",
+		"to start a new game!",
+	})
+}
+
+// DrawPaused draws the paused message
+func (r *Terminal) DrawPaused() {
+	r.centredText([]string{"Paused"})
+}
+
+// DrawGameOver draws the game over message
+func (r *Terminal) DrawGameOver(gameCFG *game.Config, name string, highScore bool) {
+	lines := []string{"Game Over!"}
+	if highScore {
+		if name == "" {
+			name = "___"
+		}
+		lines = append(lines, "You have a new high score.", "Please type your name and then", "Press Enter to continue...", name)
+	} else {
+		lines = append(lines, "Press Enter to continue...")
+	}
+	r.centredText(lines)
+}
+
+// DrawScoresList draws the high scores table
+func (r *Terminal) DrawScoresList(gameCFG *game.Config, scoresTable *scores.Type) {
+	for i, line := range scoresTable.GetTopScores() {
+		r.text(2+i, 3, fmt.Sprintf("%-6s%-8s%s", line[0], line[1], line[2]))
+	}
+}
+
+// DrawRebind draws the controls rebinding screen, keys can only be rebound in the pixel window
+func (r *Terminal) DrawRebind(gameCFG *game.Config, ctrl *controls.Type, selected int, waiting bool) {
+	r.centredText([]string{
+		"Controls can only be",
+		"changed in the window",
+	})
+}
+
+// DrawOptions draws the options screen
+func (r *Terminal) DrawOptions(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	r.menuText(names, values, selected, "Enter to change, O to go back")
+}
+
+// DrawLevels draws the level select screen
+func (r *Terminal) DrawLevels(gameCFG *game.Config, ctrl *controls.Type, names []string, values []string, selected int) {
+	r.menuText(names, values, selected, "Enter to play, L to go back")
+}
+
+// DrawEditor draws the level editor's panel, the editor needs a mouse so it is only opened in the window
+func (r *Terminal) DrawEditor(gameCFG *game.Config, ctrl *controls.Type, tools []string, selected int, status string) {
+	for i, line := range editor.PanelLines(ctrl, tools, selected, status) {
+		r.panelText(6+i, line)
+	}
+}
+
+// menuText writes a menu with a row for each name and value, highlighting the row at index selected, followed by
+// a line explaining how to use it
+func (r *Terminal) menuText(names []string, values []string, selected int, help string) {
+	for i := range names {
+		marker := "  "
+		if i == selected {
+			marker = "> "
+		}
+		r.text(2+i, 3, fmt.Sprintf("%s%-12s%s", marker, names[i], values[i]))
+	}
+	r.text(3+len(names), 3, help)
+}
+
+// Update writes the frame to the terminal
+func (r *Terminal) Update() {
+	fmt.Fprint(r.out, "\x1b[H")
+	for _, row := range r.cells {
+		var fg, bg color.RGBA
+		for j, c := range row {
+			if j == 0 || c.fg != fg {
+				fg = c.fg
+				fmt.Fprintf(r.out, "\x1b[38;2;%d;%d;%dm", fg.R, fg.G, fg.B)
+			}
+			if j == 0 || c.bg != bg {
+				bg = c.bg
+				fmt.Fprintf(r.out, "\x1b[48;2;%d;%d;%dm", bg.R, bg.G, bg.B)
+			}
+			r.out.WriteRune(c.ch)
+		}
+		fmt.Fprint(r.out, "\x1b[0m\r\n")
+	}
+	r.out.Flush()
+}
+
+// blend returns the colour, which has its alpha multiplied in, drawn over a solid background colour
+func blend(c color.RGBA, bg color.RGBA) color.RGBA {
+	keep := 255 - int(c.A)
+	return color.RGBA{
+		uint8(int(c.R) + int(bg.R)*keep/255),
+		uint8(int(c.G) + int(bg.G)*keep/255),
+		uint8(int(c.B) + int(bg.B)*keep/255),
+		255,
+	}
+}
+
+// setSquare colours a single square of the game grid
+func (r *Terminal) setSquare(pos pixel.Vec, c color.RGBA) {
+	x := int(pos.X + 0.5)
+	y := int(pos.Y + 0.5)
+	if x < 0 || x >= r.gridCols || y < 0 || y >= r.gridRows {
+		return
+	}
+	// Grid rows count up from the bottom, terminal rows count down from the top
+	fromTop := r.gridRows - 1 - y
+	target := &r.cells[1+fromTop/2][1+x]
+	if fromTop%2 == 0 {
+		target.fg = c
+	} else {
+		target.bg = c
+	}
+}
+
+// text writes a line of text starting at the row and column provided
+func (r *Terminal) text(row int, col int, line string) {
+	if row < 0 || row >= len(r.cells) {
+		return
+	}
+	for _, ch := range line {
+		if col >= len(r.cells[row]) {
+			return
+		}
+		if col >= 0 {
+			r.cells[row][col] = cell{ch: ch, fg: r.theme.Text, bg: r.theme.Background}
+		}
+		col++
+	}
+}
+
+// panelText writes a line of text centred in the panel to the right of the game area
+func (r *Terminal) panelText(row int, line string) {
+	col := r.gridCols + 3 + (terminalPanelWidth-len(line))/2
+	r.text(row, col, line)
+}
+
+// centredText writes lines of text centred in the game area
+func (r *Terminal) centredText(lines []string) {
+	top := len(r.cells)/2 - len(lines)/2
+	for i, line := range lines {
+		r.text(top+i, 1+(r.gridCols-len(line))/2, line)
+	}
+}
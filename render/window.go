@@ -0,0 +1,262 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/drawing"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/benjmarshall/gopixelsnake/sprites"
+	"github.com/benjmarshall/gopixelsnake/theme"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// drawWindowBackground fills the part of the window used by the game layout, leaving the rest of the window letterboxed
+func drawWindowBackground(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, th *theme.Type) {
+	imd.Clear()
+	imd.Color = th.Background
+	bounds := gameCFG.GetLayoutBounds()
+	imd.Push(gameCFG.GetViewMatrix().Project(bounds.Min), gameCFG.GetViewMatrix().Project(bounds.Max))
+	imd.Rectangle(0)
+	imd.Draw(win)
+}
+
+// drawGameBackground draws the game area border
+func drawGameBackground(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, th *theme.Type) {
+	imd.Clear()
+	imd.Color = th.Border
+	min, max := gameCFG.GetGameAreaAsVecs()
+	min = gameCFG.GetWindowMatrix().Project(min)
+	max = gameCFG.GetWindowMatrix().Project(max)
+	border := gameCFG.GetBorderWeight() * gameCFG.GetViewScale()
+	vec := pixel.V(border/2, border/2)
+	min = min.Sub(vec)
+	max = max.Add(vec)
+	imd.Push(min, max)
+	imd.Rectangle(border)
+	imd.Draw(win)
+}
+
+// snakeSprites draws the snake using the pieces of a sprite sheet
+type snakeSprites struct {
+	batch  *pixel.Batch
+	pieces map[snake.Piece]*pixel.Sprite
+	size   float64
+}
+
+// newSnakeSprites creates the sprites for each snake piece on the sheet
+func newSnakeSprites(sheet *sprites.Sheet) *snakeSprites {
+	sp := new(snakeSprites)
+	pic := pixel.PictureDataFromImage(sheet.GetImage())
+	sp.batch = pixel.NewBatch(&pixel.TrianglesData{}, pic)
+	sp.pieces = map[snake.Piece]*pixel.Sprite{}
+	for _, p := range []snake.Piece{snake.Head, snake.Straight, snake.Corner, snake.Tail} {
+		// Pictures have y pointing up, but the sheet is a single row so only x needs converting
+		frame := sheet.Frame(p)
+		sp.pieces[p] = pixel.NewSprite(pic, pixel.R(float64(frame.Min.X), pic.Bounds().Min.Y, float64(frame.Max.X), pic.Bounds().Max.Y))
+		sp.size = float64(frame.Dx())
+	}
+	return sp
+}
+
+// Draw draws each grid square of the snake with its sprite, rotated to face the way the piece does. The head and
+// tail slide smoothly between steps, so a straight piece is drawn under the tail to fill the gap it leaves.
+func (sp *snakeSprites) Draw(win *pixelgl.Window, gameCFG *game.Config, s *snake.Type) {
+	m := gameCFG.GetWindowMatrix()
+	scale := gameCFG.GetGridSize() * gameCFG.GetViewScale() / sp.size
+	sp.batch.Clear()
+	segments := s.InterpolatedSegments(s.GetTickFraction())
+	// Draw from the tail to the head, so the head is drawn over the body as it slides
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := segments[i]
+		angle := sprites.Angle(seg)
+		if seg.Piece == snake.Tail && seg.Slide != pixel.ZV {
+			sp.pieces[snake.Straight].Draw(sp.batch, pixel.IM.Scaled(pixel.ZV, scale).Rotated(pixel.ZV, angle).Moved(m.Project(seg.Pos)))
+		}
+		pos := m.Project(seg.Pos).Add(seg.Slide.Scaled(gameCFG.GetViewScale()))
+		sp.pieces[seg.Piece].Draw(sp.batch, pixel.IM.Scaled(pixel.ZV, scale).Rotated(pixel.ZV, angle).Moved(pos))
+	}
+	sp.batch.Draw(win)
+}
+
+// drawSnake draws each grid square of the snake in the theme's style, with rounded corners where the snake
+// turns and eyes on its head, moving the head and tail smoothly between steps. If the theme has outlines the
+// snake is outlined so it doesn't rely on colour.
+func drawSnake(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, s *snake.Type, th *theme.Type) {
+	m := gameCFG.GetWindowMatrix()
+	scale := gameCFG.GetViewScale()
+	size := gameCFG.GetGridSize() * scale
+	segments := s.InterpolatedSegments(s.GetTickFraction())
+	imd.Clear()
+	w := 0.0
+	if th.Outlines {
+		// Draw the whole snake in the outline colour and then draw the pieces inset over it
+		for _, seg := range segments {
+			for _, part := range drawing.SegmentParts(seg, m.Project(seg.Pos), size, scale, 0, th) {
+				drawPart(imd, part, th.Text)
+			}
+		}
+		w = drawing.OutlineWeight * scale
+	}
+	// Draw from the tail to the head, so the head is drawn over the body as it slides
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := segments[i]
+		for _, part := range drawing.SegmentParts(seg, m.Project(seg.Pos), size, scale, w, th) {
+			drawPart(imd, part, drawing.SegmentColour(seg, th))
+		}
+	}
+	for _, part := range drawing.EyeParts(segments[0], m.Project(segments[0].Pos), size, scale) {
+		drawPart(imd, part, th.Background)
+	}
+	imd.Draw(win)
+}
+
+// drawRivals draws the rival snakes the same way as the player's snake, all in the theme's rival colour
+func drawRivals(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, ss []*snake.Type, th *theme.Type) {
+	rt := drawing.RivalTheme(th)
+	for _, s := range ss {
+		drawSnake(win, imd, gameCFG, s, &rt)
+	}
+}
+
+// drawBerries draws each berry in the colour for its kind, with a mark so the kinds can be told apart without colour
+func drawBerries(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, bs []berries.Berry, th *theme.Type) {
+	scale := gameCFG.GetViewScale()
+	imd.Clear()
+	for _, b := range bs {
+		pos := gameCFG.GetWindowMatrix().Project(b.Pos)
+		size := drawing.BerrySize(b, gameCFG.GetGridSize()*scale)
+		drawOutlinedShape(imd, th.BerryShape, pos, size, drawing.BerryColour(b.Kind, th), th, drawing.OutlineWeight*scale)
+		for _, part := range drawing.BerryMarks(b.Kind, pos, size) {
+			drawPart(imd, part, th.Background)
+		}
+	}
+	imd.Draw(win)
+}
+
+// drawPowerUps draws each power-up as a square with a mark for its effect
+func drawPowerUps(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, ps []powerups.PowerUp, th *theme.Type) {
+	scale := gameCFG.GetViewScale()
+	imd.Clear()
+	for _, p := range ps {
+		pos := gameCFG.GetWindowMatrix().Project(p.Pos)
+		size := drawing.PowerUpSize(p, gameCFG.GetGridSize()*scale)
+		drawOutlinedShape(imd, theme.Square, pos, size, th.PowerUp, th, drawing.OutlineWeight*scale)
+		for _, part := range drawing.PowerUpMarks(p.Effect, pos, size) {
+			drawPart(imd, part, th.Background)
+		}
+	}
+	imd.Draw(win)
+}
+
+// drawWalls fills the part of the game area outside the arena, which is in the game area coordinate plane, with
+// the border colour so the player can see how far the walls have closed in. The level's walls, which are grid
+// squares in the game area coordinate plane, are filled in too.
+func drawWalls(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, arena pixel.Rect, walls []pixel.Vec, th *theme.Type) {
+	scale := gameCFG.GetViewScale()
+	imd.Clear()
+	parts := append(drawing.WallParts(gameCFG.GetGameAreaAsRec(), arena), drawing.SquareParts(walls, gameCFG.GetGridSize())...)
+	for _, part := range parts {
+		drawPart(imd, drawing.Part{Shape: part.Shape, Pos: gameCFG.GetWindowMatrix().Project(part.Pos), Size: part.Size.Scaled(scale)}, th.Border)
+	}
+	imd.Draw(win)
+}
+
+// drawPortals draws a ring in each of the grid squares with a portal, given in the game area coordinate plane
+func drawPortals(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, portals []pixel.Vec, th *theme.Type) {
+	scale := gameCFG.GetViewScale()
+	imd.Clear()
+	for _, portal := range portals {
+		ring := drawing.PortalParts(gameCFG.GetWindowMatrix().Project(portal), gameCFG.GetGridSize()*scale)
+		drawPart(imd, ring[0], th.Portal)
+		drawPart(imd, ring[1], th.Background)
+	}
+	imd.Draw(win)
+}
+
+// drawHazards draws each hazard in the shape for its kind, with its marks
+func drawHazards(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, hs []hazards.Hazard, th *theme.Type) {
+	scale := gameCFG.GetViewScale()
+	size := gameCFG.GetGridSize() * scale
+	imd.Clear()
+	for _, h := range hs {
+		pos := gameCFG.GetWindowMatrix().Project(h.Pos)
+		drawOutlinedShape(imd, drawing.HazardShape(h.Kind), pos, size, th.Hazard, th, drawing.OutlineWeight*scale)
+		for _, part := range drawing.HazardMarks(h.Kind, pos, size) {
+			drawPart(imd, part, th.Background)
+		}
+	}
+	imd.Draw(win)
+}
+
+// drawZones draws a dot in each of the grid squares in a berry zone, given in the game area coordinate plane
+func drawZones(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, zones []pixel.Vec, th *theme.Type) {
+	scale := gameCFG.GetViewScale()
+	imd.Clear()
+	for _, zone := range zones {
+		drawPart(imd, drawing.ZonePart(gameCFG.GetWindowMatrix().Project(zone), gameCFG.GetGridSize()*scale), th.Berry)
+	}
+	imd.Draw(win)
+}
+
+// drawAssist outlines the squares, given in the game area coordinate plane, which the snake can safely move into
+func drawAssist(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, cells []pixel.Vec, th *theme.Type) {
+	scale := gameCFG.GetViewScale()
+	half := gameCFG.GetGridSize() / 2 * scale
+	imd.Clear()
+	imd.Color = th.Text
+	for _, cell := range cells {
+		pos := gameCFG.GetWindowMatrix().Project(cell)
+		imd.Push(pos.Sub(pixel.V(half, half)), pos.Add(pixel.V(half, half)))
+		imd.Rectangle(scale)
+	}
+	imd.Draw(win)
+}
+
+// drawOutlinedShape adds a shape to the imdraw, with an outline of width w if the theme has outlines turned on
+func drawOutlinedShape(imd *imdraw.IMDraw, shape theme.Shape, pos pixel.Vec, size float64, col color.Color, th *theme.Type, w float64) {
+	if th.Outlines {
+		drawShape(imd, shape, pos, size, th.Text)
+		size -= 2 * w
+	}
+	drawShape(imd, shape, pos, size, col)
+}
+
+// drawShape adds a shape of the given size, centred on pos, to the imdraw
+func drawShape(imd *imdraw.IMDraw, shape theme.Shape, pos pixel.Vec, size float64, col color.Color) {
+	drawPart(imd, drawing.Part{Shape: shape, Pos: pos, Size: pixel.V(size, size)}, col)
+}
+
+// drawPart adds a part to the imdraw, round parts use the smaller of their width and height as their diameter
+func drawPart(imd *imdraw.IMDraw, part drawing.Part, col color.Color) {
+	imd.Color = col
+	half := part.Size.Scaled(0.5)
+	switch part.Shape {
+	case theme.Round:
+		imd.Push(part.Pos)
+		imd.Circle(math.Min(half.X, half.Y), 0)
+	case theme.Diamond:
+		imd.Push(part.Pos.Add(pixel.V(0, half.Y)), part.Pos.Add(pixel.V(half.X, 0)), part.Pos.Sub(pixel.V(0, half.Y)), part.Pos.Sub(pixel.V(half.X, 0)))
+		imd.Polygon(0)
+	default:
+		imd.Push(part.Pos.Sub(half), part.Pos.Add(half))
+		imd.Rectangle(0)
+	}
+}
+
+// drawEffects draws the trail, particles and flash
+func drawEffects(win *pixelgl.Window, imd *imdraw.IMDraw, gameCFG *game.Config, fx *drawing.Effects) {
+	m := gameCFG.GetWindowMatrix()
+	scale := gameCFG.GetViewScale()
+	imd.Clear()
+	for _, p := range fx.Parts(gameCFG) {
+		drawPart(imd, drawing.Part{Shape: p.Shape, Pos: m.Project(p.Pos), Size: p.Size.Scaled(scale)}, p.Colour)
+	}
+	imd.Draw(win)
+}
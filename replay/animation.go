@@ -0,0 +1,233 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/raster"
+	"github.com/benjmarshall/gopixelsnake/snake"
+)
+
+// clip is a recording sampled at a fixed frame rate. Consecutive samples showing the same tick are merged, so
+// each recorded frame is held for a whole number of samples.
+type clip struct {
+	recording *Type
+	gameCFG   *game.Config
+	scale     float64
+	indexes   []int
+	samples   []int
+}
+
+// maxFPS is the fastest frame rate animations can be exported at, APNG frame delays are written in milliseconds
+const maxFPS = 1000
+
+// newClip samples the recording at fps frames per second, to be drawn with the layout scaled by scale
+func (t *Type) newClip(gameCFG *game.Config, fps float64, scale float64) (clip, error) {
+	c := clip{recording: t, gameCFG: gameCFG, scale: scale}
+	if len(t.Frames) == 0 {
+		return c, errors.New("the recording has no frames")
+	}
+	if !(fps > 0 && fps <= maxFPS) || math.IsNaN(scale) || math.IsInf(scale, 0) || scale <= 0 {
+		return c, fmt.Errorf("the frame rate must be more than 0 and at most %d, and the scale must be positive", maxFPS)
+	}
+	duration := t.Duration()
+	for i := 0; float64(i)/fps < duration; i++ {
+		index := t.FrameIndexAt(float64(i) / fps)
+		if len(c.indexes) > 0 && c.indexes[len(c.indexes)-1] == index {
+			c.samples[len(c.samples)-1]++
+			continue
+		}
+		c.indexes = append(c.indexes, index)
+		c.samples = append(c.samples, 1)
+	}
+	return c, nil
+}
+
+// render draws the i'th image of the clip
+func (c *clip) render(i int) *image.RGBA {
+	frame := c.recording.Frames[c.indexes[i]]
+	s := snake.NewSnakeFromState(*c.gameCFG, frame.Snake)
+	r := raster.NewImage(c.gameCFG, c.scale)
+	r.Clear()
+	r.DrawBackground(c.gameCFG)
+	r.DrawSnake(c.gameCFG, &s)
+	r.DrawBerries(c.gameCFG, frame.GetBerries())
+	r.DrawTitle()
+	r.DrawScore(frame.Score)
+	return r.GetImage()
+}
+
+// WriteGIF renders the recording as an animated GIF at fps frames per second, with the layout scaled by scale
+func (t *Type) WriteGIF(w io.Writer, gameCFG *game.Config, fps float64, scale float64) error {
+	c, err := t.newClip(gameCFG, fps, scale)
+	if err != nil {
+		return err
+	}
+
+	// The game is drawn with a handful of flat colours, so put those in the palette first and fill the rest
+	// from Plan9 for anything else. Without dithering the game colours then come out exactly.
+	pal := color.Palette{}
+	seen := map[color.RGBA]bool{}
+	img := c.render(0)
+	for i := 0; i+3 < len(img.Pix) && len(pal) < 256; i += 4 {
+		col := color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+		if !seen[col] {
+			seen[col] = true
+			pal = append(pal, col)
+		}
+	}
+	for _, col := range palette.Plan9 {
+		if len(pal) >= 256 {
+			break
+		}
+		pal = append(pal, col)
+	}
+
+	anim := gif.GIF{}
+	elapsed := 0
+	shown := 0.0
+	for i := range c.indexes {
+		img := c.render(i)
+		paletted := image.NewPaletted(img.Bounds(), pal)
+		draw.Draw(paletted, img.Bounds(), img, image.ZP, draw.Src)
+		// GIF delays are in 100ths of a second, round the running total so the clip doesn't drift
+		shown += float64(c.samples[i]) / fps
+		delay := int(math.Floor(shown*100+0.5)) - elapsed
+		elapsed += delay
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, &anim)
+}
+
+// WriteAPNG renders the recording as an animated PNG at fps frames per second, with the layout scaled by scale
+func (t *Type) WriteAPNG(w io.Writer, gameCFG *game.Config, fps float64, scale float64) error {
+	c, err := t.newClip(gameCFG, fps, scale)
+	if err != nil {
+		return err
+	}
+
+	// An APNG is a normal PNG of the first frame, with extra chunks describing the animation and holding
+	// the image data of the other frames
+	if _, err := w.Write([]byte("\x89PNG\r\n\x1a\n")); err != nil {
+		return err
+	}
+	sequence := uint32(0)
+	elapsed := 0
+	shown := 0.0
+	for i := range c.indexes {
+		img := c.render(i)
+		buf := new(bytes.Buffer)
+		if err := png.Encode(buf, img); err != nil {
+			return err
+		}
+		chunks, err := readPNGChunks(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		data := []byte{}
+		for _, chunk := range chunks {
+			if chunk.name == "IHDR" && i == 0 {
+				if err := writePNGChunk(w, "IHDR", chunk.data); err != nil {
+					return err
+				}
+				actl := make([]byte, 8)
+				binary.BigEndian.PutUint32(actl[0:], uint32(len(c.indexes)))
+				binary.BigEndian.PutUint32(actl[4:], 0)
+				if err := writePNGChunk(w, "acTL", actl); err != nil {
+					return err
+				}
+			}
+			if chunk.name == "IDAT" {
+				data = append(data, chunk.data...)
+			}
+		}
+
+		bounds := img.Bounds()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+		// APNG delays are a fraction of a second, write them in milliseconds whatever the frame rate and round the
+		// running total so the clip doesn't drift. A frame held for longer than the largest delay is cut short.
+		shown += float64(c.samples[i]) / fps
+		delay := int(math.Floor(shown*1000+0.5)) - elapsed
+		if delay > math.MaxUint16 {
+			delay = math.MaxUint16
+		}
+		elapsed += delay
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		sequence++
+		if err := writePNGChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+
+		if i == 0 {
+			err = writePNGChunk(w, "IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, sequence)
+			sequence++
+			err = writePNGChunk(w, "fdAT", append(fdat, data...))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
+
+// pngChunk is a single chunk of a PNG file
+type pngChunk struct {
+	name string
+	data []byte
+}
+
+// readPNGChunks splits an encoded PNG into its chunks
+func readPNGChunks(b []byte) ([]pngChunk, error) {
+	chunks := []pngChunk{}
+	if len(b) < 8 {
+		return chunks, errors.New("not a PNG")
+	}
+	b = b[8:]
+	for len(b) >= 12 {
+		length := int(binary.BigEndian.Uint32(b[0:4]))
+		if len(b) < 12+length {
+			return chunks, errors.New("truncated PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{name: string(b[4:8]), data: b[8 : 8+length]})
+		b = b[12+length:]
+	}
+	return chunks, nil
+}
+
+// writePNGChunk writes a chunk with its length and checksum
+func writePNGChunk(w io.Writer, name string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(data)))
+	copy(header[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+	for _, part := range [][]byte{header, data, footer} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
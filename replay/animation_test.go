@@ -0,0 +1,98 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/gif"
+	"math"
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// newTestRecording returns a recording of the snake moving right for frames ticks at the speed provided
+func newTestRecording(gameCFG game.Config, frames int, speed float64) Type {
+	recording := NewReplay()
+	s := snake.NewSnakeAt(gameCFG, pixel.V(8, 10), snake.RIGHT)
+	for i := 0; i < frames; i++ {
+		state := s.GetState()
+		state.HeadPos = state.HeadPos.Add(pixel.V(float64(i), 0))
+		state.TailPos = state.TailPos.Add(pixel.V(float64(i), 0))
+		state.Speed = speed
+		recording.Frames = append(recording.Frames, Frame{Snake: state, Score: i * 10})
+	}
+	return recording
+}
+
+func TestAPNGDelays(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	// 6 ticks at speed 3 is 2 seconds long
+	recording := newTestRecording(gameCFG, 6, 3)
+	for _, fps := range []float64{1, 7, 10, 29.97, 60} {
+		buf := new(bytes.Buffer)
+		if err := recording.WriteAPNG(buf, &gameCFG, fps, 0.2); err != nil {
+			t.Fatalf("WriteAPNG at %g fps: %v", fps, err)
+		}
+		chunks, err := readPNGChunks(buf.Bytes())
+		if err != nil {
+			t.Fatalf("WriteAPNG at %g fps wrote a broken PNG: %v", fps, err)
+		}
+		total := 0
+		frames := 0
+		for _, chunk := range chunks {
+			if chunk.name != "fcTL" {
+				continue
+			}
+			frames++
+			num := binary.BigEndian.Uint16(chunk.data[20:])
+			den := binary.BigEndian.Uint16(chunk.data[22:])
+			if den != 1000 {
+				t.Errorf("at %g fps frame %d has a delay denominator of %d, want 1000", fps, frames, den)
+			}
+			total += int(num)
+		}
+		if frames == 0 {
+			t.Fatalf("WriteAPNG at %g fps wrote no frames", fps)
+		}
+		// Each frame is held for whole samples, so the clip is as long as the samples covering the recording
+		want := int(math.Floor(math.Ceil(2*fps)/fps*1000 + 0.5))
+		if total != want {
+			t.Errorf("at %g fps the frames last %dms, want %dms", fps, total, want)
+		}
+	}
+}
+
+func TestGIFDelays(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	recording := newTestRecording(gameCFG, 6, 3)
+	buf := new(bytes.Buffer)
+	if err := recording.WriteGIF(buf, &gameCFG, 10, 0.2); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, delay := range anim.Delay {
+		total += delay
+	}
+	if total != 200 {
+		t.Errorf("the frames last %d hundredths of a second, want 200", total)
+	}
+}
+
+func TestAnimationFrameRate(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	recording := newTestRecording(gameCFG, 2, 3)
+	for _, fps := range []float64{0, -10, math.NaN(), math.Inf(1), maxFPS + 1} {
+		if err := recording.WriteAPNG(new(bytes.Buffer), &gameCFG, fps, 0.2); err == nil {
+			t.Errorf("WriteAPNG at %g fps didn't return an error", fps)
+		}
+		if err := recording.WriteGIF(new(bytes.Buffer), &gameCFG, fps, 0.2); err == nil {
+			t.Errorf("WriteGIF at %g fps didn't return an error", fps)
+		}
+	}
+}
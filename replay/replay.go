@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+	"github.com/shibukawa/configdir"
+)
+
+// Frame is the state of the game after one tick of the snake. Recordings made before there were several berries
+// only have Berry, newer recordings have Berries instead.
+type Frame struct {
+	Snake   snake.State
+	Berry   pixel.Vec
+	Berries []berries.Berry
+	Score   int
+}
+
+// Type is a recording of a game, holding a frame for every tick of the snake
+type Type struct {
+	Frames []Frame
+}
+
+// NewReplay returns an empty recording
+func NewReplay() Type {
+	return Type{Frames: []Frame{}}
+}
+
+// AddFrame records the state of the game after a tick
+func (t *Type) AddFrame(s *snake.Type, bs []berries.Berry, score int) {
+	t.Frames = append(t.Frames, Frame{Snake: s.GetState(), Berries: append([]berries.Berry{}, bs...), Score: score})
+}
+
+// GetBerries returns the berries on the board in the frame
+func (f *Frame) GetBerries() []berries.Berry {
+	if f.Berries == nil {
+		return []berries.Berry{{Pos: f.Berry, Kind: berries.Normal}}
+	}
+	return f.Berries
+}
+
+// Duration returns the length of the recording in seconds of game time
+func (t *Type) Duration() float64 {
+	duration := 0.0
+	for _, frame := range t.Frames {
+		duration += 1 / frame.Snake.Speed
+	}
+	return duration
+}
+
+// FrameIndexAt returns the index of the frame showing at a time in seconds from the start of the recording
+func (t *Type) FrameIndexAt(seconds float64) int {
+	elapsed := 0.0
+	for i, frame := range t.Frames {
+		elapsed += 1 / frame.Snake.Speed
+		if elapsed > seconds {
+			return i
+		}
+	}
+	return len(t.Frames) - 1
+}
+
+// Write encodes the recording as JSON
+func (t *Type) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
+
+// Read decodes a recording written by Write
+func Read(r io.Reader) (Type, error) {
+	t := NewReplay()
+	err := json.NewDecoder(r).Decode(&t)
+	return t, err
+}
+
+// SaveFile saves the recording to a file
+func (t *Type) SaveFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.Write(f)
+}
+
+// LoadFile loads a recording from a file
+func LoadFile(filename string) (Type, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return NewReplay(), err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// SaveReplay saves the recording to a file in the game's config folder, next to the high scores
+func (t *Type) SaveReplay(filename string) error {
+	folders := configdir.New("benjmarshall", "gopixelsnake").QueryFolders(configdir.Global)
+	f, err := folders[0].Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.Write(f)
+}
+
+// LoadReplay loads a recording saved with SaveReplay
+func LoadReplay(filename string) (Type, error) {
+	folder := configdir.New("benjmarshall", "gopixelsnake").QueryFolderContainsFile(filename)
+	if folder == nil {
+		return NewReplay(), os.ErrNotExist
+	}
+	f, err := folder.Open(filename)
+	if err != nil {
+		return NewReplay(), err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,625 @@
+package rivals
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/random"
+	"github.com/benjmarshall/gopixelsnake/settings"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// Difficulty is how well the rival snakes play
+type Difficulty int
+
+const (
+	// Easy rivals only look a few squares ahead and are slow to change their minds, so they walk into traps.
+	Easy Difficulty = iota
+	// Normal rivals look further ahead and go after new berries quicker.
+	Normal
+	// Hard rivals look a long way ahead, react on every step and keep clear of the heads of snakes which could beat them.
+	Hard
+	// NumDifficulties is the number of difficulties, it can be used to loop over all difficulties.
+	NumDifficulties
+)
+
+// difficultyNames are the names shown for each difficulty
+var difficultyNames = map[Difficulty]string{
+	Easy:   "Easy",
+	Normal: "Normal",
+	Hard:   "Hard",
+}
+
+// skill is how a difficulty plays. Lookahead is how many grid squares a rival searches for a berry and how much room
+// it checks there is before going somewhere, reaction is how many steps it takes between picking a berry to go for
+// and speed is how many grid squares it moves a second.
+type skill struct {
+	lookahead int
+	reaction  int
+	speed     float64
+}
+
+// skills are how each difficulty plays
+var skills = map[Difficulty]skill{
+	Easy:   {lookahead: 10, reaction: 3, speed: 2},
+	Normal: {lookahead: 20, reaction: 2, speed: 3},
+	Hard:   {lookahead: 40, reaction: 1, speed: 4},
+}
+
+// MaxRivals is the most rival snakes which can share the board with the player
+const MaxRivals = 3
+
+// Player is the index used for the player's snake in a Death, the rivals are numbered from 0
+const Player = -1
+
+// Nobody is the killer of a snake which didn't run into another snake
+const Nobody = -2
+
+// KillPoints is what a snake scores for each grid square long the snake it kills was
+const KillPoints = 10
+
+// respawnDelay is how long a rival is off the board after it dies
+const respawnDelay = 3 * time.Second
+
+// spawnRunway is how many grid squares in front of a rival have to be free when it comes onto the board
+const spawnRunway = 4
+
+// spawnDistance is how many grid squares from the player's head a rival has to come onto the board
+const spawnDistance = 8
+
+// String returns the name of the difficulty
+func (d Difficulty) String() string {
+	return difficultyNames[d]
+}
+
+// Rival is a snake played by the computer. Score and Kills are what it has scored and how many snakes it has killed
+// this game, they are kept while it is off the board after dying.
+type Rival struct {
+	Snake     snake.Type
+	Score     int
+	Kills     int
+	alive     bool
+	wait      time.Duration
+	eaten     bool
+	target    pixel.Vec
+	hasTarget bool
+	thinkIn   int
+}
+
+// Death is a snake dying at Pos. Victim and Killer are the index of a rival or Player, Killer is Nobody if the
+// snake didn't run into another one. Points is what the killer scored for it.
+type Death struct {
+	Pos    pixel.Vec
+	Victim int
+	Killer int
+	Cause  snake.Collision
+	Points int
+}
+
+// RivalState is a snapshot of a rival, which can be saved and used to restore it later
+type RivalState struct {
+	Snake snake.State
+	Score int
+	Kills int
+	Alive bool
+	Wait  time.Duration
+	Eaten bool
+}
+
+// State is a snapshot of the rivals part way through a game and how far through their random sequence they have
+// got, which can be saved and used to restore them later
+type State struct {
+	Rivals      []RivalState
+	PlayerKills int
+	Speed       float64
+	Rand        random.State
+}
+
+// Type holds the rival snakes on the board, how many there are and how well they play
+type Type struct {
+	count       int
+	difficulty  Difficulty
+	rivals      []Rival
+	playerKills int
+	src         *random.Source
+	rand        *rand.Rand
+	speed       float64
+	portals     map[pixel.Vec]pixel.Vec
+	settings    *settings.Type
+}
+
+// NewRivals returns the rivals chosen last time, loading the number of them and the difficulty from the settings
+// provided. The board starts without any rivals on it, see Reset.
+func NewRivals(set *settings.Type) Type {
+	t := new(Type)
+	t.settings = set
+	t.count = set.GetInt("rivals.Count", 0)
+	if t.count < 0 || t.count > MaxRivals {
+		t.count = 0
+	}
+	t.difficulty = Difficulty(set.GetInt("rivals.Difficulty", int(Normal)))
+	if t.difficulty < 0 || t.difficulty >= NumDifficulties {
+		t.difficulty = Normal
+	}
+	t.rivals = []Rival{}
+	return *t
+}
+
+// GetCount returns how many rivals share the board with the player
+func (t *Type) GetCount() int {
+	return t.count
+}
+
+// GetDifficulty returns how well the rivals play
+func (t *Type) GetDifficulty() Difficulty {
+	return t.difficulty
+}
+
+// NextCount changes to the next number of rivals, going back to none after MaxRivals, and saves the choice
+func (t *Type) NextCount() {
+	t.count = (t.count + 1) % (MaxRivals + 1)
+	t.settings.SetInt("rivals.Count", t.count)
+	t.settings.SaveSettings()
+}
+
+// NextDifficulty changes to the next difficulty and saves the choice
+func (t *Type) NextDifficulty() {
+	t.difficulty = (t.difficulty + 1) % NumDifficulties
+	t.settings.SetInt("rivals.Difficulty", int(t.difficulty))
+	t.settings.SaveSettings()
+}
+
+// Reset takes the rivals off the board and puts the number chosen back on for a new game, in squares where they
+// have room to start away from the player. They move at least as fast as speed, the level's starting speed, and
+// go through the portals, which map each grid square to the grid square of its partner. Blocked returns true for
+// squares, in the game area coordinate plane, they can't go in.
+func (t *Type) Reset(gameCFG *game.Config, seed int64, speed float64, portals map[pixel.Vec]pixel.Vec, player *snake.Type, blocked func(pixel.Vec) bool) {
+	t.src = random.NewSource(seed)
+	t.rand = rand.New(t.src)
+	t.speed = math.Max(speed, skills[t.difficulty].speed)
+	t.portals = portals
+	t.playerKills = 0
+	t.rivals = []Rival{}
+	for i := 0; i < t.count; i++ {
+		t.rivals = append(t.rivals, Rival{})
+		t.spawn(gameCFG, i, player, blocked)
+	}
+}
+
+// GetState returns a snapshot of the rivals
+func (t *Type) GetState() State {
+	state := State{Rivals: []RivalState{}, PlayerKills: t.playerKills, Speed: t.speed}
+	if t.src != nil {
+		state.Rand = t.src.GetState()
+	}
+	for _, r := range t.rivals {
+		state.Rivals = append(state.Rivals, RivalState{
+			Snake: r.Snake.GetState(),
+			Score: r.Score,
+			Kills: r.Kills,
+			Alive: r.alive,
+			Wait:  r.wait,
+			Eaten: r.eaten,
+		})
+	}
+	return state
+}
+
+// SetState puts the rivals back the way they were in the snapshot, going through the portals given to Reset. They
+// pick a berry to go for again on their next step.
+func (t *Type) SetState(gameCFG *game.Config, state State) {
+	t.src = random.NewSource(state.Rand.Seed)
+	t.src.SetState(state.Rand)
+	t.rand = rand.New(t.src)
+	t.speed = state.Speed
+	t.playerKills = state.PlayerKills
+	t.rivals = []Rival{}
+	for _, rs := range state.Rivals {
+		r := Rival{Score: rs.Score, Kills: rs.Kills, alive: rs.Alive, wait: rs.Wait, eaten: rs.Eaten}
+		if rs.Alive {
+			r.Snake = snake.NewSnakeFromState(*gameCFG, rs.Snake)
+			r.Snake.SetPortals(t.portals)
+		}
+		t.rivals = append(t.rivals, r)
+	}
+}
+
+// Clear takes all the rivals off the board, for modes which are played alone
+func (t *Type) Clear() {
+	t.rivals = []Rival{}
+}
+
+// GetRivals returns the rivals, including any which are off the board after dying
+func (t *Type) GetRivals() []Rival {
+	return t.rivals
+}
+
+// GetSnakes returns the snakes of the rivals on the board
+func (t *Type) GetSnakes() []*snake.Type {
+	ss := []*snake.Type{}
+	for i := range t.rivals {
+		if t.rivals[i].alive {
+			ss = append(ss, &t.rivals[i].Snake)
+		}
+	}
+	return ss
+}
+
+// GetPlayerKills returns how many rivals the player has killed this game
+func (t *Type) GetPlayerKills() int {
+	return t.playerKills
+}
+
+// IsAlive returns true if the rival is on the board
+func (r Rival) IsAlive() bool {
+	return r.alive
+}
+
+// Occupies returns true if any rival is in the grid square at pos, which is in the game area coordinate plane
+func (t *Type) Occupies(pos pixel.Vec) bool {
+	for i := range t.rivals {
+		if t.rivals[i].alive && t.rivals[i].Snake.Occupies(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// Update moves each rival as many steps as it has had time for since the last update and brings back any which
+// have been off the board long enough. Each step the rival picks where to go, heading for the nearest of the
+// targets it can see, and scores the same as the player does for moving. Rivals die if they run into anything
+// blocked or hazard returns true for, the edge of the game area, themselves or another snake, including the player. A snake
+// whose head runs into another's body is killed by it, and when two heads meet the longer snake kills the shorter,
+// or both die if they are the same length. It returns the snakes which died, which can include the player.
+func (t *Type) Update(gameCFG *game.Config, dt time.Duration, player *snake.Type, targets []pixel.Vec, blocked func(pixel.Vec) bool, hazard func(pixel.Vec) bool) []Death {
+	deaths := []Death{}
+	for i := range t.rivals {
+		r := &t.rivals[i]
+		r.wait -= dt
+		if !r.alive {
+			if r.wait <= 0 {
+				t.spawn(gameCFG, i, player, func(pos pixel.Vec) bool { return blocked(pos) || hazard(pos) })
+			}
+			continue
+		}
+		for r.alive && r.wait <= 0 {
+			r.wait += time.Duration(float64(time.Second) / t.speed)
+			r.Snake.Update(r.eaten, t.think(gameCFG, i, player, targets, blocked, hazard))
+			r.eaten = false
+			r.Score += int(t.speed * 10)
+			deaths = append(deaths, t.collide(gameCFG, i, player, blocked, hazard)...)
+			for _, d := range deaths {
+				if d.Victim == Player {
+					// The game is over so nothing else moves
+					return deaths
+				}
+			}
+		}
+	}
+	return deaths
+}
+
+// HitBy checks whether the player's head, which has just moved, has run into a rival, returning the snakes which died
+func (t *Type) HitBy(player *snake.Type) []Death {
+	head := player.GetHeadPos()
+	for i := range t.rivals {
+		r := &t.rivals[i]
+		if !r.alive || !r.Snake.Occupies(head) {
+			continue
+		}
+		if r.Snake.GetHeadPos() == head {
+			return t.headOn(Player, player.GetLength(), i, r.Snake.GetLength(), head)
+		}
+		return []Death{t.kill(Player, i, head, snake.HitSnake, player.GetLength())}
+	}
+	return []Death{}
+}
+
+// Crush kills any rival in the grid square at pos, which is in the game area coordinate plane, when a hazard
+// runs into it
+func (t *Type) Crush(pos pixel.Vec) []Death {
+	deaths := []Death{}
+	for i := range t.rivals {
+		if t.rivals[i].alive && t.rivals[i].Snake.Occupies(pos) {
+			deaths = append(deaths, t.kill(i, Nobody, pos, snake.HitHazard, t.rivals[i].Snake.GetLength()))
+		}
+	}
+	return deaths
+}
+
+// Feed gives the rival the berry it has just eaten, which works on it the same way it does on the player except
+// that rivals don't change speed. Shrink is how many grid squares a poison berry shrinks it by. It returns the
+// points the rival scored for the berry.
+func (t *Type) Feed(i int, b berries.Berry, shrink int) int {
+	r := &t.rivals[i]
+	switch b.Kind {
+	case berries.Normal, berries.Golden:
+		r.eaten = true
+	case berries.Poison:
+		r.Snake.Shrink(shrink)
+	}
+	points := b.Kind.Points(t.speed)
+	r.Score += points
+	return points
+}
+
+// collide checks whether the rival's head, which has just moved, has run into anything, returning the snakes which died
+func (t *Type) collide(gameCFG *game.Config, i int, player *snake.Type, blocked func(pixel.Vec) bool, hazard func(pixel.Vec) bool) []Death {
+	r := &t.rivals[i]
+	head := r.Snake.GetHeadPos()
+	length := r.Snake.GetLength()
+	if collision := r.Snake.CheckCollision(gameCFG); collision != snake.NoCollision {
+		return []Death{t.kill(i, Nobody, head, collision, length)}
+	}
+	if blocked(head) {
+		return []Death{t.kill(i, Nobody, head, snake.HitWall, length)}
+	}
+	if hazard(head) {
+		return []Death{t.kill(i, Nobody, head, snake.HitHazard, length)}
+	}
+	if player.Occupies(head) {
+		if player.GetHeadPos() == head {
+			return t.headOn(i, length, Player, player.GetLength(), head)
+		}
+		return []Death{t.kill(i, Player, head, snake.HitSnake, length)}
+	}
+	for j := range t.rivals {
+		other := &t.rivals[j]
+		if j == i || !other.alive || !other.Snake.Occupies(head) {
+			continue
+		}
+		if other.Snake.GetHeadPos() == head {
+			return t.headOn(i, length, j, other.Snake.GetLength(), head)
+		}
+		return []Death{t.kill(i, j, head, snake.HitSnake, length)}
+	}
+	return []Death{}
+}
+
+// headOn settles two snakes' heads meeting at pos, the snake at index mover has just moved into the head of the
+// one at index other. The longer snake kills the shorter, or both die if they are the same length.
+func (t *Type) headOn(mover int, moverLength int, other int, otherLength int, pos pixel.Vec) []Death {
+	if moverLength > otherLength {
+		return []Death{t.kill(other, mover, pos, snake.HitSnake, otherLength)}
+	} else if moverLength < otherLength {
+		return []Death{t.kill(mover, other, pos, snake.HitSnake, moverLength)}
+	}
+	return []Death{t.kill(mover, Nobody, pos, snake.HitSnake, moverLength), t.kill(other, Nobody, pos, snake.HitSnake, otherLength)}
+}
+
+// kill takes the victim off the board, if it is a rival, and gives the killer the credit for it, which is worth
+// more the longer the victim was
+func (t *Type) kill(victim int, killer int, pos pixel.Vec, cause snake.Collision, victimLength int) Death {
+	d := Death{Pos: pos, Victim: victim, Killer: killer, Cause: cause}
+	if victim != Player {
+		t.rivals[victim].alive = false
+		t.rivals[victim].wait = respawnDelay
+	}
+	if killer == Nobody {
+		return d
+	}
+	d.Points = victimLength * KillPoints
+	if killer == Player {
+		t.playerKills++
+	} else {
+		t.rivals[killer].Kills++
+		t.rivals[killer].Score += d.Points
+	}
+	return d
+}
+
+// think picks the way the rival goes on its next step. Every few steps, depending on how quickly it reacts, it
+// picks the nearest target it can reach within its lookahead, and it heads for that target until it picks again,
+// even if the target has gone. It doesn't go anywhere without room for it to fit, as far as it can see, choosing
+// the way with the most room if there isn't enough anywhere. Hard rivals also keep away from the heads of snakes
+// at least as long as them. It returns NOCHANGE to carry straight on.
+func (t *Type) think(gameCFG *game.Config, i int, player *snake.Type, targets []pixel.Vec, blocked func(pixel.Vec) bool, hazard func(pixel.Vec) bool) snake.Direction {
+	r := &t.rivals[i]
+	sk := skills[t.difficulty]
+	grid := gameCFG.GetGridMatrix()
+	head := square(grid, r.Snake.GetHeadPos())
+	heading := r.Snake.GetDirection()
+	taken := t.taken(gameCFG, player)
+	checked := map[pixel.Vec]bool{}
+	free := func(sq pixel.Vec) bool {
+		if ok, seen := checked[sq]; seen {
+			return ok
+		}
+		pos := grid.Project(sq)
+		ok := gameCFG.GetGameAreaAsRec().Contains(pos) && !blocked(pos) && !hazard(pos) && !taken[sq]
+		checked[sq] = ok
+		return ok
+	}
+
+	r.thinkIn--
+	if r.thinkIn <= 0 {
+		r.thinkIn = sk.reaction
+		goals := map[pixel.Vec]bool{}
+		for _, target := range targets {
+			goals[square(grid, target)] = true
+		}
+		_, r.target, r.hasTarget = search(head, func(sq pixel.Vec) bool { return goals[sq] }, free, sk.lookahead)
+	}
+	if r.hasTarget && r.target == head {
+		r.hasTarget = false
+	}
+	route := heading
+	if r.hasTarget {
+		if step, _, ok := search(head, func(sq pixel.Vec) bool { return sq == r.target }, free, 2*sk.lookahead); ok {
+			route = direction(step)
+		}
+	}
+
+	danger := map[pixel.Vec]bool{}
+	if t.difficulty == Hard {
+		heads := []pixel.Vec{}
+		if player.GetLength() >= r.Snake.GetLength() {
+			heads = append(heads, square(grid, player.GetHeadPos()))
+		}
+		for j := range t.rivals {
+			if j != i && t.rivals[j].alive && t.rivals[j].Snake.GetLength() >= r.Snake.GetLength() {
+				heads = append(heads, square(grid, t.rivals[j].Snake.GetHeadPos()))
+			}
+		}
+		for _, h := range heads {
+			for _, d := range []snake.Direction{snake.UP, snake.DOWN, snake.LEFT, snake.RIGHT} {
+				danger[h.Add(d.GetVec())] = true
+			}
+		}
+	}
+
+	need := r.Snake.GetLength()
+	if need > sk.lookahead {
+		need = sk.lookahead
+	}
+	best, bestRoom := heading, -1
+	for _, dir := range []snake.Direction{route, heading, snake.UP, snake.DOWN, snake.LEFT, snake.RIGHT} {
+		next := head.Add(dir.GetVec())
+		if dir.GetVec() == pixel.ZV || dir.GetVec().Add(heading.GetVec()) == pixel.ZV || !free(next) {
+			continue
+		}
+		room := 0
+		if !danger[next] {
+			room = space(next, free, sk.lookahead)
+		}
+		if room >= need {
+			best = dir
+			break
+		}
+		if room > bestRoom {
+			best, bestRoom = dir, room
+		}
+	}
+	if best == heading {
+		return snake.NOCHANGE
+	}
+	return best
+}
+
+// taken returns the grid squares which have a snake in them, the player or any of the rivals on the board
+func (t *Type) taken(gameCFG *game.Config, player *snake.Type) map[pixel.Vec]bool {
+	grid := gameCFG.GetGridMatrix()
+	taken := map[pixel.Vec]bool{}
+	for _, s := range append(t.GetSnakes(), player) {
+		for _, seg := range s.Segments() {
+			taken[square(grid, seg.Pos)] = true
+		}
+	}
+	return taken
+}
+
+// spawn puts the rival at index i on the board, in a random square with its body and a runway in front of it free
+// and away from the player's head. If there isn't room it stays off the board and tries again on the next update.
+func (t *Type) spawn(gameCFG *game.Config, i int, player *snake.Type, blocked func(pixel.Vec) bool) {
+	r := &t.rivals[i]
+	grid := gameCFG.GetGridMatrix()
+	x, y := gameCFG.GetGameAreaDims()
+	cols, rows := int(x/gameCFG.GetGridSize()), int(y/gameCFG.GetGridSize())
+	taken := t.taken(gameCFG, player)
+	playerHead := square(grid, player.GetHeadPos())
+	headings := []snake.Direction{snake.UP, snake.DOWN, snake.LEFT, snake.RIGHT}
+	for try := 0; try < 200; try++ {
+		head := pixel.V(float64(t.rand.Intn(cols)), float64(t.rand.Intn(rows)))
+		dir := headings[t.rand.Intn(len(headings))]
+		if head.To(playerHead).Len() < spawnDistance {
+			continue
+		}
+		ok := true
+		for k := 1 - snake.StartLength; k <= spawnRunway && ok; k++ {
+			sq := head.Add(dir.GetVec().Scaled(float64(k)))
+			_, portal := t.portals[sq]
+			pos := grid.Project(sq)
+			ok = gameCFG.GetGameAreaAsRec().Contains(pos) && !blocked(pos) && !taken[sq] && !portal
+		}
+		if !ok {
+			continue
+		}
+		r.Snake = snake.NewSnakeAt(*gameCFG, head, dir)
+		r.Snake.SetSpeed(t.speed)
+		r.Snake.SetPortals(t.portals)
+		r.alive = true
+		r.eaten = false
+		r.hasTarget = false
+		r.thinkIn = 0
+		r.wait = time.Duration(float64(time.Second) / t.speed)
+		return
+	}
+	r.wait = 0
+}
+
+// search looks outwards from the grid square start through the squares which are free, up to limit steps away,
+// for the nearest square which is a goal. It returns the first step towards it, the goal found and false if no goal
+// was found.
+func search(start pixel.Vec, goal func(pixel.Vec) bool, free func(pixel.Vec) bool, limit int) (pixel.Vec, pixel.Vec, bool) {
+	type node struct {
+		sq    pixel.Vec
+		first pixel.Vec
+		steps int
+	}
+	seen := map[pixel.Vec]bool{start: true}
+	queue := []node{{sq: start}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.steps > 0 && goal(n.sq) {
+			return n.first, n.sq, true
+		}
+		if n.steps >= limit {
+			continue
+		}
+		for _, d := range []pixel.Vec{pixel.V(0, 1), pixel.V(0, -1), pixel.V(-1, 0), pixel.V(1, 0)} {
+			next := n.sq.Add(d)
+			if seen[next] || !free(next) {
+				continue
+			}
+			seen[next] = true
+			first := n.first
+			if n.steps == 0 {
+				first = d
+			}
+			queue = append(queue, node{next, first, n.steps + 1})
+		}
+	}
+	return pixel.ZV, pixel.ZV, false
+}
+
+// space counts the free grid squares which can be reached from start, including start, up to limit of them
+func space(start pixel.Vec, free func(pixel.Vec) bool, limit int) int {
+	seen := map[pixel.Vec]bool{start: true}
+	queue := []pixel.Vec{start}
+	count := 0
+	for len(queue) > 0 && count < limit {
+		sq := queue[0]
+		queue = queue[1:]
+		count++
+		for _, d := range []pixel.Vec{pixel.V(0, 1), pixel.V(0, -1), pixel.V(-1, 0), pixel.V(1, 0)} {
+			next := sq.Add(d)
+			if !seen[next] && free(next) {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return count
+}
+
+// direction returns the direction which moves the snake by the step, one grid square
+func direction(step pixel.Vec) snake.Direction {
+	for _, d := range []snake.Direction{snake.UP, snake.DOWN, snake.LEFT, snake.RIGHT} {
+		if d.GetVec() == step {
+			return d
+		}
+	}
+	return snake.NOCHANGE
+}
+
+// square returns the grid square of pos, which is in the game area coordinate plane, rounded so it matches
+// squares projected back exactly
+func square(grid pixel.Matrix, pos pixel.Vec) pixel.Vec {
+	sq := grid.Unproject(pos)
+	return pixel.V(math.Round(sq.X), math.Round(sq.Y))
+}
@@ -0,0 +1,249 @@
+package rivals
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// placed is a snake put on the board for a test, with its head in a grid square and shrunk by shrink squares so
+// snakes of different lengths can meet
+type placed struct {
+	head   pixel.Vec
+	dir    snake.Direction
+	shrink int
+}
+
+// newSnake returns the snake described by p
+func newSnake(gameCFG game.Config, p placed) snake.Type {
+	s := snake.NewSnakeAt(gameCFG, p.head, p.dir)
+	s.Shrink(p.shrink)
+	return s
+}
+
+// newTestRivals returns a board with the player and a rival for each of the placed snakes, which move three squares
+// a second and are a step away from moving, as they are when they come onto the board
+func newTestRivals(gameCFG game.Config, player placed, rs ...placed) (Type, snake.Type) {
+	t := Type{speed: 3, rivals: []Rival{}}
+	for _, p := range rs {
+		t.rivals = append(t.rivals, Rival{Snake: newSnake(gameCFG, p), alive: true, wait: time.Second / 3})
+	}
+	return t, newSnake(gameCFG, player)
+}
+
+// checkDeaths compares the deaths with the victims and killers wanted, and checks the points are only scored by
+// a killer and are worth the victim's length
+func checkDeaths(t *testing.T, got []Death, want [][2]int, lengths map[int]int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d deaths %+v, want %d", len(got), got, len(want))
+	}
+	for i, d := range got {
+		if d.Victim != want[i][0] || d.Killer != want[i][1] {
+			t.Errorf("death %d is victim %d killed by %d, want victim %d killed by %d", i, d.Victim, d.Killer, want[i][0], want[i][1])
+		}
+		wantPoints := 0
+		if d.Killer != Nobody {
+			wantPoints = lengths[d.Victim] * KillPoints
+		}
+		if d.Points != wantPoints {
+			t.Errorf("death %d is worth %d points, want %d", i, d.Points, wantPoints)
+		}
+	}
+}
+
+// checkCredit checks the player's kills and each rival's kills and score, which only come from kill points here
+func checkCredit(t *testing.T, rs *Type, deaths []Death) {
+	t.Helper()
+	playerKills := 0
+	kills := map[int]int{}
+	scores := map[int]int{}
+	for _, d := range deaths {
+		if d.Killer == Player {
+			playerKills++
+		} else if d.Killer != Nobody {
+			kills[d.Killer]++
+			scores[d.Killer] += d.Points
+		}
+	}
+	if rs.GetPlayerKills() != playerKills {
+		t.Errorf("the player has %d kills, want %d", rs.GetPlayerKills(), playerKills)
+	}
+	for i, r := range rs.GetRivals() {
+		if r.Kills != kills[i] || r.Score != scores[i] {
+			t.Errorf("rival %d has %d kills and %d points, want %d kills and %d points", i, r.Kills, r.Score, kills[i], scores[i])
+		}
+	}
+}
+
+func TestHitBy(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	tests := []struct {
+		name   string
+		player placed
+		rival  placed
+		want   [][2]int
+	}{
+		{
+			name:   "player into a rival's body",
+			player: placed{head: pixel.V(8, 10), dir: snake.UP},
+			rival:  placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			want:   [][2]int{{Player, 0}},
+		},
+		{
+			name:   "head on, the player is longer",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rival:  placed{head: pixel.V(10, 10), dir: snake.DOWN, shrink: 1},
+			want:   [][2]int{{0, Player}},
+		},
+		{
+			name:   "head on, the rival is longer",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT, shrink: 1},
+			rival:  placed{head: pixel.V(10, 10), dir: snake.DOWN},
+			want:   [][2]int{{Player, 0}},
+		},
+		{
+			name:   "head on, the same length",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rival:  placed{head: pixel.V(10, 10), dir: snake.DOWN},
+			want:   [][2]int{{Player, Nobody}, {0, Nobody}},
+		},
+		{
+			name:   "clear of the rival",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rival:  placed{head: pixel.V(15, 15), dir: snake.DOWN},
+			want:   [][2]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, player := newTestRivals(gameCFG, tt.player, tt.rival)
+			lengths := map[int]int{Player: player.GetLength(), 0: rs.rivals[0].Snake.GetLength()}
+			deaths := rs.HitBy(&player)
+			checkDeaths(t, deaths, tt.want, lengths)
+			checkCredit(t, &rs, deaths)
+			for _, d := range deaths {
+				if d.Victim == 0 && rs.GetRivals()[0].IsAlive() {
+					t.Error("the rival is still on the board after it died")
+				}
+			}
+		})
+	}
+}
+
+func TestCollide(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	grid := gameCFG.GetGridMatrix()
+	wall := grid.Project(pixel.V(3, 3))
+	blocked := func(pos pixel.Vec) bool { return pos == wall }
+	hazard := func(pos pixel.Vec) bool { return pos == grid.Project(pixel.V(3, 16)) }
+	tests := []struct {
+		name   string
+		player placed
+		rivals []placed
+		want   [][2]int
+	}{
+		{
+			name:   "rival into the player's body",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(8, 10), dir: snake.UP}},
+			want:   [][2]int{{0, Player}},
+		},
+		{
+			name:   "rival into another rival's body",
+			player: placed{head: pixel.V(2, 18), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(8, 10), dir: snake.UP}, {head: pixel.V(10, 10), dir: snake.RIGHT}},
+			want:   [][2]int{{0, 1}},
+		},
+		{
+			name:   "head on with the player, the rival is longer",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT, shrink: 1},
+			rivals: []placed{{head: pixel.V(10, 10), dir: snake.DOWN}},
+			want:   [][2]int{{Player, 0}},
+		},
+		{
+			name:   "head on with the player, the player is longer",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(10, 10), dir: snake.DOWN, shrink: 1}},
+			want:   [][2]int{{0, Player}},
+		},
+		{
+			name:   "head on with another rival, the other is longer",
+			player: placed{head: pixel.V(2, 18), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(10, 10), dir: snake.DOWN, shrink: 1}, {head: pixel.V(10, 10), dir: snake.RIGHT}},
+			want:   [][2]int{{0, 1}},
+		},
+		{
+			name:   "head on with another rival, the same length",
+			player: placed{head: pixel.V(2, 18), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(10, 10), dir: snake.DOWN}, {head: pixel.V(10, 10), dir: snake.RIGHT}},
+			want:   [][2]int{{0, Nobody}, {1, Nobody}},
+		},
+		{
+			name:   "rival into a wall",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(3, 3), dir: snake.UP}},
+			want:   [][2]int{{0, Nobody}},
+		},
+		{
+			name:   "rival into a hazard",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(3, 16), dir: snake.UP}},
+			want:   [][2]int{{0, Nobody}},
+		},
+		{
+			name:   "rival in the clear",
+			player: placed{head: pixel.V(10, 10), dir: snake.RIGHT},
+			rivals: []placed{{head: pixel.V(15, 15), dir: snake.UP}},
+			want:   [][2]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, player := newTestRivals(gameCFG, tt.player, tt.rivals...)
+			lengths := map[int]int{Player: player.GetLength()}
+			for i, r := range rs.rivals {
+				lengths[i] = r.Snake.GetLength()
+			}
+			deaths := rs.collide(&gameCFG, 0, &player, blocked, hazard)
+			checkDeaths(t, deaths, tt.want, lengths)
+			checkCredit(t, &rs, deaths)
+		})
+	}
+}
+
+func TestCrush(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	rs, _ := newTestRivals(gameCFG, placed{head: pixel.V(2, 18), dir: snake.RIGHT},
+		placed{head: pixel.V(10, 10), dir: snake.RIGHT}, placed{head: pixel.V(15, 15), dir: snake.UP})
+	deaths := rs.Crush(gameCFG.GetGridMatrix().Project(pixel.V(8, 10)))
+	checkDeaths(t, deaths, [][2]int{{0, Nobody}}, nil)
+	checkCredit(t, &rs, deaths)
+	if rs.GetRivals()[0].IsAlive() || !rs.GetRivals()[1].IsAlive() {
+		t.Error("the hazard didn't crush only the rival it ran into")
+	}
+}
+
+func TestScoring(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	rs, player := newTestRivals(gameCFG, placed{head: pixel.V(2, 18), dir: snake.RIGHT},
+		placed{head: pixel.V(10, 5), dir: snake.UP}, placed{head: pixel.V(15, 5), dir: snake.UP})
+	open := func(pixel.Vec) bool { return false }
+	// One step each, which scores the same as the player does for moving
+	rs.Update(&gameCFG, time.Second/3, &player, nil, open, open)
+	points := rs.Feed(1, berries.Berry{Kind: berries.Golden}, 2)
+	if want := berries.Golden.Points(rs.speed); points != want {
+		t.Errorf("the golden berry scored %d, want %d", points, want)
+	}
+	step := int(rs.speed * 10)
+	if got := rs.GetRivals()[0].Score; got != step {
+		t.Errorf("rival 0 scored %d, want %d", got, step)
+	}
+	if got := rs.GetRivals()[1].Score; got != step+points {
+		t.Errorf("rival 1 scored %d, want %d", got, step+points)
+	}
+}
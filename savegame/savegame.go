@@ -0,0 +1,172 @@
+package savegame
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/modes"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/random"
+	"github.com/benjmarshall/gopixelsnake/rivals"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/shibukawa/configdir"
+)
+
+// Version is the version of the format games are saved in, it goes up whenever the format changes so saves from
+// other versions of the game aren't loaded wrongly
+const Version = 1
+
+// Filename is the name of the file a game is saved to in the game's config folder, next to the high scores
+const Filename = "saved_game.json"
+
+// Type is a snapshot of a game part way through with everything needed to carry it on. The level is made again
+// from the mode, stage, seed and daily date the same way it was at the start of the game, and Level is its name so
+// it can be checked that it is the same level. Positions are in the game area coordinate plane, except for snakes
+// which are in grid coordinates.
+type Type struct {
+	Version    int
+	Mode       modes.Mode
+	Level      string
+	Stage      int
+	Seed       int64
+	DailyDate  string
+	Elapsed    time.Duration
+	Score      int
+	StageScore int
+	Snake      snake.State
+	Effects    []snake.ActiveEffect
+	Eaten      bool
+	Berries    berries.State
+	PowerUps   powerups.State
+	Hazards    []hazards.State
+	Rivals     rivals.State
+}
+
+// Check returns an error if the snapshot can't be carried on by this version of the game on a game area the size
+// of the one in the config, because it was saved in a different format or something in it is out of range
+func (t *Type) Check(gameCFG *game.Config) error {
+	if t.Version != Version {
+		return fmt.Errorf("the game was saved in format %d, not %d", t.Version, Version)
+	}
+	if t.Mode < 0 || t.Mode >= modes.NumModes {
+		return errors.New("the game's mode doesn't exist")
+	}
+	if t.Mode == modes.Daily && t.DailyDate != modes.Today() {
+		return errors.New("the daily challenge has changed")
+	}
+	if t.Score < 0 || t.StageScore < 0 || t.StageScore > t.Score || t.Elapsed < 0 {
+		return errors.New("the score or timer is out of range")
+	}
+	if err := t.Snake.Check(gameCFG); err != nil {
+		return err
+	}
+	for _, e := range t.Effects {
+		if e.Effect < 0 || e.Effect >= snake.NumEffects || e.TicksLeft < 0 {
+			return errors.New("the snake has an effect which doesn't exist")
+		}
+	}
+	area := gameCFG.GetGameAreaAsRec()
+	for _, b := range t.Berries.Berries {
+		if !area.Contains(b.Pos) || b.Kind < 0 || b.Kind >= berries.NumKinds {
+			return errors.New("a berry is out of range")
+		}
+	}
+	for _, p := range t.PowerUps.PowerUps {
+		if !area.Contains(p.Pos) || p.Effect < 0 || p.Effect >= snake.NumEffects {
+			return errors.New("a power-up is out of range")
+		}
+	}
+	for _, h := range t.Hazards {
+		if !area.Contains(h.Hazard.Pos) || h.Hazard.Kind < 0 || h.Hazard.Kind >= hazards.NumKinds ||
+			h.Hazard.Speed <= 0 || hazards.CheckSpeed(h.Hazard.Speed) != nil {
+			return errors.New("a hazard is out of range")
+		}
+	}
+	if len(t.Rivals.Rivals) > rivals.MaxRivals {
+		return fmt.Errorf("there can't be more than %d rivals", rivals.MaxRivals)
+	}
+	if len(t.Rivals.Rivals) > 0 && snake.CheckSpeed(t.Rivals.Speed) != nil {
+		return errors.New("the rivals' speed is out of range")
+	}
+	for _, r := range t.Rivals.Rivals {
+		if r.Score < 0 || r.Kills < 0 {
+			return errors.New("a rival's score is out of range")
+		}
+		if !r.Alive {
+			continue
+		}
+		if r.Snake.Check(gameCFG) != nil {
+			return errors.New("a rival's snake is broken")
+		}
+	}
+	for _, count := range []int64{t.Berries.Rand.Count, t.PowerUps.Rand.Count, t.Rivals.Rand.Count} {
+		if count < 0 || count > random.MaxCount {
+			return errors.New("a random sequence is out of range")
+		}
+	}
+	return nil
+}
+
+// Write encodes the snapshot as JSON
+func (t *Type) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t)
+}
+
+// Read decodes a snapshot written by Write, it should be checked with Check before it is used
+func Read(r io.Reader) (Type, error) {
+	t := Type{}
+	err := json.NewDecoder(r).Decode(&t)
+	return t, err
+}
+
+// Save saves the snapshot to the game's config folder, replacing any game saved before
+func (t *Type) Save() error {
+	folders := configdir.New("benjmarshall", "gopixelsnake").QueryFolders(configdir.Global)
+	f, err := folders[0].Create(Filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.Write(f)
+}
+
+// Load loads the game saved with Save, returning an error if there isn't one or it can't be carried on on a game
+// area the size of the one in the config
+func Load(gameCFG *game.Config) (Type, error) {
+	folder := configdir.New("benjmarshall", "gopixelsnake").QueryFolderContainsFile(Filename)
+	if folder == nil {
+		return Type{}, os.ErrNotExist
+	}
+	f, err := folder.Open(Filename)
+	if err != nil {
+		return Type{}, err
+	}
+	defer f.Close()
+	t, err := Read(f)
+	if err != nil {
+		return t, err
+	}
+	return t, t.Check(gameCFG)
+}
+
+// Exists returns true if there is a saved game
+func Exists() bool {
+	return configdir.New("benjmarshall", "gopixelsnake").QueryFolderContainsFile(Filename) != nil
+}
+
+// Delete removes the saved game, once it has been carried on or can't be
+func Delete() error {
+	folder := configdir.New("benjmarshall", "gopixelsnake").QueryFolderContainsFile(Filename)
+	if folder == nil {
+		return nil
+	}
+	return os.Remove(filepath.Join(folder.Path, Filename))
+}
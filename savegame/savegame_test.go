@@ -0,0 +1,115 @@
+package savegame
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/benjmarshall/gopixelsnake/berries"
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/benjmarshall/gopixelsnake/hazards"
+	"github.com/benjmarshall/gopixelsnake/modes"
+	"github.com/benjmarshall/gopixelsnake/powerups"
+	"github.com/benjmarshall/gopixelsnake/rivals"
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// newTestGame returns a snapshot of a game on a 20x20 grid with something of everything in it
+func newTestGame(gameCFG game.Config) Type {
+	grid := gameCFG.GetGridMatrix()
+	player := snake.NewSnakeAt(gameCFG, pixel.V(8, 10), snake.RIGHT)
+	player.SetSpeed(4.5)
+	rival := snake.NewSnakeAt(gameCFG, pixel.V(8, 15), snake.LEFT)
+	hs := hazards.NewHazards(&gameCFG, []hazards.Hazard{{Kind: hazards.Ball, Pos: pixel.V(2, 2), Dir: pixel.V(1, 1)}})
+	return Type{
+		Version:    Version,
+		Mode:       modes.Endless,
+		Level:      "Open",
+		Seed:       42,
+		Elapsed:    90 * time.Second,
+		Score:      1200,
+		StageScore: 1200,
+		Snake:      player.GetState(),
+		Effects:    []snake.ActiveEffect{{Effect: snake.Ghost, TicksLeft: 10}},
+		Berries: berries.State{Berries: []berries.Berry{
+			{Pos: grid.Project(pixel.V(3, 3)), Kind: berries.Golden, Lifetime: 5 * time.Second, TimeLeft: 2 * time.Second},
+		}},
+		PowerUps: powerups.State{PowerUps: []powerups.PowerUp{
+			{Pos: grid.Project(pixel.V(5, 5)), Effect: snake.Shield, Lifetime: 60, TicksLeft: 30},
+		}},
+		Hazards: hs.GetState(),
+		Rivals: rivals.State{
+			Rivals: []rivals.RivalState{{Snake: rival.GetState(), Score: 300, Alive: true}},
+			Speed:  3,
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	saved := newTestGame(gameCFG)
+	if err := saved.Check(&gameCFG); err != nil {
+		t.Fatalf("Check() on the game before it was written: %v", err)
+	}
+	first := new(bytes.Buffer)
+	if err := saved.Write(first); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Read(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Check(&gameCFG); err != nil {
+		t.Errorf("Check() on the game read back: %v", err)
+	}
+	// Writing the game read back gives the same JSON, so nothing was lost on the way
+	second := new(bytes.Buffer)
+	if err := loaded.Write(second); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("the game read back is written as\n%s\nwant\n%s", second, first)
+	}
+}
+
+func TestCheckSpeeds(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	tests := []struct {
+		name   string
+		change func(g *Type)
+	}{
+		{"snake too slow", func(g *Type) { g.Snake.Speed = 1 }},
+		{"snake too fast", func(g *Type) { g.Snake.Speed = 1e12 }},
+		{"snake infinite", func(g *Type) { g.Snake.Speed = math.Inf(1) }},
+		{"snake NaN", func(g *Type) { g.Snake.Speed = math.NaN() }},
+		{"rivals stopped", func(g *Type) { g.Rivals.Speed = 0 }},
+		{"rivals backwards", func(g *Type) { g.Rivals.Speed = -1 }},
+		{"rivals too fast", func(g *Type) { g.Rivals.Speed = 1e12 }},
+		{"rivals NaN", func(g *Type) { g.Rivals.Speed = math.NaN() }},
+		{"hazard stopped", func(g *Type) { g.Hazards[0].Hazard.Speed = 0 }},
+		{"hazard too fast", func(g *Type) { g.Hazards[0].Hazard.Speed = 1e12 }},
+		{"hazard infinite", func(g *Type) { g.Hazards[0].Hazard.Speed = math.Inf(1) }},
+		{"hazard NaN", func(g *Type) { g.Hazards[0].Hazard.Speed = math.NaN() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(gameCFG)
+			tt.change(&g)
+			if g.Check(&gameCFG) == nil {
+				t.Error("Check() didn't return an error")
+			}
+		})
+	}
+}
+
+func TestCheckNoRivals(t *testing.T) {
+	// Games without rivals never set the rivals' speed
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	g := newTestGame(gameCFG)
+	g.Rivals = rivals.State{}
+	if err := g.Check(&gameCFG); err != nil {
+		t.Errorf("Check() = %v, want nil", err)
+	}
+}
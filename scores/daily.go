@@ -0,0 +1,135 @@
+package scores
+
+import (
+	"encoding/csv"
+	"sort"
+	"strconv"
+
+	"github.com/shibukawa/configdir"
+)
+
+// Daily holds the results of the daily challenge for every day it has been played. Only the first result each
+// player gets on a day counts, so the table for each day shows everyone's first attempt at that day's game. Players
+// are only told apart by the name they type, so someone playing under a new name gets another attempt.
+type Daily struct {
+	records    [][]string
+	scoresFile string
+	configDirs configdir.ConfigDir
+	numScores  int
+}
+
+// NewDaily creates a new daily challenge scores struct, showing up to numScores results for each day
+func NewDaily(filename string, numScores int) Daily {
+	d := new(Daily)
+	d.scoresFile = filename
+	d.records = [][]string{}
+	d.configDirs = configdir.New("benjmarshall", "gopixelsnake")
+	d.numScores = numScores
+	d.LoadScores()
+	return *d
+}
+
+// AddScore records a player's result for the day's challenge and saves it. It returns false, without recording
+// anything, if the player already has a result for that day.
+func (d *Daily) AddScore(date string, score int, name string) bool {
+	if d.HasPlayed(date, name) {
+		return false
+	}
+	d.records = append(d.records, []string{date, name, strconv.Itoa(score)})
+	d.SaveScores()
+	return true
+}
+
+// HasPlayed returns true if the player already has a result for the day's challenge
+func (d *Daily) HasPlayed(date string, name string) bool {
+	for _, record := range d.records {
+		if record[0] == date && record[1] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTable returns the day's best results as a scores table, which can be drawn like any other. The table isn't
+// saved, results should be added to the daily scores instead.
+func (d *Daily) GetTable(date string) Type {
+	day := [][]string{}
+	for _, record := range d.records {
+		if record[0] == date {
+			day = append(day, []string{record[1], record[2]})
+		}
+	}
+	sort.SliceStable(day, func(i, j int) bool {
+		a, _ := strconv.Atoi(day[i][1])
+		b, _ := strconv.Atoi(day[j][1])
+		return a > b
+	})
+	if len(day) > d.numScores {
+		day = day[:d.numScores]
+	}
+	t := new(Type)
+	t.scoresRecord = append([][]string{[]string{"Name", "Points"}}, day...)
+	t.numScores = d.numScores
+	return *t
+}
+
+// GetDates returns each day which has results, newest first. Dates are in the YYYY-MM-DD format so they sort
+// in the same order as the days.
+func (d *Daily) GetDates() []string {
+	seen := map[string]bool{}
+	dates := []string{}
+	for _, record := range d.records {
+		if !seen[record[0]] {
+			seen[record[0]] = true
+			dates = append(dates, record[0])
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	return dates
+}
+
+// SaveScores saves the daily results to a csv file
+func (d *Daily) SaveScores() {
+	folders := d.configDirs.QueryFolders(configdir.Global)
+
+	f, err := folders[0].Create(d.scoresFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	for _, value := range d.records {
+		err := w.Write(value)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// LoadScores loads saved daily results from a csv file, ignoring any lines which aren't a date, name and score
+func (d *Daily) LoadScores() {
+	folder := d.configDirs.QueryFolderContainsFile(d.scoresFile)
+	if folder == nil {
+		return
+	}
+	f, err := folder.Open(d.scoresFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return
+	}
+	for _, record := range records {
+		if len(record) == 3 {
+			d.records = append(d.records, record)
+		}
+	}
+}
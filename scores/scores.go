@@ -2,6 +2,7 @@ package scores
 
 import (
 	"encoding/csv"
+	"fmt"
 	"strconv"
 
 	"github.com/kniren/gota/dataframe"
@@ -14,6 +15,7 @@ type Type struct {
 	scoresFile   string
 	configDirs   configdir.ConfigDir
 	numScores    int
+	times        bool
 }
 
 // NewScores creates a new scores struct
@@ -29,6 +31,13 @@ func NewScores(filename string, numScores int) Type {
 	return *t
 }
 
+// NewTimes creates a new scores struct for a table of times in milliseconds, where the lowest time is best
+func NewTimes(filename string, numScores int) Type {
+	t := NewScores(filename, numScores)
+	t.times = true
+	return t
+}
+
 // AddScore pushes a new high score into the scores table
 func (t *Type) AddScore(score int, name string) {
 	record := t.getOrderedScores()
@@ -41,18 +50,22 @@ func (t *Type) AddScore(score int, name string) {
 	return
 }
 
-// GetTopScores returns the top n scores as a string slice, ordered in desecnding order of points
+// GetTopScores returns the top n scores as a string slice, ordered in desecnding order of points, or ascending
+// order of time for a table of times
 func (t *Type) GetTopScores() [][]string {
 	topScoresSlice := [][]string{
 		[]string{"Pos.", "Name", "Points"},
 	}
+	if t.times {
+		topScoresSlice[0][2] = "Time"
+	}
 
 	if len(t.scoresRecord) > 1 {
 		scoresTable := dataframe.LoadRecords(t.scoresRecord)
 		if scoresTable.Err != nil {
 			panic(scoresTable.Err)
 		}
-		orderedScores := scoresTable.Arrange(dataframe.RevSort("Points"))
+		orderedScores := scoresTable.Arrange(t.order())
 		if orderedScores.Err != nil {
 			panic(orderedScores.Err)
 		}
@@ -62,7 +75,7 @@ func (t *Type) GetTopScores() [][]string {
 			if i == 0 {
 				continue
 			}
-			topScoresSlice = append(topScoresSlice, []string{strconv.Itoa(i), record[0], record[1]})
+			topScoresSlice = append(topScoresSlice, []string{strconv.Itoa(i), record[0], t.format(record[1])})
 		}
 	}
 
@@ -76,7 +89,7 @@ func (t *Type) getOrderedScores() [][]string {
 		if scoresTable.Err != nil {
 			panic(scoresTable.Err)
 		}
-		orderedScores := scoresTable.Arrange(dataframe.RevSort("Points"))
+		orderedScores := scoresTable.Arrange(t.order())
 		if orderedScores.Err != nil {
 			panic(orderedScores.Err)
 		}
@@ -100,6 +113,47 @@ func (t *Type) GetBottomScore() int {
 	return score
 }
 
+// Qualifies returns true if the score, or time for a table of times, is good enough to go in the table
+func (t *Type) Qualifies(score int) bool {
+	if !t.times {
+		return score >= t.GetBottomScore()
+	}
+	records := t.getOrderedScores()
+	if len(records)-1 < t.numScores {
+		return true
+	}
+	worst, err := strconv.Atoi(records[len(records)-1][1])
+	if err != nil {
+		return true
+	}
+	return score <= worst
+}
+
+// GetTimes returns true if the table holds times, where the lowest is best, rather than points
+func (t *Type) GetTimes() bool {
+	return t.times
+}
+
+// order returns the order the table is sorted in, best first
+func (t *Type) order() dataframe.Order {
+	if t.times {
+		return dataframe.Sort("Points")
+	}
+	return dataframe.RevSort("Points")
+}
+
+// format returns a score from the table for display, times are shown in seconds
+func (t *Type) format(score string) string {
+	if !t.times {
+		return score
+	}
+	ms, err := strconv.Atoi(score)
+	if err != nil {
+		return score
+	}
+	return fmt.Sprintf("%.2fs", float64(ms)/1000)
+}
+
 // SaveScores saves the scores to a csv file
 func (t *Type) SaveScores() {
 	// If we haven't got any scores stop now
@@ -0,0 +1,135 @@
+package settings
+
+import (
+	"encoding/csv"
+	"sort"
+	"strconv"
+
+	"github.com/shibukawa/configdir"
+)
+
+// Type holds the user configuration for the game as a set of key/value pairs
+type Type struct {
+	values       map[string]string
+	settingsFile string
+	configDirs   configdir.ConfigDir
+}
+
+// NewSettings creates a new settings struct and loads any saved values
+func NewSettings(filename string) Type {
+	t := new(Type)
+	t.settingsFile = filename
+	t.values = map[string]string{}
+	t.configDirs = configdir.New("benjmarshall", "gopixelsnake")
+	t.LoadSettings()
+	return *t
+}
+
+// GetString returns the value stored for key, or def if it has not been set
+func (t *Type) GetString(key string, def string) string {
+	value, ok := t.values[key]
+	if !ok {
+		return def
+	}
+	return value
+}
+
+// GetInt returns the integer stored for key, or def if it has not been set or is invalid
+func (t *Type) GetInt(key string, def int) int {
+	value, err := strconv.Atoi(t.GetString(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetFloat returns the float stored for key, or def if it has not been set or is invalid
+func (t *Type) GetFloat(key string, def float64) float64 {
+	value, err := strconv.ParseFloat(t.GetString(key, ""), 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetBool returns the boolean stored for key, or def if it has not been set or is invalid
+func (t *Type) GetBool(key string, def bool) bool {
+	value, err := strconv.ParseBool(t.GetString(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// SetString stores a value for key
+func (t *Type) SetString(key string, value string) {
+	t.values[key] = value
+}
+
+// SetInt stores an integer value for key
+func (t *Type) SetInt(key string, value int) {
+	t.SetString(key, strconv.Itoa(value))
+}
+
+// SetFloat stores a float value for key
+func (t *Type) SetFloat(key string, value float64) {
+	t.SetString(key, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// SetBool stores a boolean value for key
+func (t *Type) SetBool(key string, value bool) {
+	t.SetString(key, strconv.FormatBool(value))
+}
+
+// SaveSettings saves the settings to a csv file
+func (t *Type) SaveSettings() {
+	folders := t.configDirs.QueryFolders(configdir.Global)
+
+	f, err := folders[0].Create(t.settingsFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	// Write the keys in order so the file is stable between saves
+	keys := []string{}
+	for key := range t.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := w.Write([]string{key, t.values[key]})
+		if err != nil {
+			return
+		}
+	}
+}
+
+// LoadSettings loads saved settings from a csv file
+func (t *Type) LoadSettings() {
+	folder := t.configDirs.QueryFolderContainsFile(t.settingsFile)
+	if folder != nil {
+		f, err := folder.Open(t.settingsFile)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		r := csv.NewReader(f)
+		records, err := r.ReadAll()
+		if err != nil {
+			return
+		}
+
+		for _, record := range records {
+			if len(record) != 2 {
+				continue
+			}
+			t.values[record[0]] = record[1]
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package snake
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/benjmarshall/gopixelsnake/game"
@@ -12,21 +16,171 @@ import (
 type Type struct {
 	headPos          pixel.Vec
 	tailPos          pixel.Vec
+	prevHeadPos      pixel.Vec
+	prevTailPos      pixel.Vec
+	lastUpdate       time.Time
 	length           float64
 	speed            float64
+	speedRamp        float64
 	currentDirection Direction
 	pointsList       []pixel.Vec
+	jumps            []pixel.Vec
+	portals          map[pixel.Vec]pixel.Vec
+	blocked          func(pos pixel.Vec) bool
+	teleported       bool
 	gameCFG          *game.Config
 	ticker           time.Ticker
 	tickerChannel    chan time.Time
 	startChannel     chan time.Time
+	effects          [NumEffects]int
+	ended            []Effect
+	shielded         bool
 }
 
+// startSpeed is the speed of a new snake, the speed never drops below it
+const startSpeed = 2
+
+// MaxSpeed is the fastest the snake can go, in steps a second, the speed never goes above it
+const MaxSpeed = 1000
+
+// CheckSpeed returns an error if the snake can't go at speed, which has to be from its starting speed up to
+// MaxSpeed
+func CheckSpeed(speed float64) error {
+	if !(speed >= startSpeed && speed <= MaxSpeed) {
+		return fmt.Errorf("the speed must be from %v to %v", startSpeed, MaxSpeed)
+	}
+	return nil
+}
+
+// StartLength is how long a new snake is, its body stretches out behind its head
+const StartLength = 5
+
+// minLength is the shortest the snake can be shrunk to, a head and a tail
+const minLength = 2
+
+// slowMotionFactor is how much the slow motion effect slows the snake's ticker down by
+const slowMotionFactor = 0.5
+
+// scoreMultiplier is how much the multiplier effect multiplies the score by
+const scoreMultiplier = 2
+
 // Direction is used to define the direction the snake is heading
 type Direction struct {
 	val pixel.Vec
 }
 
+// State is a snapshot of a snake, in grid coordinates, which can be saved and used to restore it later. Jumps
+// holds the direction the body goes through a portal from each point, or zero where it doesn't.
+type State struct {
+	HeadPos   pixel.Vec
+	TailPos   pixel.Vec
+	Points    []pixel.Vec
+	Jumps     []pixel.Vec
+	Length    float64
+	Speed     float64
+	Direction pixel.Vec
+}
+
+// Piece is the kind of snake piece drawn in a grid square
+type Piece int
+
+const (
+	// Head is the front of the snake.
+	Head Piece = iota
+	// Straight is a piece of body which carries straight on.
+	Straight
+	// Corner is a piece of body where the snake turned.
+	Corner
+	// Tail is the end of the snake.
+	Tail
+)
+
+// Collision is what the snake has run into, if anything
+type Collision int
+
+const (
+	// NoCollision means the snake can carry on.
+	NoCollision Collision = iota
+	// HitWall means the snake has left the game area.
+	HitWall
+	// HitSelf means the snake has run into its own body.
+	HitSelf
+	// HitHazard means the snake and one of the level's hazards have run into each other.
+	HitHazard
+	// HitSnake means the snake has run into another snake.
+	HitSnake
+)
+
+// String returns a description of the collision
+func (c Collision) String() string {
+	switch c {
+	case HitWall:
+		return "hit the wall"
+	case HitSelf:
+		return "hit itself"
+	case HitHazard:
+		return "hit a hazard"
+	case HitSnake:
+		return "hit another snake"
+	}
+	return "no collision"
+}
+
+// Effect is a timed effect on the snake, given to it by a power-up
+type Effect int
+
+const (
+	// Ghost lets the snake pass through its own body.
+	Ghost Effect = iota
+	// SlowMotion slows the snake's ticker down.
+	SlowMotion
+	// Magnet pulls nearby berries towards the snake's head.
+	Magnet
+	// Shield turns the snake away from the wall the first time it would hit it.
+	Shield
+	// Multiplier multiplies the score.
+	Multiplier
+	// NumEffects is the number of effects, it can be used to loop over all effects.
+	NumEffects
+)
+
+// effectNames are the names used for each effect in the settings file and on screen
+var effectNames = map[Effect]string{
+	Ghost:      "Ghost",
+	SlowMotion: "SlowMotion",
+	Magnet:     "Magnet",
+	Shield:     "Shield",
+	Multiplier: "Multiplier",
+}
+
+// String returns the name of the effect
+func (e Effect) String() string {
+	return effectNames[e]
+}
+
+// ActiveEffect is an effect on the snake and the number of steps the snake has left before it wears off
+type ActiveEffect struct {
+	Effect    Effect
+	TicksLeft int
+}
+
+// String returns the name of the effect and the steps it has left
+func (a ActiveEffect) String() string {
+	return fmt.Sprintf("%s %d", a.Effect, a.TicksLeft)
+}
+
+// Segment is one grid square of the snake. Front is the direction towards the head, or the way the snake is
+// heading for the head itself, and Back is the direction towards the tail, or away from the body for the tail.
+// Slide is how far the piece should be drawn from its square, in the game area coordinate plane, so that it
+// moves smoothly between steps.
+type Segment struct {
+	Pos   pixel.Vec
+	Piece Piece
+	Front Direction
+	Back  Direction
+	Slide pixel.Vec
+}
+
 var (
 	// UP is the Direction defining travel towards the top of the game area.
 	UP = Direction{pixel.V(0, 1)}
@@ -40,13 +194,67 @@ var (
 	NOCHANGE = Direction{pixel.V(0, 0)}
 )
 
+// TurnQueue is a bounded queue of turns requested by the player which have not yet been applied to the snake
+type TurnQueue struct {
+	turns []Direction
+	size  int
+}
+
+// NewTurnQueue returns an empty turn queue which holds at most size turns
+func NewTurnQueue(size int) TurnQueue {
+	if size < 1 {
+		size = 1
+	}
+	return TurnQueue{turns: []Direction{}, size: size}
+}
+
+// Push adds a turn to the back of the queue. The turn is compared with the heading the snake will have once
+// all the queued turns are applied, turns which would not change that heading or would reverse the snake
+// onto itself are discarded, as are turns pushed when the queue is full. Returns true if the turn was queued.
+func (q *TurnQueue) Push(dir Direction, heading Direction) bool {
+	if len(q.turns) > 0 {
+		heading = q.turns[len(q.turns)-1]
+	}
+	if dir == NOCHANGE || dir == heading || isOpposite(dir, heading) || len(q.turns) >= q.size {
+		return false
+	}
+	q.turns = append(q.turns, dir)
+	return true
+}
+
+// Pop removes and returns the turn at the front of the queue, or NOCHANGE if the queue is empty
+func (q *TurnQueue) Pop() Direction {
+	if len(q.turns) == 0 {
+		return NOCHANGE
+	}
+	dir := q.turns[0]
+	q.turns = q.turns[1:]
+	return dir
+}
+
+// Len returns the number of turns waiting in the queue
+func (q *TurnQueue) Len() int {
+	return len(q.turns)
+}
+
+// Clear removes all the turns from the queue
+func (q *TurnQueue) Clear() {
+	q.turns = []Direction{}
+}
+
 // NewSnake returns an initialised snake
 func NewSnake(gameCFG game.Config) Type {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return NewSeededSnake(gameCFG, time.Now().UnixNano())
+}
+
+// NewSeededSnake returns an initialised snake which always starts in the same place and direction for the same seed
+func NewSeededSnake(gameCFG game.Config, seed int64) Type {
+	r := rand.New(rand.NewSource(seed))
 	snake := new(Type)
 	snake.gameCFG = &gameCFG
-	snake.length = 5
-	snake.speed = 2
+	snake.length = StartLength
+	snake.speed = startSpeed
+	snake.speedRamp = 1
 	x, y := gameCFG.GetGameAreaDims()
 	snakeStartingMargin := 10
 	startingDimX := int(x/gameCFG.GetGridSize()) - snakeStartingMargin
@@ -73,6 +281,90 @@ func NewSnake(gameCFG game.Config) Type {
 	return *snake
 }
 
+// NewSnakeAt returns an initialised snake with its head in the grid square provided, heading in the direction dir
+// with its body stretched out behind it
+func NewSnakeAt(gameCFG game.Config, head pixel.Vec, dir Direction) Type {
+	snake := new(Type)
+	snake.gameCFG = &gameCFG
+	snake.length = StartLength
+	snake.speed = startSpeed
+	snake.speedRamp = 1
+	snake.headPos = head
+	snake.currentDirection = dir
+	snake.tailPos = snake.headPos.Sub(snake.currentDirection.val.Scaled(snake.length - 1))
+	return *snake
+}
+
+// NewSnakeFromState returns a snake restored from a snapshot, the snake's ticker is not started
+func NewSnakeFromState(gameCFG game.Config, state State) Type {
+	snake := new(Type)
+	snake.gameCFG = &gameCFG
+	snake.headPos = state.HeadPos
+	snake.tailPos = state.TailPos
+	snake.pointsList = append([]pixel.Vec{}, state.Points...)
+	snake.jumps = make([]pixel.Vec, len(state.Points))
+	if len(state.Jumps) == len(state.Points) {
+		copy(snake.jumps, state.Jumps)
+	}
+	snake.length = state.Length
+	snake.speed = state.Speed
+	snake.speedRamp = 1
+	snake.currentDirection = Direction{state.Direction}
+	return *snake
+}
+
+// GetState returns a snapshot of the snake
+func (s *Type) GetState() State {
+	return State{
+		HeadPos:   s.headPos,
+		TailPos:   s.tailPos,
+		Points:    append([]pixel.Vec{}, s.pointsList...),
+		Jumps:     append([]pixel.Vec{}, s.jumps...),
+		Length:    s.length,
+		Speed:     s.speed,
+		Direction: s.currentDirection.val,
+	}
+}
+
+// Check returns an error if the snapshot isn't a snake which could be on a grid the size of the game area: every
+// point has to be a grid square in it, each part of the body has to run straight from one point to the next unless
+// it jumps through a portal, and the length, speed and direction have to be ones the snake could have
+func (state State) Check(gameCFG *game.Config) error {
+	x, y := gameCFG.GetGameAreaDims()
+	cols, rows := x/gameCFG.GetGridSize(), y/gameCFG.GetGridSize()
+	if state.Length < minLength || math.IsNaN(state.Length) {
+		return errors.New("the snake is too short")
+	}
+	if state.Length > cols*rows {
+		return errors.New("the snake is too long")
+	}
+	if CheckSpeed(state.Speed) != nil {
+		return errors.New("the snake's speed is out of range")
+	}
+	heading := false
+	for _, d := range []Direction{UP, DOWN, LEFT, RIGHT} {
+		heading = heading || state.Direction == d.val
+	}
+	if !heading {
+		return errors.New("the snake's direction is wrong")
+	}
+	if len(state.Jumps) != 0 && len(state.Jumps) != len(state.Points) {
+		return errors.New("the snake's jumps don't match its points")
+	}
+	positions := append(append([]pixel.Vec{state.HeadPos}, state.Points...), state.TailPos)
+	for i, pos := range positions {
+		if pos.X < 0 || pos.Y < 0 || pos.X >= cols || pos.Y >= rows || pos.X != math.Trunc(pos.X) || pos.Y != math.Trunc(pos.Y) {
+			return errors.New("the snake isn't on the grid")
+		}
+		// The body goes through a portal from the point before this one if that point has a jump
+		jumped := i >= 2 && i-2 < len(state.Jumps) && state.Jumps[i-2] != pixel.ZV
+		if i > 0 && !jumped && positions[i-1].X != pos.X && positions[i-1].Y != pos.Y {
+			return errors.New("the snake's body isn't joined up")
+		}
+	}
+	return nil
+}
+
 // GetHeadPos returns the position of the head of the snake in the game area coordinate plane
 func (s *Type) GetHeadPos() pixel.Vec {
 	return s.gameCFG.GetGridMatrix().Project(s.headPos)
@@ -92,30 +384,227 @@ func (s *Type) GetPositionPoints() []pixel.Vec {
 	return positions
 }
 
+// cells returns every grid square of the snake in order from the head to the tail, in grid coordinates
+func (s *Type) cells() []pixel.Vec {
+	cells, _ := s.cellsAndJumps()
+	return cells
+}
+
+// cellsAndJumps returns every grid square of the snake in order from the head to the tail, in grid coordinates,
+// and for each square the direction the snake went through a portal to get from the next square to it, or zero
+// if the next square is next to it
+func (s *Type) cellsAndJumps() ([]pixel.Vec, []pixel.Vec) {
+	// Walk along the snake from the head, through each turn point, to the tail collecting every square. Where
+	// the body goes through a portal it jumps straight to the square on the other side.
+	positions := []pixel.Vec{s.headPos}
+	positions = append(positions, s.pointsList...)
+	positions = append(positions, s.tailPos)
+	cells := []pixel.Vec{s.headPos}
+	jumps := []pixel.Vec{pixel.ZV}
+	for i := 0; i < len(positions)-1; i++ {
+		if i > 0 && s.jumps[i-1] != pixel.ZV {
+			jumps[len(jumps)-1] = s.jumps[i-1]
+			cells = append(cells, positions[i+1])
+			jumps = append(jumps, pixel.ZV)
+			continue
+		}
+		step := positions[i].To(positions[i+1])
+		steps := int(step.Len() + 0.5)
+		if steps == 0 {
+			continue
+		}
+		step = step.Scaled(1 / float64(steps))
+		for j := 1; j <= steps; j++ {
+			cells = append(cells, positions[i].Add(step.Scaled(float64(j))))
+			jumps = append(jumps, pixel.ZV)
+		}
+	}
+	return cells, jumps
+}
+
+// Segments returns every grid square of the snake in order from the head to the tail, with positions in the
+// game area coordinate plane
+func (s *Type) Segments() []Segment {
+	cells, jumps := s.cellsAndJumps()
+	segments := make([]Segment, len(cells))
+	for i, cell := range cells {
+		seg := Segment{Pos: s.gameCFG.GetGridMatrix().Project(cell)}
+		// Squares either side of a portal aren't next to each other, they are joined to the portal's side of
+		// their square instead
+		if i == 0 {
+			seg.Front = s.currentDirection
+		} else if jumps[i-1] != pixel.ZV {
+			seg.Front = Direction{jumps[i-1]}
+		} else {
+			seg.Front = Direction{cell.To(cells[i-1])}
+		}
+		if i == len(cells)-1 {
+			seg.Back = Direction{seg.Front.val.Scaled(-1)}
+		} else if jumps[i] != pixel.ZV {
+			seg.Back = Direction{jumps[i].Scaled(-1)}
+		} else {
+			seg.Back = Direction{cell.To(cells[i+1])}
+		}
+		switch {
+		case i == 0:
+			seg.Piece = Head
+		case i == len(cells)-1:
+			seg.Piece = Tail
+		case isOpposite(seg.Front, seg.Back):
+			seg.Piece = Straight
+		default:
+			seg.Piece = Corner
+		}
+		segments[i] = seg
+	}
+	return segments
+}
+
+// InterpolatedSegments returns the segments of the snake with the head and tail slid back towards where they were
+// before the last step, so they can be drawn part way between the two. The fraction is how far through the step
+// to draw them, where 1 draws them in their grid squares.
+func (s *Type) InterpolatedSegments(fraction float64) []Segment {
+	segments := s.Segments()
+	back := 1 - fraction
+	head := &segments[0]
+	head.Slide = s.gameCFG.GetGridMatrix().Project(s.prevHeadPos).Sub(head.Pos).Scaled(back)
+	if len(segments) > 1 && isNextTo(s.prevTailPos, s.tailPos) {
+		tail := &segments[len(segments)-1]
+		tail.Slide = s.gameCFG.GetGridMatrix().Project(s.prevTailPos).Sub(tail.Pos).Scaled(back)
+	}
+	return segments
+}
+
+// GetTickFraction returns how far the snake is through its current step, from 0 just after it moved to 1 when
+// it is due to move again. It is 1 if the snake hasn't moved yet, or has stopped moving.
+func (s *Type) GetTickFraction() float64 {
+	if s.lastUpdate.IsZero() {
+		return 1
+	}
+	return math.Min(float64(time.Since(s.lastUpdate))/float64(s.tickInterval()), 1)
+}
+
+// GetLength returns the number of grid squares the snake is long
+func (s *Type) GetLength() int {
+	return int(s.length)
+}
+
 // GetSpeed returns the snake speed multiplier
 func (s *Type) GetSpeed() float64 {
 	return s.speed
 }
 
+// GetDirection returns the direction the snake is currently heading
+func (s *Type) GetDirection() Direction {
+	return s.currentDirection
+}
+
 // GetTicker returns the snake speed ticker
 func (s *Type) GetTicker() <-chan time.Time {
 	return s.tickerChannel
 }
 
-// IncreaseSpeed increase the speed of the snake
+// SetSpeedRamp sets how much the speed increases each time IncreaseSpeed is called, the default is 1
+func (s *Type) SetSpeedRamp(ramp float64) {
+	s.speedRamp = ramp
+}
+
+// IncreaseSpeed increase the speed of the snake, up to MaxSpeed
 func (s *Type) IncreaseSpeed() {
-	s.speed++
+	s.speed = math.Min(s.speed+s.speedRamp, MaxSpeed)
+	s.restartTicker()
+}
+
+// SetSpeed sets the speed of the snake, e.g. to start a level faster, it is never set below the starting speed or
+// above MaxSpeed
+func (s *Type) SetSpeed(speed float64) {
+	s.speed = math.Min(math.Max(speed, startSpeed), MaxSpeed)
+	s.restartTicker()
+}
+
+// DecreaseSpeed undoes one call to IncreaseSpeed, the snake never goes slower than it started
+func (s *Type) DecreaseSpeed() {
+	s.speed = math.Max(s.speed-s.speedRamp, startSpeed)
+	s.restartTicker()
+}
+
+// Shrink shortens the snake by moving its tail up to n squares towards its head, it never gets shorter than a
+// head and a tail
+func (s *Type) Shrink(n int) {
+	for i := 0; i < n && s.length > minLength; i++ {
+		s.dropPassedTurn()
+		s.moveTail()
+		s.length--
+	}
+	// Don't slide the tail back over the squares it has jumped
+	s.prevTailPos = s.tailPos
+}
+
+// AddEffect gives the snake an effect for the number of steps provided, an effect the snake already has is topped
+// up rather than added to
+func (s *Type) AddEffect(e Effect, ticks int) {
+	slowed := s.HasEffect(SlowMotion)
+	if ticks > s.effects[e] {
+		s.effects[e] = ticks
+	}
+	if s.HasEffect(SlowMotion) != slowed {
+		s.restartTicker()
+	}
+}
+
+// HasEffect returns true if the snake has the effect
+func (s *Type) HasEffect(e Effect) bool {
+	return s.effects[e] > 0
+}
+
+// GetEffects returns the effects the snake has, in the order they are declared
+func (s *Type) GetEffects() []ActiveEffect {
+	active := []ActiveEffect{}
+	for e := Effect(0); e < NumEffects; e++ {
+		if s.HasEffect(e) {
+			active = append(active, ActiveEffect{e, s.effects[e]})
+		}
+	}
+	return active
+}
+
+// GetEndedEffects returns the effects which wore off, or were used up, on the snake's last step
+func (s *Type) GetEndedEffects() []Effect {
+	return s.ended
+}
+
+// GetShielded returns true if the shield turned the snake away from the wall on its last step
+func (s *Type) GetShielded() bool {
+	return s.shielded
+}
+
+// GetScoreMultiplier returns how much the score should be multiplied by while the snake has the multiplier effect
+func (s *Type) GetScoreMultiplier() float64 {
+	if s.HasEffect(Multiplier) {
+		return scoreMultiplier
+	}
+	return 1
+}
+
+// restartTicker starts a new ticker for the snake's current speed
+func (s *Type) restartTicker() {
+	if s.startChannel == nil {
+		// The ticker hasn't been started yet, StartOfGame will start it at the right speed
+		return
+	}
 	// Shut down the old ticker and channel multiplex
 	close(s.startChannel)
 	s.ticker.Stop()
 	// Start up the new ones
 	s.startChannel = make(chan time.Time)
-	s.ticker = *time.NewTicker(time.Second / time.Duration(s.speed))
+	s.ticker = *time.NewTicker(s.tickInterval())
 	go tickerMultiplex(s.tickerChannel, s.ticker.C, s.startChannel)
 }
 
 // Update is used to Update the status of snake position and speed.
 func (s *Type) Update(eaten bool, dir Direction) {
+	s.tickEffects()
+
 	// If the snake has eaten let's  the length
 	if eaten {
 		s.length++
@@ -124,77 +613,211 @@ func (s *Type) Update(eaten bool, dir Direction) {
 	if dir != NOCHANGE {
 		//log.Println("Changing direction")
 		// Ignore a request to change to the opposite direction
-		if !(dir == UP && s.currentDirection == DOWN) &&
-			!(dir == DOWN && s.currentDirection == UP) &&
-			!(dir == LEFT && s.currentDirection == RIGHT) &&
-			!(dir == RIGHT && s.currentDirection == LEFT) {
+		if !isOpposite(dir, s.currentDirection) {
 			// Update the direction
 			s.currentDirection = dir
 			// Push the current head position into the points stack
-			s.pointsList = append([]pixel.Vec{s.headPos}, s.pointsList...)
+			s.pushPoint(s.headPos, pixel.ZV)
 		}
 	}
-	if len(s.pointsList) > 0 {
-		if s.tailPos == s.pointsList[len(s.pointsList)-1] {
-			// If the tail is on our last point the remove it from the current stack
-			if len(s.pointsList) <= 1 {
-				s.pointsList = []pixel.Vec{}
-			} else {
-				s.pointsList = s.pointsList[0 : len(s.pointsList)-1]
-			}
-		}
+	// If the shield is up turn away from the wall rather than hit it
+	if s.HasEffect(Shield) && !s.inBounds(s.headPos.Add(s.currentDirection.val)) {
+		s.deflect()
 	}
+	s.dropPassedTurn()
+
+	// Remember where the snake was so it can be drawn moving smoothly to its new position
+	s.prevHeadPos = s.headPos
+	s.prevTailPos = s.tailPos
+	s.lastUpdate = time.Now()
 
 	// Update the head position
 	s.headPos = s.headPos.Add(s.currentDirection.val)
 	// Update the tail position (if we have eaten a berry, leave the tail where it is)
 	if !eaten {
-		if len(s.pointsList) == 0 {
-			s.tailPos = s.tailPos.Add(s.currentDirection.val)
-		} else {
-			vec := s.tailPos.To(s.pointsList[len(s.pointsList)-1]).Unit()
-			s.tailPos = s.tailPos.Add(vec)
+		s.moveTail()
+	}
+
+	// If the head has gone into a portal it comes out of the other one heading the same way. The body jumps
+	// from the square before the portal to the exit, and the head slides out of the exit.
+	s.teleported = false
+	if exit, ok := s.portals[s.headPos]; ok {
+		s.pushPoint(s.prevHeadPos, pixel.ZV)
+		s.pushPoint(exit, s.currentDirection.val)
+		s.headPos = exit
+		s.prevHeadPos = exit.Sub(s.currentDirection.val)
+		s.teleported = true
+	}
+}
+
+// SetPortals sets the portals the snake can go through, each grid square maps to the grid square of its partner
+func (s *Type) SetPortals(portals map[pixel.Vec]pixel.Vec) {
+	s.portals = portals
+}
+
+// SetBlocked sets the check for grid squares the shield should turn the snake away from as well as the edge of the
+// game area, e.g. walls. It is given positions in the game area coordinate plane.
+func (s *Type) SetBlocked(blocked func(pos pixel.Vec) bool) {
+	s.blocked = blocked
+}
+
+// GetTeleported returns true if the snake went through a portal on its last step
+func (s *Type) GetTeleported() bool {
+	return s.teleported
+}
+
+// pushPoint pushes a grid position onto the front of the points stack, unless it is already there. If jump isn't
+// zero the body went through a portal in that direction between the point and the next one towards the tail,
+// rather than running along a line between them.
+func (s *Type) pushPoint(pos pixel.Vec, jump pixel.Vec) {
+	if len(s.pointsList) > 0 && s.pointsList[0] == pos {
+		if jump != pixel.ZV {
+			s.jumps[0] = jump
+		}
+		return
+	}
+	s.pointsList = append([]pixel.Vec{pos}, s.pointsList...)
+	s.jumps = append([]pixel.Vec{jump}, s.jumps...)
+}
+
+// tickEffects counts down the steps left for each of the snake's effects, noting any which wear off
+func (s *Type) tickEffects() {
+	s.ended = []Effect{}
+	s.shielded = false
+	slowed := s.HasEffect(SlowMotion)
+	for e := Effect(0); e < NumEffects; e++ {
+		if s.HasEffect(e) {
+			s.effects[e]--
+			if !s.HasEffect(e) {
+				s.ended = append(s.ended, e)
+			}
 		}
 	}
+	if slowed && !s.HasEffect(SlowMotion) {
+		s.restartTicker()
+	}
+}
+
+// deflect uses up the shield to turn the snake along the wall it is heading into, preferring a direction which
+// doesn't run into its body
+func (s *Type) deflect() {
+	turns := []Direction{{pixel.V(s.currentDirection.val.Y, s.currentDirection.val.X)}, {pixel.V(-s.currentDirection.val.Y, -s.currentDirection.val.X)}}
+	best := NOCHANGE
+	for _, dir := range turns {
+		next := s.headPos.Add(dir.val)
+		if !s.inBounds(next) {
+			continue
+		}
+		if best == NOCHANGE || !s.onBody(next) {
+			best = dir
+		}
+	}
+	if best == NOCHANGE {
+		return
+	}
+	s.currentDirection = best
+	s.pushPoint(s.headPos, pixel.ZV)
+	s.effects[Shield] = 0
+	s.ended = append(s.ended, Shield)
+	s.shielded = true
+}
+
+// inBounds returns true if the grid position is inside the game area and not blocked
+func (s *Type) inBounds(pos pixel.Vec) bool {
+	areaPos := s.gameCFG.GetGridMatrix().Project(pos)
+	return s.gameCFG.GetGameAreaAsRec().Contains(areaPos) && (s.blocked == nil || !s.blocked(areaPos))
+}
+
+// dropPassedTurn removes the last turn from the points stack once the tail has reached it
+func (s *Type) dropPassedTurn() {
+	if len(s.pointsList) > 0 {
+		if s.tailPos == s.pointsList[len(s.pointsList)-1] {
+			// If the tail is on our last point the remove it from the current stack
+			s.dropLastPoint()
+		}
+	}
+}
+
+// dropLastPoint removes the point nearest the tail from the points stack
+func (s *Type) dropLastPoint() {
+	if len(s.pointsList) <= 1 {
+		s.pointsList = []pixel.Vec{}
+		s.jumps = []pixel.Vec{}
+	} else {
+		s.pointsList = s.pointsList[0 : len(s.pointsList)-1]
+		s.jumps = s.jumps[0 : len(s.jumps)-1]
+	}
+}
+
+// moveTail moves the tail one square along the body towards the head, jumping through a portal if the body does
+func (s *Type) moveTail() {
+	if len(s.pointsList) == 0 {
+		s.tailPos = s.tailPos.Add(s.currentDirection.val)
+	} else if last := len(s.pointsList) - 1; s.jumps[last] != pixel.ZV {
+		s.tailPos = s.pointsList[last]
+		s.dropLastPoint()
+	} else {
+		vec := s.tailPos.To(s.pointsList[last]).Unit()
+		s.tailPos = s.tailPos.Add(vec)
+	}
 }
 
 // CheckSnakeOK is used to check the snake hasn't exicted the game area and has not hit itself
 func (s *Type) CheckSnakeOK(gameCFG *game.Config) bool {
+	return s.CheckCollision(gameCFG) == NoCollision
+}
+
+// CheckCollision returns what the snake's head has run into, if anything
+func (s *Type) CheckCollision(gameCFG *game.Config) Collision {
 
 	// Check snake is inside the game boundary
 	if !gameCFG.GetGameAreaAsRec().Contains(s.GetHeadPos()) {
-		return false
+		return HitWall
 	}
 
-	// Check snake hasn't hit itself
-	// First collect all the turn positions of the snake (minus the head)
-	positions := []pixel.Vec{}
-	positions = append(positions, s.pointsList...)
-	positions = append(positions, s.tailPos)
-	// Loop over the positions
-	for i := 0; i < len(positions)-1; i++ {
-		// Get the length of the line from one turn position to the next
-		l := positions[i].To(positions[i+1]).Len() + 1
-		// Now add all of the points along the line to a new slice
-		subPositions := []pixel.Vec{positions[i]}
-		if l > 2 {
-			for j := 1.0; j < l-2+1; j++ {
-				// We use linear interpolation here to get the points inbetween the end vectors
-				interpPoint := j / (l - 1)
-				interpVal := pixel.Lerp(positions[i], positions[i+1], interpPoint)
-				subPositions = append(subPositions, interpVal)
-			}
+	// Check snake hasn't hit itself, ghosts can pass through their own body
+	if !s.HasEffect(Ghost) && s.onBody(s.headPos) {
+		return HitSelf
+	}
+	return NoCollision
+}
+
+// Occupies returns true if any part of the snake is in the grid square at pos, which is in the game area coordinate plane
+func (s *Type) Occupies(pos pixel.Vec) bool {
+	for _, seg := range s.Segments() {
+		if seg.Pos == pos {
+			return true
 		}
-		subPositions = append(subPositions, positions[i+1])
-		// Now loop over the slice of points along the line and see if we have a colision.
-		for _, subPos := range subPositions {
-			if s.headPos == subPos {
-				return false
-			}
+	}
+	return false
+}
+
+// SafeMoves returns the squares next to the head, in the game area coordinate plane, which the snake
+// can move into on its next step without leaving the game area or hitting itself, unless it is a ghost
+func (s *Type) SafeMoves(gameCFG *game.Config) []pixel.Vec {
+	safe := []pixel.Vec{}
+	for _, dir := range []Direction{UP, DOWN, LEFT, RIGHT} {
+		if isOpposite(dir, s.currentDirection) {
+			continue
+		}
+		next := s.headPos.Add(dir.val)
+		pos := gameCFG.GetGridMatrix().Project(next)
+		if !gameCFG.GetGameAreaAsRec().Contains(pos) || (!s.HasEffect(Ghost) && s.onBody(next)) {
+			continue
 		}
+		safe = append(safe, pos)
 	}
+	return safe
+}
 
-	return true
+// onBody returns true if the grid position is on the snake's body, which is every square but the head
+func (s *Type) onBody(pos pixel.Vec) bool {
+	for _, cell := range s.cells()[1:] {
+		if pos == cell {
+			return true
+		}
+	}
+	return false
 }
 
 // CheckIfSnakeHasEaten is used to check the snake has easten the berry
@@ -214,14 +837,11 @@ func (s *Type) StartOfGame(dir Direction) {
 	// send an immediate trigger to start the first frame
 	s.tickerChannel = make(chan time.Time)
 	s.startChannel = make(chan time.Time)
-	s.ticker = *time.NewTicker(time.Second / time.Duration(s.speed))
+	s.ticker = *time.NewTicker(s.tickInterval())
 	go tickerMultiplex(s.tickerChannel, s.ticker.C, s.startChannel)
 	s.startChannel <- time.Now()
 
-	if (dir == UP && s.currentDirection == DOWN) ||
-		(dir == DOWN && s.currentDirection == UP) ||
-		(dir == LEFT && s.currentDirection == RIGHT) ||
-		(dir == RIGHT && s.currentDirection == LEFT) {
+	if isOpposite(dir, s.currentDirection) {
 		// User has started in opposite direction, switch head and tail.
 		tempPos := s.headPos
 		s.headPos = s.tailPos
@@ -230,6 +850,55 @@ func (s *Type) StartOfGame(dir Direction) {
 	}
 }
 
+// tickInterval returns the time between steps at the snake's current speed, which is slower in slow motion
+func (s *Type) tickInterval() time.Duration {
+	speed := s.speed
+	if s.HasEffect(SlowMotion) {
+		speed *= slowMotionFactor
+	}
+	return time.Duration(float64(time.Second) / speed)
+}
+
+// GetVec returns the unit vector of the direction, in grid squares
+func (d Direction) GetVec() pixel.Vec {
+	return d.val
+}
+
+// String returns the name of the direction
+func (d Direction) String() string {
+	switch d {
+	case UP:
+		return "up"
+	case DOWN:
+		return "down"
+	case LEFT:
+		return "left"
+	case RIGHT:
+		return "right"
+	}
+	return "no change"
+}
+
+// DirectionFromName returns the direction with the name provided, as produced by Direction.String()
+func DirectionFromName(name string) (Direction, bool) {
+	for _, d := range []Direction{UP, DOWN, LEFT, RIGHT, NOCHANGE} {
+		if d.String() == strings.ToLower(name) {
+			return d, true
+		}
+	}
+	return NOCHANGE, false
+}
+
+// isNextTo returns true if the two grid positions are side by side
+func isNextTo(a pixel.Vec, b pixel.Vec) bool {
+	return a.To(b).Len() == 1
+}
+
+// isOpposite returns true if the two directions point opposite ways
+func isOpposite(a Direction, b Direction) bool {
+	return a != NOCHANGE && a.val.Add(b.val) == pixel.ZV
+}
+
 // tickerMultiplex is used to allow us to send an immdidiate pulse into the 'ticker' channel upon creation
 func tickerMultiplex(out chan<- time.Time, tickerIn <-chan time.Time, startIn <-chan time.Time) {
 	for true {
@@ -0,0 +1,320 @@
+package snake
+
+import (
+	"testing"
+
+	"github.com/benjmarshall/gopixelsnake/game"
+	"github.com/faiface/pixel"
+)
+
+func TestTurnQueuePush(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		heading Direction
+		pushes  []Direction
+		queued  []bool
+		want    []Direction
+	}{
+		{
+			name:    "two quick perpendicular turns are both kept in order",
+			size:    2,
+			heading: RIGHT,
+			pushes:  []Direction{UP, LEFT},
+			queued:  []bool{true, true},
+			want:    []Direction{UP, LEFT},
+		},
+		{
+			name:    "reversing the current heading is discarded",
+			size:    2,
+			heading: RIGHT,
+			pushes:  []Direction{LEFT},
+			queued:  []bool{false},
+			want:    []Direction{},
+		},
+		{
+			name:    "reversing the queued heading is discarded",
+			size:    3,
+			heading: RIGHT,
+			pushes:  []Direction{UP, DOWN},
+			queued:  []bool{true, false},
+			want:    []Direction{UP},
+		},
+		{
+			name:    "turning back the current way after a queued turn is kept",
+			size:    3,
+			heading: RIGHT,
+			pushes:  []Direction{UP, RIGHT},
+			queued:  []bool{true, true},
+			want:    []Direction{UP, RIGHT},
+		},
+		{
+			name:    "turning the way the snake is heading is discarded",
+			size:    2,
+			heading: RIGHT,
+			pushes:  []Direction{RIGHT},
+			queued:  []bool{false},
+			want:    []Direction{},
+		},
+		{
+			name:    "a repeated turn is discarded",
+			size:    3,
+			heading: RIGHT,
+			pushes:  []Direction{UP, UP},
+			queued:  []bool{true, false},
+			want:    []Direction{UP},
+		},
+		{
+			name:    "no change is discarded",
+			size:    2,
+			heading: RIGHT,
+			pushes:  []Direction{NOCHANGE},
+			queued:  []bool{false},
+			want:    []Direction{},
+		},
+		{
+			name:    "a turn pushed when the queue is full is rejected",
+			size:    2,
+			heading: RIGHT,
+			pushes:  []Direction{UP, LEFT, DOWN},
+			queued:  []bool{true, true, false},
+			want:    []Direction{UP, LEFT},
+		},
+		{
+			name:    "a queue is never smaller than one turn",
+			size:    0,
+			heading: RIGHT,
+			pushes:  []Direction{UP, LEFT},
+			queued:  []bool{true, false},
+			want:    []Direction{UP},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewTurnQueue(tt.size)
+			for i, dir := range tt.pushes {
+				if got := q.Push(dir, tt.heading); got != tt.queued[i] {
+					t.Errorf("Push(%v) = %v, want %v", dir, got, tt.queued[i])
+				}
+			}
+			if q.Len() != len(tt.want) {
+				t.Fatalf("Len() = %d, want %d", q.Len(), len(tt.want))
+			}
+			for _, want := range tt.want {
+				if got := q.Pop(); got != want {
+					t.Errorf("Pop() = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTurnQueuePopAndClear(t *testing.T) {
+	tests := []struct {
+		name   string
+		pushes []Direction
+		clear  bool
+		want   []Direction
+	}{
+		{
+			name: "popping an empty queue gives no change",
+			want: []Direction{NOCHANGE},
+		},
+		{
+			name:   "popping takes turns from the front until the queue is empty",
+			pushes: []Direction{UP, LEFT},
+			want:   []Direction{UP, LEFT, NOCHANGE},
+		},
+		{
+			name:   "clearing removes every turn",
+			pushes: []Direction{UP, LEFT},
+			clear:  true,
+			want:   []Direction{NOCHANGE},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewTurnQueue(3)
+			for _, dir := range tt.pushes {
+				q.Push(dir, RIGHT)
+			}
+			if tt.clear {
+				q.Clear()
+				if q.Len() != 0 {
+					t.Fatalf("Len() after Clear() = %d, want 0", q.Len())
+				}
+				// The queue compares new turns with the snake's heading again once it has been cleared
+				if !q.Push(DOWN, RIGHT) {
+					t.Errorf("Push(DOWN) after Clear() was discarded")
+				}
+				q.Pop()
+			}
+			for _, want := range tt.want {
+				if got := q.Pop(); got != want {
+					t.Errorf("Pop() = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestShieldDeflects(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	grid := gameCFG.GetGridMatrix()
+	tests := []struct {
+		name     string
+		head     pixel.Vec
+		blocked  []pixel.Vec
+		shield   bool
+		want     pixel.Vec
+		shielded bool
+	}{
+		{
+			name:     "off the edge of the game area",
+			head:     pixel.V(19, 10),
+			shield:   true,
+			want:     pixel.V(19, 9),
+			shielded: true,
+		},
+		{
+			name:     "off a blocked square",
+			head:     pixel.V(10, 10),
+			blocked:  []pixel.Vec{pixel.V(11, 10)},
+			shield:   true,
+			want:     pixel.V(10, 9),
+			shielded: true,
+		},
+		{
+			name:     "away from a blocked square on one side",
+			head:     pixel.V(10, 10),
+			blocked:  []pixel.Vec{pixel.V(11, 10), pixel.V(10, 9)},
+			shield:   true,
+			want:     pixel.V(10, 11),
+			shielded: true,
+		},
+		{
+			name:    "not when blocked on both sides",
+			head:    pixel.V(10, 10),
+			blocked: []pixel.Vec{pixel.V(11, 10), pixel.V(10, 11), pixel.V(10, 9)},
+			shield:  true,
+			want:    pixel.V(11, 10),
+		},
+		{
+			name:    "not without a shield",
+			head:    pixel.V(10, 10),
+			blocked: []pixel.Vec{pixel.V(11, 10)},
+			want:    pixel.V(11, 10),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSnakeAt(gameCFG, tt.head, RIGHT)
+			blocked := map[pixel.Vec]bool{}
+			for _, square := range tt.blocked {
+				blocked[grid.Project(square)] = true
+			}
+			s.SetBlocked(func(pos pixel.Vec) bool { return blocked[pos] })
+			if tt.shield {
+				s.AddEffect(Shield, 10)
+			}
+			s.Update(false, NOCHANGE)
+			if got := s.GetHeadPos(); got != grid.Project(tt.want) {
+				t.Errorf("head moved to %v, want %v", got, grid.Project(tt.want))
+			}
+			if s.GetShielded() != tt.shielded {
+				t.Errorf("GetShielded() = %v, want %v", s.GetShielded(), tt.shielded)
+			}
+			if tt.shielded && s.HasEffect(Shield) {
+				t.Errorf("the shield wasn't used up")
+			}
+		})
+	}
+}
+
+func TestSpeedLimits(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	s := NewSnakeAt(gameCFG, pixel.V(8, 10), RIGHT)
+	s.SetSpeed(1e12)
+	if s.GetSpeed() != MaxSpeed {
+		t.Errorf("SetSpeed(1e12) set the speed to %v, want %v", s.GetSpeed(), MaxSpeed)
+	}
+	s.IncreaseSpeed()
+	if s.GetSpeed() != MaxSpeed {
+		t.Errorf("IncreaseSpeed() at the top speed set the speed to %v, want %v", s.GetSpeed(), MaxSpeed)
+	}
+	if err := s.GetState().Check(&gameCFG); err != nil {
+		t.Errorf("Check() on a snake at the top speed: %v", err)
+	}
+	s.SetSpeed(0)
+	if s.GetSpeed() != startSpeed {
+		t.Errorf("SetSpeed(0) set the speed to %v, want %v", s.GetSpeed(), startSpeed)
+	}
+}
+
+// checkBody checks the snake's squares run from head to tail without gaps, except where the body jumps from the
+// square next to a portal's entrance to the square of its exit, and that the snake is as long as it should be
+func checkBody(t *testing.T, s *Type, portals map[pixel.Vec]pixel.Vec) {
+	t.Helper()
+	cells := s.cells()
+	if len(cells) != s.GetLength() {
+		t.Fatalf("the snake has %d squares, want %d", len(cells), s.GetLength())
+	}
+	for i := 1; i < len(cells); i++ {
+		if cells[i].To(cells[i-1]).Len() == 1 {
+			continue
+		}
+		jumped := false
+		for _, dir := range []Direction{UP, DOWN, LEFT, RIGHT} {
+			if exit, ok := portals[cells[i].Add(dir.GetVec())]; ok && exit == cells[i-1] {
+				jumped = true
+			}
+		}
+		if !jumped {
+			t.Fatalf("the body has a gap between %v and %v which isn't a portal", cells[i], cells[i-1])
+		}
+	}
+}
+
+func TestTailFollowsThroughPortal(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	portals := map[pixel.Vec]pixel.Vec{pixel.V(8, 10): pixel.V(12, 3), pixel.V(12, 3): pixel.V(8, 10)}
+	s := NewSnakeAt(gameCFG, pixel.V(5, 10), RIGHT)
+	s.SetPortals(portals)
+	// Three steps to reach the entrance and five more for the rest of the snake to follow
+	for i := 0; i < 8; i++ {
+		s.Update(false, NOCHANGE)
+		if c := s.CheckCollision(&gameCFG); c != NoCollision {
+			t.Fatalf("step %d: the snake collided (%v) going through the portal", i+1, c)
+		}
+		if err := s.GetState().Check(&gameCFG); err != nil {
+			t.Fatalf("step %d: %v", i+1, err)
+		}
+		checkBody(t, &s, portals)
+	}
+	// The whole snake has come out of the exit and carries on along the row
+	for _, cell := range s.cells() {
+		if cell.Y != 3 {
+			t.Fatalf("the snake is at %v, want all of it on the row of the exit", s.cells())
+		}
+	}
+	if len(s.GetState().Points) != 0 {
+		t.Errorf("the snake still has turn points %v once its tail is through the portal", s.GetState().Points)
+	}
+}
+
+func TestPortalBodyNoFalseCollision(t *testing.T) {
+	gameCFG := game.NewGameConfig(200, 200, 2, 10, pixel.R(0, 0, 300, 220))
+	// The exit is behind the entrance on the same row, so the snake goes round and round the squares between them
+	// with its head coming up behind its own tail. A body drawn straight from the exit to the entrance would cover
+	// the squares in front of the head.
+	portals := map[pixel.Vec]pixel.Vec{pixel.V(10, 10): pixel.V(3, 10), pixel.V(3, 10): pixel.V(10, 10)}
+	s := NewSnakeAt(gameCFG, pixel.V(8, 10), RIGHT)
+	s.SetPortals(portals)
+	for i := 0; i < 30; i++ {
+		s.Update(false, NOCHANGE)
+		if c := s.CheckCollision(&gameCFG); c != NoCollision {
+			t.Fatalf("step %d: the snake at %v collided (%v) with the far side of the portal", i+1, s.cells(), c)
+		}
+		checkBody(t, &s, portals)
+	}
+}
@@ -0,0 +1,117 @@
+package sprites
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"os"
+
+	// Sprite sheets are PNG images
+	_ "image/png"
+
+	"github.com/benjmarshall/gopixelsnake/snake"
+	"github.com/faiface/pixel"
+)
+
+// Sheet is a sprite sheet holding the snake pieces. The sheet is a row of four square frames, which are the head,
+// straight body, corner and tail pieces in that order. Each frame is drawn as if the snake were heading up the
+// screen, so the head faces up, the straight piece runs from top to bottom, the corner joins the top and right
+// edges and the tail joins the body at the top. The frames are rotated to match the way each piece faces.
+type Sheet struct {
+	img  image.Image
+	size int
+}
+
+// sheets holds the sheets which have already been loaded, so switching themes doesn't reload them
+var sheets = map[string]*Sheet{}
+
+// Load loads a sprite sheet from a PNG file
+func Load(filename string) (*Sheet, error) {
+	if sheet, ok := sheets[filename]; ok {
+		return sheet, nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	size := img.Bounds().Dy()
+	if size == 0 || img.Bounds().Dx() < 4*size {
+		return nil, errors.New("sprite sheets must be a row of four square frames")
+	}
+
+	sheet := &Sheet{img: img, size: size}
+	sheets[filename] = sheet
+	return sheet, nil
+}
+
+// GetImage returns the whole sprite sheet image
+func (s *Sheet) GetImage() image.Image {
+	return s.img
+}
+
+// Frame returns the part of the sheet image holding the piece
+func (s *Sheet) Frame(p snake.Piece) image.Rectangle {
+	min := s.img.Bounds().Min.Add(image.Pt(int(p)*s.size, 0))
+	return image.Rectangle{Min: min, Max: min.Add(image.Pt(s.size, s.size))}
+}
+
+// At returns the colour of the piece, rotated by angle, at x and y. These are measured from the centre of the
+// square the piece is drawn in, as a fraction of its size, with y pointing up.
+func (s *Sheet) At(p snake.Piece, angle float64, x float64, y float64) color.Color {
+	// Undo the rotation to find the point on the frame
+	sin, cos := math.Sincos(-angle)
+	fx := x*cos - y*sin
+	fy := x*sin + y*cos
+	frame := s.Frame(p)
+	px := frame.Min.X + clamp(int((fx+0.5)*float64(s.size)), s.size)
+	py := frame.Min.Y + clamp(int((0.5-fy)*float64(s.size)), s.size)
+	return s.img.At(px, py)
+}
+
+// Angle returns the angle, in radians anticlockwise, the frame for the segment should be rotated by
+func Angle(seg snake.Segment) float64 {
+	up := pixel.V(0, 1)
+	right := pixel.V(1, 0)
+	front := seg.Front.GetVec()
+	back := seg.Back.GetVec()
+	for turn := 0; turn < 4; turn++ {
+		angle := float64(turn) * math.Pi / 2
+		if seg.Piece != snake.Corner {
+			if rotate(up, angle) == front {
+				return angle
+			}
+			continue
+		}
+		// The corner frame is symmetric, so it can join its edges either way round
+		u, r := rotate(up, angle), rotate(right, angle)
+		if (u == front && r == back) || (u == back && r == front) {
+			return angle
+		}
+	}
+	return 0
+}
+
+// rotate rotates a unit grid vector by angle, rounding the result back onto the grid
+func rotate(v pixel.Vec, angle float64) pixel.Vec {
+	sin, cos := math.Sincos(angle)
+	return pixel.V(math.Floor(v.X*cos-v.Y*sin+0.5), math.Floor(v.X*sin+v.Y*cos+0.5))
+}
+
+// clamp limits i to the range 0 to size-1
+func clamp(i int, size int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= size {
+		return size - 1
+	}
+	return i
+}
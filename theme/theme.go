@@ -0,0 +1,360 @@
+package theme
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shibukawa/configdir"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// Shape defines how a part of the game is drawn
+type Shape string
+
+const (
+	// Square draws a filled square the size of a grid cell.
+	Square Shape = "square"
+	// Round draws a filled circle the size of a grid cell.
+	Round Shape = "round"
+	// Diamond draws a filled square rotated onto its corner.
+	Diamond Shape = "diamond"
+)
+
+// Fonts are the font faces a theme can choose from by name
+var Fonts = map[string]font.Face{
+	"basic": basicfont.Face7x13,
+	"bold":  bold(basicfont.Face7x13),
+}
+
+// bold returns a heavier copy of a basic font face, made by drawing each glyph a second time one pixel to the right.
+// The glyphs are one pixel wider so they are spaced one pixel further apart.
+func bold(face *basicfont.Face) *basicfont.Face {
+	b := *face
+	b.Width++
+	b.Advance++
+	bounds := face.Mask.Bounds()
+	mask := image.NewAlpha(image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X+1, bounds.Max.Y))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			_, _, _, a := face.Mask.At(x, y).RGBA()
+			if a == 0 {
+				continue
+			}
+			mask.SetAlpha(x, y, color.Alpha{255})
+			mask.SetAlpha(x+1, y, color.Alpha{255})
+		}
+	}
+	b.Mask = mask
+	return &b
+}
+
+// Type defines the colours and shapes the game is drawn with
+type Type struct {
+	Name       string
+	Letterbox  color.RGBA
+	Background color.RGBA
+	Border     color.RGBA
+	Text       color.RGBA
+	SnakeHead  color.RGBA
+	SnakeBody  color.RGBA
+	SnakeTail  color.RGBA
+	Berry      color.RGBA
+	// GoldenBerry, PoisonBerry and SlowBerry are the colours of the other kinds of berry
+	GoldenBerry color.RGBA
+	PoisonBerry color.RGBA
+	SlowBerry   color.RGBA
+	// PowerUp is the colour of the power-ups
+	PowerUp color.RGBA
+	// Portal is the colour of the rings portals are drawn with
+	Portal color.RGBA
+	// Hazard is the colour of the hazards which move around some levels
+	Hazard color.RGBA
+	// Rival is the colour of the snakes played by the computer
+	Rival      color.RGBA
+	HeadShape  Shape
+	TailShape  Shape
+	BerryShape Shape
+	Font       string
+	// Sprites is the path of a sprite sheet to draw the snake with, if it is empty the shapes are used
+	Sprites string
+	// Outlines and TextScale aren't read from theme files, they are set from the accessibility options
+	Outlines  bool
+	TextScale float64
+}
+
+// Classic is the original look of the game
+var Classic = Type{
+	Name:        "Classic",
+	Letterbox:   colornames.Black,
+	Background:  colornames.Darkcyan,
+	Border:      colornames.White,
+	Text:        colornames.Black,
+	SnakeHead:   colornames.Purple,
+	SnakeBody:   colornames.Purple,
+	SnakeTail:   colornames.Purple,
+	Berry:       colornames.Orangered,
+	GoldenBerry: colornames.Gold,
+	PoisonBerry: colornames.Chartreuse,
+	SlowBerry:   colornames.Lightskyblue,
+	PowerUp:     colornames.White,
+	Portal:      colornames.Yellow,
+	Hazard:      colornames.Black,
+	Rival:       colornames.Navy,
+	HeadShape:   Square,
+	TailShape:   Square,
+	BerryShape:  Round,
+	Font:        "basic",
+	TextScale:   1,
+}
+
+// HighContrast uses bright colours on black for players who find the classic colours hard to tell apart
+var HighContrast = Type{
+	Name:        "High Contrast",
+	Letterbox:   colornames.Black,
+	Background:  colornames.Black,
+	Border:      colornames.White,
+	Text:        colornames.White,
+	SnakeHead:   colornames.White,
+	SnakeBody:   colornames.Yellow,
+	SnakeTail:   colornames.Yellow,
+	Berry:       colornames.Magenta,
+	GoldenBerry: colornames.Orange,
+	PoisonBerry: colornames.Lime,
+	SlowBerry:   colornames.Cyan,
+	PowerUp:     colornames.Red,
+	Portal:      colornames.Dodgerblue,
+	Hazard:      colornames.Silver,
+	Rival:       colornames.Mediumpurple,
+	HeadShape:   Square,
+	TailShape:   Square,
+	BerryShape:  Diamond,
+	Font:        "basic",
+	TextScale:   1,
+}
+
+// Night is a dark theme which is easy on the eyes
+var Night = Type{
+	Name:        "Night",
+	Letterbox:   colornames.Black,
+	Background:  colornames.Midnightblue,
+	Border:      colornames.Slategray,
+	Text:        colornames.Lightsteelblue,
+	SnakeHead:   colornames.Springgreen,
+	SnakeBody:   colornames.Mediumseagreen,
+	SnakeTail:   colornames.Seagreen,
+	Berry:       colornames.Gold,
+	GoldenBerry: colornames.Orange,
+	PoisonBerry: colornames.Orchid,
+	SlowBerry:   colornames.Deepskyblue,
+	PowerUp:     colornames.Hotpink,
+	Portal:      colornames.Violet,
+	Hazard:      colornames.Crimson,
+	Rival:       colornames.Sandybrown,
+	HeadShape:   Round,
+	TailShape:   Round,
+	BerryShape:  Round,
+	Font:        "basic",
+	TextScale:   1,
+}
+
+// Retro looks like an old handheld console. The power-ups, hazards and rival snakes each have their own shade of
+// green so they can be told apart from the snake's head, even in the terminal where everything is a square.
+var Retro = Type{
+	Name:        "Retro",
+	Letterbox:   color.RGBA{15, 56, 15, 255},
+	Background:  color.RGBA{155, 188, 15, 255},
+	Border:      color.RGBA{48, 98, 48, 255},
+	Text:        color.RGBA{15, 56, 15, 255},
+	SnakeHead:   color.RGBA{15, 56, 15, 255},
+	SnakeBody:   color.RGBA{48, 98, 48, 255},
+	SnakeTail:   color.RGBA{48, 98, 48, 255},
+	Berry:       color.RGBA{15, 56, 15, 255},
+	GoldenBerry: color.RGBA{15, 56, 15, 255},
+	PoisonBerry: color.RGBA{15, 56, 15, 255},
+	SlowBerry:   color.RGBA{15, 56, 15, 255},
+	PowerUp:     color.RGBA{98, 128, 48, 255},
+	Portal:      color.RGBA{48, 98, 48, 255},
+	Hazard:      color.RGBA{64, 64, 24, 255},
+	Rival:       color.RGBA{88, 120, 88, 255},
+	HeadShape:   Square,
+	TailShape:   Square,
+	BerryShape:  Square,
+	Font:        "basic",
+	TextScale:   1,
+}
+
+// ColourSafe uses the Okabe-Ito palette, which can be told apart with any of the common forms of colour blindness
+var ColourSafe = Type{
+	Name:        "Colour Safe",
+	Letterbox:   colornames.Black,
+	Background:  colornames.Black,
+	Border:      color.RGBA{240, 228, 66, 255},
+	Text:        colornames.White,
+	SnakeHead:   color.RGBA{86, 180, 233, 255},
+	SnakeBody:   color.RGBA{0, 114, 178, 255},
+	SnakeTail:   color.RGBA{0, 114, 178, 255},
+	Berry:       color.RGBA{230, 159, 0, 255},
+	GoldenBerry: color.RGBA{240, 228, 66, 255},
+	PoisonBerry: color.RGBA{213, 94, 0, 255},
+	SlowBerry:   color.RGBA{0, 158, 115, 255},
+	PowerUp:     color.RGBA{204, 121, 167, 255},
+	Portal:      colornames.White,
+	Hazard:      color.RGBA{153, 153, 153, 255},
+	Rival:       color.RGBA{204, 121, 167, 255},
+	HeadShape:   Round,
+	TailShape:   Square,
+	BerryShape:  Diamond,
+	Font:        "basic",
+	TextScale:   1,
+}
+
+// ColourSafeLight is the Okabe-Ito palette on a light background
+var ColourSafeLight = Type{
+	Name:        "Colour Safe Light",
+	Letterbox:   colornames.Black,
+	Background:  colornames.White,
+	Border:      colornames.Black,
+	Text:        colornames.Black,
+	SnakeHead:   color.RGBA{0, 0, 0, 255},
+	SnakeBody:   color.RGBA{0, 114, 178, 255},
+	SnakeTail:   color.RGBA{0, 114, 178, 255},
+	Berry:       color.RGBA{213, 94, 0, 255},
+	GoldenBerry: color.RGBA{230, 159, 0, 255},
+	PoisonBerry: color.RGBA{204, 121, 167, 255},
+	SlowBerry:   color.RGBA{0, 158, 115, 255},
+	PowerUp:     color.RGBA{86, 180, 233, 255},
+	Portal:      colornames.Black,
+	Hazard:      color.RGBA{153, 153, 153, 255},
+	Rival:       color.RGBA{204, 121, 167, 255},
+	HeadShape:   Round,
+	TailShape:   Square,
+	BerryShape:  Diamond,
+	Font:        "basic",
+	TextScale:   1,
+}
+
+// BuiltIn returns the themes which come with the game
+func BuiltIn() []Type {
+	return []Type{Classic, HighContrast, Night, Retro, ColourSafe, ColourSafeLight}
+}
+
+// LoadThemes returns the built in themes followed by any theme files in the game's config folder.
+// Theme files are named theme_<name>.csv, files which can't be loaded are skipped.
+func LoadThemes() []Type {
+	themes := BuiltIn()
+	for _, folder := range configdir.New("benjmarshall", "gopixelsnake").QueryFolders(configdir.All) {
+		files, err := filepath.Glob(filepath.Join(folder.Path, "theme_*.csv"))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			t, err := LoadFile(file)
+			if err != nil {
+				continue
+			}
+			themes = append(themes, t)
+		}
+	}
+	return themes
+}
+
+// LoadFile loads a theme from a csv file of key,value records. Anything not set in the file is taken from the
+// classic theme, colours are written as #rrggbb and shapes as square, round or diamond. A sprite sheet path
+// is relative to the folder the theme file is in.
+func LoadFile(filename string) (Type, error) {
+	t := Classic
+	t.Name = strings.TrimSuffix(strings.TrimPrefix(filepath.Base(filename), "theme_"), ".csv")
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return t, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return t, err
+	}
+
+	colours := map[string]*color.RGBA{
+		"letterbox":   &t.Letterbox,
+		"background":  &t.Background,
+		"border":      &t.Border,
+		"text":        &t.Text,
+		"snakehead":   &t.SnakeHead,
+		"snakebody":   &t.SnakeBody,
+		"snaketail":   &t.SnakeTail,
+		"berry":       &t.Berry,
+		"goldenberry": &t.GoldenBerry,
+		"poisonberry": &t.PoisonBerry,
+		"slowberry":   &t.SlowBerry,
+		"powerup":     &t.PowerUp,
+		"portal":      &t.Portal,
+		"hazard":      &t.Hazard,
+		"rival":       &t.Rival,
+	}
+	shapes := map[string]*Shape{
+		"headshape":  &t.HeadShape,
+		"tailshape":  &t.TailShape,
+		"berryshape": &t.BerryShape,
+	}
+	for _, record := range records {
+		if len(record) != 2 {
+			return t, errors.New("theme records must be key,value")
+		}
+		key := strings.ToLower(strings.TrimSpace(record[0]))
+		value := strings.TrimSpace(record[1])
+		if c, ok := colours[key]; ok {
+			if *c, err = parseColour(value); err != nil {
+				return t, err
+			}
+		} else if s, ok := shapes[key]; ok {
+			*s = Shape(strings.ToLower(value))
+			if *s != Square && *s != Round && *s != Diamond {
+				return t, fmt.Errorf("unknown shape %q", value)
+			}
+		} else if key == "name" {
+			t.Name = value
+		} else if key == "sprites" {
+			t.Sprites = value
+			if !filepath.IsAbs(value) {
+				t.Sprites = filepath.Join(filepath.Dir(filename), value)
+			}
+		} else if key == "font" {
+			if _, ok := Fonts[value]; !ok {
+				return t, fmt.Errorf("unknown font %q", value)
+			}
+			t.Font = value
+		} else {
+			return t, fmt.Errorf("unknown theme setting %q", record[0])
+		}
+	}
+	return t, nil
+}
+
+// GetFace returns the font face the theme's text is drawn with
+func (t *Type) GetFace() font.Face {
+	face, ok := Fonts[t.Font]
+	if !ok {
+		return basicfont.Face7x13
+	}
+	return face
+}
+
+// parseColour parses a colour written as #rrggbb
+func parseColour(value string) (color.RGBA, error) {
+	c := color.RGBA{A: 255}
+	_, err := fmt.Sscanf(value, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	if err != nil {
+		return c, fmt.Errorf("colour %q must be written as #rrggbb", value)
+	}
+	return c, nil
+}
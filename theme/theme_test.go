@@ -0,0 +1,92 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// drawLine returns the mask of a line of text drawn with the face
+func drawLine(face font.Face, line string) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, font.MeasureString(face, line).Ceil(), face.Metrics().Height.Ceil()))
+	d := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Metrics().Ascent.Ceil())}
+	d.DrawString(line)
+	return mask
+}
+
+func TestBoldFont(t *testing.T) {
+	const line = "Score 1234"
+	basic := drawLine(Fonts["basic"], line)
+	heavy := drawLine(Fonts["bold"], line)
+	if heavy.Bounds().Dx() <= basic.Bounds().Dx() {
+		t.Errorf("the bold line is %d pixels wide, want wider than the basic %d", heavy.Bounds().Dx(), basic.Bounds().Dx())
+	}
+	count := func(mask *image.Alpha) int {
+		n := 0
+		for _, a := range mask.Pix {
+			if a > 0 {
+				n++
+			}
+		}
+		return n
+	}
+	if count(heavy) <= count(basic) {
+		t.Errorf("the bold line sets %d pixels, want more than the basic %d", count(heavy), count(basic))
+	}
+}
+
+func TestLoadFileFont(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"basic", "basic", false},
+		{"bold", "bold", false},
+		{"serif", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "theme_test.csv")
+			if err := os.WriteFile(filename, []byte("name,Test\nfont,"+tt.value+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			th, err := LoadFile(filename)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("loading font %q didn't return an error", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if th.Font != tt.want || th.GetFace() != Fonts[tt.want] {
+				t.Errorf("font is %q, want %q", th.Font, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetroColours(t *testing.T) {
+	parts := map[string]color.RGBA{
+		"background": Retro.Background,
+		"snake head": Retro.SnakeHead,
+		"snake body": Retro.SnakeBody,
+		"power-up":   Retro.PowerUp,
+		"hazard":     Retro.Hazard,
+		"rival":      Retro.Rival,
+	}
+	for a, ca := range parts {
+		for b, cb := range parts {
+			if a < b && ca == cb {
+				t.Errorf("the %s and %s are both %v", a, b, ca)
+			}
+		}
+	}
+}